@@ -0,0 +1,11 @@
+data "trustbuilder_list_file" "users" {
+  path        = "/api/users"
+  results_key = "data.items"
+  next_key    = "links.next"
+  total_key   = "meta.total"
+  output_path = "${path.module}/users.json"
+}
+
+output "users_count" {
+  value = data.trustbuilder_list_file.users.count
+}
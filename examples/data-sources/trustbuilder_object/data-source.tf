@@ -0,0 +1,12 @@
+data "trustbuilder_object" "admins" {
+  path         = "/api/groups"
+  search_key   = "name"
+  search_value = "admins"
+  results_key  = "data.items"
+}
+
+resource "trustbuilder_json_field" "admins_group" {
+  path  = "/configs/main"
+  field = "access.admins_group_id"
+  value = jsonencode(data.trustbuilder_object.admins.id)
+}
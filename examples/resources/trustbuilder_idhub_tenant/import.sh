@@ -1 +1,5 @@
+# With the API path and the tenant name
 terraform import trustbuilder_idhub_tenant.test "path,tenant"
+
+# With only the tenant name, when the provider default_path is set
+terraform import trustbuilder_idhub_tenant.test "tenant"
@@ -0,0 +1,5 @@
+resource "trustbuilder_json_field" "new_ui" {
+  path  = "/configs/main"
+  field = "features.new_ui"
+  value = jsonencode(true)
+}
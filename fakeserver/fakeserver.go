@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"time"
 )
@@ -14,9 +15,12 @@ import (
 /*Fakeserver represents a HTTP server with objects to hold and return.*/
 type Fakeserver struct {
 	server  *http.Server
+	mux     *http.ServeMux
 	objects map[string]map[string]interface{}
-	debug   bool
-	running bool
+	/* Objects of the /api/objects/{id}/children collections, by parent id */
+	children map[string]map[string]map[string]interface{}
+	debug    bool
+	running  bool
 }
 
 /*NewFakeServer creates a HTTP server used for tests and debugging.*/
@@ -24,9 +28,11 @@ func NewFakeServer(iPort int, iObjects map[string]map[string]interface{}, iStart
 	serverMux := http.NewServeMux()
 
 	svr := &Fakeserver{
-		debug:   iDebug,
-		objects: iObjects,
-		running: false,
+		mux:      serverMux,
+		debug:    iDebug,
+		objects:  iObjects,
+		children: make(map[string]map[string]map[string]interface{}),
+		running:  false,
 	}
 
 	//If we were passed an argument for where to serve /static from...
@@ -85,11 +91,51 @@ func (svr *Fakeserver) Running() bool {
 	return svr.running
 }
 
+/*
+decodeObject decodes the JSON object of a request body, keeping its numbers
+as written, e.g. large integers and high-precision decimals.
+*/
+func decodeObject(b []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(string(b)))
+	decoder.UseNumber()
+	var obj map[string]interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
 /*GetServer returns the server object itself.*/
 func (svr *Fakeserver) GetServer() *http.Server {
 	return svr.server
 }
 
+/*
+HandleMethods registers handlers answering the given path per HTTP method.
+Any other method is rejected with 405 Method Not Allowed and an Allow header,
+so tests can assert the provider uses the configured verb.
+*/
+func (svr *Fakeserver) HandleMethods(path string, handlers map[string]http.HandlerFunc) {
+	allowed := make([]string, 0, len(handlers))
+	for method := range handlers {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+
+	svr.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.Method]
+		if !ok {
+			if svr.debug {
+				log.Printf("fakeserver.go: Method %s not allowed on %s\n", r.Method, path)
+			}
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	})
+}
+
 func (svr *Fakeserver) handleAPIObject(w http.ResponseWriter, r *http.Request) {
 	var obj map[string]interface{}
 	var id string
@@ -137,6 +183,9 @@ func (svr *Fakeserver) handleAPIObject(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 			return
 		}
+	} else if len(parts) == 5 && parts[2] == "objects" && parts[4] == "children" {
+		svr.handleChildren(w, r, parts[3], b)
+		return
 	} else if path == "/api/objects" && r.Method == "GET" && len(r.URL.Query()) != 0 {
 		// filter by params
 		result := make([]map[string]any, 0)
@@ -205,7 +254,8 @@ func (svr *Fakeserver) handleAPIObject(w http.ResponseWriter, r *http.Request) {
 			log.Printf("fakeserver.go: data sent - unmarshalling from JSON: %s\n", string(b))
 		}
 
-		err := json.Unmarshal(b, &obj)
+		var err error
+		obj, err = decodeObject(b)
 		if err != nil {
 			/* Failure goes back to the user as a 500. Log data here for
 			   debugging (which shouldn't ever fail!) */
@@ -261,3 +311,61 @@ func (svr *Fakeserver) handleAPIObject(w http.ResponseWriter, r *http.Request) {
 		log.Fatalf("fakeserver.go: Error on data sent retry %s\n", err)
 	}
 }
+
+/*
+handleChildren serves the collection of the children of an object, for
+resources whose path holds the id of their parent: POST creates a child,
+which must have an id, and GET lists the children matching the query
+parameters, like /api/objects. The parent must exist.
+*/
+func (svr *Fakeserver) handleChildren(w http.ResponseWriter, r *http.Request, parentId string, b []byte) {
+	if _, ok := svr.objects[parentId]; !ok {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	children, ok := svr.children[parentId]
+	if !ok {
+		children = make(map[string]map[string]interface{})
+		svr.children[parentId] = children
+	}
+
+	switch r.Method {
+	case "POST":
+		obj, err := decodeObject(b)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		val, ok := obj["id"]
+		if !ok {
+			http.Error(w, "POST sent with no id field in the data. Cannot persist this!", http.StatusBadRequest)
+			return
+		}
+		id := fmt.Sprintf("%v", val)
+		if _, ok := children[id]; ok {
+			http.Error(w, "POST sent with an existing id. Cannot persist this!", http.StatusBadRequest)
+			return
+		}
+		if svr.debug {
+			log.Printf("fakeserver.go: Writing child %s of %s with new data:%+v\n", id, parentId, obj)
+		}
+		children[id] = obj
+		b, _ = json.Marshal(obj)
+	case "GET":
+		result := make([]map[string]interface{}, 0)
+		for _, hash := range children {
+			for key, value := range hash {
+				if r.URL.Query().Has(key) && r.URL.Query().Get(key) == value {
+					result = append(result, hash)
+				}
+			}
+		}
+		b, _ = json.Marshal(result)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if _, err := w.Write(b); err != nil {
+		log.Fatalf("fakeserver.go: Can not write the json in http response to %s: %s\n", r.URL.Path, err)
+	}
+}
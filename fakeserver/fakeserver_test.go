@@ -0,0 +1,92 @@
+package fakeserver
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFakeserver_HandleMethods(t *testing.T) {
+	svr := NewFakeServer(19091, make(map[string]map[string]interface{}), false, false, "")
+	svr.HandleMethods("/methods/object", map[string]http.HandlerFunc{
+		"PATCH": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("patched"))
+		},
+		"GET": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("read"))
+		},
+	})
+	svr.StartInBackground()
+	defer svr.Shutdown()
+
+	tests := []struct {
+		method string
+		status int
+		body   string
+	}{
+		{"GET", http.StatusOK, "read"},
+		{"PATCH", http.StatusOK, "patched"},
+		{"PUT", http.StatusMethodNotAllowed, "Method Not Allowed"},
+		{"DELETE", http.StatusMethodNotAllowed, "Method Not Allowed"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, "http://127.0.0.1:19091/methods/object", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s request failed: %s", test.method, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.status {
+			t.Errorf("%s returned the status %d; want %d", test.method, resp.StatusCode, test.status)
+		}
+		if strings.TrimSpace(string(body)) != test.body {
+			t.Errorf("%s returned the body %q; want %q", test.method, body, test.body)
+		}
+		if test.status == http.StatusMethodNotAllowed && resp.Header.Get("Allow") != "GET, PATCH" {
+			t.Errorf("%s returned the Allow header %q; want %q", test.method, resp.Header.Get("Allow"), "GET, PATCH")
+		}
+	}
+}
+
+func TestFakeserver_children(t *testing.T) {
+	objects := map[string]map[string]interface{}{
+		"1": {"id": "1", "identifier": "parent_1"},
+	}
+	svr := NewFakeServer(19094, objects, true, false, "")
+	defer svr.Shutdown()
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		result string
+	}{
+		{"POST", "/api/objects/1/children", `{"id":"11","identifier":"child_11"}`, http.StatusOK, `{"id":"11","identifier":"child_11"}`},
+		{"POST", "/api/objects/1/children", `{"id":"11","identifier":"child_11"}`, http.StatusBadRequest, "POST sent with an existing id. Cannot persist this!"},
+		{"POST", "/api/objects/1/children", `{"identifier":"child_12"}`, http.StatusBadRequest, "POST sent with no id field in the data. Cannot persist this!"},
+		{"GET", "/api/objects/1/children?identifier=child_11", "", http.StatusOK, `[{"id":"11","identifier":"child_11"}]`},
+		{"GET", "/api/objects/1/children?identifier=child_12", "", http.StatusOK, `[]`},
+		{"POST", "/api/objects/2/children", `{"id":"21","identifier":"child_21"}`, http.StatusNotFound, "Not Found"},
+		{"DELETE", "/api/objects/1/children", "", http.StatusMethodNotAllowed, "Method Not Allowed"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, "http://127.0.0.1:19094"+test.path, strings.NewReader(test.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %s", test.method, test.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.status {
+			t.Errorf("%s %s returned the status %d; want %d", test.method, test.path, resp.StatusCode, test.status)
+		}
+		if strings.TrimSpace(string(body)) != test.result {
+			t.Errorf("%s %s returned the body %q; want %q", test.method, test.path, body, test.result)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package apiclient
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -10,7 +12,7 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
+	"mime"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
@@ -20,24 +22,59 @@ import (
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/sync/semaphore"
 	"golang.org/x/time/rate"
 
 	jwtgen "github.com/golang-jwt/jwt/v5"
 )
 
+// UTF-8 byte order mark, stripped from the response bodies.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// DefaultJwtAlgorithm is the signing algorithm used when none is configured.
+const DefaultJwtAlgorithm = "HS256"
+
+// Header and scheme carrying the JWT and OAuth tokens when none is configured.
+const (
+	DefaultAuthHeaderName   = "Authorization"
+	DefaultAuthHeaderPrefix = "Bearer"
+)
+
 type JwtHashedToken struct {
 	Secret                 []byte
 	Algortithm             string
 	Claims                 map[string]any
 	ValidityDurationMinute int64
+	// Private key of the asymmetric algorithms, used instead of Secret.
+	PrivateKey crypto.Signer
+	// DER certificate chain of PrivateKey sent in the x5c header, leaf first.
+	CertificateChain [][]byte
 }
 
 type ApiClientOpt struct {
-	Uri                 string
-	Jwt                 *JwtHashedToken
-	Insecure            bool
-	Username            string
-	Password            string
+	Uri      string
+	Jwt      *JwtHashedToken
+	Insecure bool
+	Username string
+	Password string
+	// Static token sent in the AuthHeaderName header, e.g. read from a .netrc file.
+	Token string
+	// Login request opening the session of the other requests.
+	Login *SessionLogin
+	// Sends a request answered 401 once more with new credentials.
+	ReauthOn401 bool
+	// Status codes of a successful response per operation, replacing the
+	// default check of a 2xx code, e.g. 201 for the creations.
+	ExpectedStatus map[Operation][]int
+	// Language of the responses, e.g. "fr-FR", sent in the Accept-Language
+	// header of all the requests, the token requests included.
+	AcceptLanguage string
+	// Header receiving the idempotency key of the create requests, e.g.
+	// "Idempotency-Key", none when empty. The key is the same for all the
+	// attempts of a create.
+	IdempotencyKeyHeader string
+	// Messages replacing the generic error of the API errors, by status code.
+	StatusMessages      map[int]string
 	Headers             map[string]string
 	Timeout             int64
 	IdAttribute         string
@@ -52,6 +89,15 @@ type ApiClientOpt struct {
 	WriteReturnsObject  bool
 	CreateReturnsObject bool
 	XssiPrefix          string
+	// Format of the response bodies, ResponseFormatJson when empty. The JSON
+	// Lines bodies of the successful responses are converted into arrays.
+	ResponseFormat string
+	// Dot-separated path of the errors in the response bodies, e.g. "errors".
+	// A successful response with errors at this path fails the request.
+	ResponseErrorsPath string
+	// Dot-separated path of the objects, or the arrays of objects, in the
+	// response bodies, e.g. "data" (see ResponseAtRoot).
+	ResponseRoot        string
 	UseCookies          bool
 	RateLimit           float64
 	OauthClientID       string
@@ -59,39 +105,149 @@ type ApiClientOpt struct {
 	OauthScopes         []string
 	OauthTokenURL       string
 	OauthEndpointParams url.Values
-	CertFile            string
-	KeyFile             string
-	RootCaFile          string
-	CertString          string
-	KeyString           string
-	RootCaString        string
+	OauthAudience       string
+	OauthRefreshToken   string
+	OauthTokenFile      string
+	// Client authentication of the token requests, detected on the first
+	// request when empty. OauthTlsClientAuth sends no client secret, the
+	// client certificate of the token requests authenticating the client.
+	OauthAuthMethod OauthAuthMethod
+	// Root CA and client certificate of the token endpoint, when its PKI
+	// differs from the API's. When one is set, the token requests use their
+	// own HTTP client, without the API TLS settings.
+	OauthRootCaFile string
+	OauthCertFile   string
+	OauthKeyFile    string
+	CertFile        string
+	KeyFile         string
+	RootCaFile      string
+	CertString      string
+	KeyString       string
+	RootCaString    string
+	// Load the client certificate of CertFile and KeyFile, or Pkcs12File,
+	// again when it changes on disk.
+	CertReload bool
+	// Send the requests of http:// URIs in cleartext HTTP/2 (h2c) with prior
+	// knowledge, without upgrade from HTTP/1.1. The https:// URIs then
+	// require HTTP/2 too.
+	Http2PriorKnowledge bool
+	// Header and scheme carrying the JWT and OAuth tokens, "Authorization"
+	// and "Bearer" when empty.
+	AuthHeaderName   string
+	AuthHeaderPrefix string
+	Pkcs12File       string
+	Pkcs12Password   string
+	PinnedCertSha256 string
+	// With PinnedCertSha256, trusts the pinned certificate without validating its chain.
+	PinnedCertOnly         bool
+	StripHeadersOnRedirect []string
+	// Returns the 3xx responses instead of following them. They fail like any
+	// status code out of the ExpectedStatus of the operation.
+	NoFollowRedirects bool
+	// Rejects the redirects to another host than the original request's.
+	RestrictRedirectsToSameHost bool
+	MaxConcurrentRequests       int64
+	JsonDecodeRetries           int64
+	// Escapes <, > and & in the JSON request bodies like json.Marshal, e.g.
+	// "\u0026", instead of sending them as is.
+	EscapeHTML bool
+	// Minimum size in bytes of the request bodies sent gzip encoded, 0
+	// disabling the compression.
+	CompressRequestMinBytes int64
+	// Maximum size in bytes of the decoded response bodies, 0 for no limit.
+	MaxResponseSize      int64
+	AppendTrailingSlash  bool
+	TraceHttp            bool
+	IdentifierQueryParam string
+	DefaultPath          string
+	// Number of times a retryable failure is sent again, 0 disabling retries.
+	MaxRetries int64
+	// Errors retried whatever their status code, in addition to the 429, 502,
+	// 503 and 504 responses and the connection failures.
+	RetryConditions []RetryCondition
+	// Jitter of the backoff between retries, JitterFull when empty.
+	RetryJitter JitterStrategy
+	// Fails all the next requests at once after a request failed with a 5xx,
+	// its retries included.
+	FailFast bool
+	// Upper bound in seconds of the wait between two attempts, 30 when 0.
+	RetryMaxWait int64
+	// Time budget in seconds of a request and its retries, 0 for no limit.
+	// No retry is sent once its wait would exceed the budget.
+	RetryMaxElapsedTime int64
 	Debug               bool
+	// File receiving the debug output instead of the standard logger (STDERR).
+	DebugLogFile string
+	// Length in bytes of the request and response bodies in the debug
+	// output, the rest being cut, 0 for no limit.
+	MaxLogBodyBytes int64
+	// Replaces the request and response bodies of the debug output and of
+	// the HTTP traces with their size, e.g. for bodies holding secrets.
+	RedactLogBodies bool
 }
 
 /*APIClient is a HTTP client with additional controlling fields.*/
 type APIClient struct {
-	HttpClient          *http.Client
-	Uri                 string
-	Jwt                 *JwtHashedToken
-	Insecure            bool
-	Username            string
-	Password            string
-	Headers             map[string]string
-	IdAttribute         string
-	CreateMethod        string
-	ReadMethod          string
-	ReadData            string
-	UpdateMethod        string
-	UpdateData          string
-	DestroyMethod       string
-	DestroyData         string
-	CopyKeys            []string
-	WriteReturnsObject  bool
-	CreateReturnsObject bool
-	XssiPrefix          string
-	RateLimiter         *rate.Limiter
-	Debug               bool
-	OauthConfig         *clientcredentials.Config
+	HttpClient              *http.Client
+	Uri                     string
+	Jwt                     *JwtHashedToken
+	Insecure                bool
+	Username                string
+	Password                string
+	Token                   string
+	Login                   *SessionLogin
+	ReauthOn401             bool
+	StatusMessages          map[int]string
+	ExpectedStatus          map[Operation][]int
+	Headers                 map[string]string
+	AcceptLanguage          string
+	IdempotencyKeyHeader    string
+	IdAttribute             string
+	CreateMethod            string
+	ReadMethod              string
+	ReadData                string
+	UpdateMethod            string
+	UpdateData              string
+	DestroyMethod           string
+	DestroyData             string
+	CopyKeys                []string
+	WriteReturnsObject      bool
+	CreateReturnsObject     bool
+	XssiPrefix              string
+	ResponseErrorsPath      string
+	ResponseFormat          string
+	ResponseRoot            string
+	RateLimiter             *rate.Limiter
+	ConcurrencyLimiter      *semaphore.Weighted
+	JsonDecodeRetries       int64
+	EscapeHTML              bool
+	MaxResponseSize         int64
+	CompressRequestMinBytes int64
+	AppendTrailingSlash     bool
+	Timeout                 time.Duration
+	TraceHttp               bool
+	IdentifierQueryParam    string
+	DefaultPath             string
+	AuthHeaderName          string
+	AuthHeaderPrefix        string
+	MaxRetries              int64
+	RetryConditions         []RetryCondition
+	RetryJitter             JitterStrategy
+	retryBaseWait           time.Duration
+	retryMaxWait            time.Duration
+	retryMaxElapsedTime     time.Duration
+	failFast                *failFastBreaker
+	Debug                   bool
+	MaxLogBodyBytes         int64
+	RedactLogBodies         bool
+	Logger                  *log.Logger
+	OauthConfig             *clientcredentials.Config
+	OauthRefreshTokenSource *RefreshTokenSource
+	OauthAuthMethod         OauthAuthMethod
+	// HTTP client of the token requests, HttpClient when nil.
+	OauthHttpClient *http.Client
+	// Reloader of the client certificate when cert_reload is set.
+	certReloader *certReloader
 }
 
 func (jwt *JwtHashedToken) completeClaimValidityTime() {
@@ -105,19 +261,28 @@ func (jwt *JwtHashedToken) completeClaimValidityTime() {
 
 func (jwt *JwtHashedToken) getSignedJwt() (string, error) {
 	signer := jwtgen.GetSigningMethod(jwt.Algortithm)
+	if signer == nil {
+		return "", fmt.Errorf("unsupported JWT signing algorithm: '%s'", jwt.Algortithm)
+	}
 	token := jwtgen.NewWithClaims(signer, jwtgen.MapClaims(jwt.Claims))
+	if len(jwt.CertificateChain) > 0 {
+		token.Header["x5c"] = x5cHeader(jwt.CertificateChain)
+	}
 
+	if jwt.PrivateKey != nil {
+		return token.SignedString(jwt.PrivateKey)
+	}
 	return token.SignedString(jwt.Secret)
 }
 
-// Returns a map from the given string in JSON format.
-// If the JSON is an array, only the first object is converted.
+// Returns a map from the given string in JSON format, the numbers kept as
+// json.Number. If the JSON is an array, only the first object is converted.
 func JsonDecodeApiResponse(jsonData string) (map[string]any, error) {
-	var data any
 	var mapData map[string]any
 	var ok bool
 
-	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+	data, err := decodeJsonNumbers(jsonData)
+	if err != nil {
 		return nil, err
 	}
 
@@ -144,15 +309,76 @@ func JsonDecodeApiResponse(jsonData string) (map[string]any, error) {
 	return mapData, nil
 }
 
+// JsonMarshal is json.Marshal without escaping <, > and &: the encoded bodies
+// and values keep them as is, for the APIs comparing the bodies byte for byte.
+// The client EscapeHTML escapes them in the request bodies.
+func JsonMarshal(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
 func JsonEncode(data map[string]any) (string, error) {
 
-	jsonBytes, err := json.Marshal(data)
+	jsonBytes, err := JsonMarshal(data)
 	if err != nil {
 		return "", fmt.Errorf("the data can't be encoded into JSON: %v", data)
 	}
 	return string(jsonBytes), err
 }
 
+// Encodes a decoded JSON value, e.g. from decodeJsonNumbers.
+func encodeJsonValue(data any) (string, error) {
+	jsonBytes, err := JsonMarshal(data)
+	if err != nil {
+		return "", fmt.Errorf("the data can't be encoded into JSON: %v", data)
+	}
+	return string(jsonBytes), nil
+}
+
+// Returns the JSON document re-encoded with sorted object keys and without
+// insignificant whitespace, so that equal documents are sent byte for byte
+// the same. Numbers keep their original representation.
+func CanonicalJson(jsonData string) (string, error) {
+	data, err := decodeJsonNumbers(jsonData)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return "", fmt.Errorf("the data can't be encoded into JSON: %v", err)
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// Decodes a single JSON document, keeping the numbers as json.Number.
+func decodeJsonNumbers(jsonData string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(jsonData))
+	decoder.UseNumber()
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("the data can't be decoded from JSON: %v", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("the data holds more than one JSON document")
+	}
+	return data, nil
+}
+
+// Returns true when the API response carries no object. SendRequest returns
+// "{}" for empty bodies, e.g. on 204 No Content.
+func IsEmptyResponse(jsonData string) bool {
+	trimmed := strings.TrimSpace(jsonData)
+	return trimmed == "" || trimmed == "{}"
+}
+
 // If the value of the key is not a string, returns an error.
 func GetKeyValue(jsonData string, key string) (string, error) {
 	var ok bool
@@ -177,8 +403,16 @@ func GetKeyValue(jsonData string, key string) (string, error) {
 
 // NewAPIClient makes a new api client for RESTful calls.
 func NewAPIClient(opt *ApiClientOpt) (*APIClient, error) {
+	logger := log.Default()
+	if opt.Debug && opt.DebugLogFile != "" {
+		logFile, err := os.OpenFile(opt.DebugLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("could not open the debug log file: %v", err)
+		}
+		logger = log.New(logFile, "", log.LstdFlags)
+	}
 	if opt.Debug {
-		log.Printf("api_client.go: Constructing debug api_client\n")
+		logger.Printf("api_client.go: Constructing debug api_client\n")
 	}
 
 	if opt.Uri == "" {
@@ -186,8 +420,8 @@ func NewAPIClient(opt *ApiClientOpt) (*APIClient, error) {
 	}
 
 	/* Sane default */
-	if opt.IdAttribute == "" {
-		opt.IdAttribute = "id"
+	if opt.IdentifierQueryParam == "" {
+		opt.IdentifierQueryParam = "identifier"
 	}
 
 	/* Remove any trailing slashes since we will append
@@ -206,12 +440,55 @@ func NewAPIClient(opt *ApiClientOpt) (*APIClient, error) {
 	if opt.DestroyMethod == "" {
 		opt.DestroyMethod = "DELETE"
 	}
+	if opt.AuthHeaderName == "" {
+		opt.AuthHeaderName = DefaultAuthHeaderName
+	}
+	if opt.AuthHeaderPrefix == "" {
+		opt.AuthHeaderPrefix = DefaultAuthHeaderPrefix
+	}
+	if opt.RetryJitter == "" {
+		opt.RetryJitter = JitterFull
+	}
+	if err := opt.RetryJitter.validate(); err != nil {
+		return nil, err
+	}
+	if err := opt.OauthAuthMethod.validate(); err != nil {
+		return nil, err
+	}
+	if opt.OauthAuthMethod == OauthTlsClientAuth && opt.OauthClientSecret != "" {
+		return nil, errors.New("the OAuth client secret can't be set with the tls_client_auth method, the client being authenticated by its certificate")
+	}
+	retryMaxWait := defaultRetryMaxWait
+	if opt.RetryMaxWait > 0 {
+		retryMaxWait = time.Second * time.Duration(opt.RetryMaxWait)
+	}
+	if opt.Jwt != nil && opt.Jwt.Algortithm == "" {
+		opt.Jwt.Algortithm = DefaultJwtAlgorithm
+	}
+	if opt.Jwt != nil && opt.Jwt.PrivateKey != nil {
+		/* A key of another type than the algorithm's only fails on signing */
+		if _, err := opt.Jwt.getSignedJwt(); err != nil {
+			return nil, fmt.Errorf("the JWT private key can't sign with %s: %v", opt.Jwt.Algortithm, err)
+		}
+	}
 
 	tlsConfig := &tls.Config{
 		/* Disable TLS verification if requested */
 		InsecureSkipVerify: opt.Insecure,
 	}
 
+	if opt.PinnedCertSha256 != "" {
+		verifier, err := pinnedCertificateVerifier(opt.PinnedCertSha256)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.VerifyPeerCertificate = verifier
+		/* The pinned fingerprint is then the only check of the server certificate */
+		if opt.PinnedCertOnly {
+			tlsConfig.InsecureSkipVerify = true
+		}
+	}
+
 	if opt.CertString != "" && opt.KeyString != "" {
 		cert, err := tls.X509KeyPair([]byte(opt.CertString), []byte(opt.KeyString))
 		if err != nil {
@@ -228,6 +505,27 @@ func NewAPIClient(opt *ApiClientOpt) (*APIClient, error) {
 		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
+	if opt.Pkcs12File != "" {
+		cert, err := loadPkcs12Certificate(opt.Pkcs12File, opt.Pkcs12Password)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	var reloader *certReloader
+	if opt.CertReload {
+		var err error
+		reloader, err = clientCertReloader(opt)
+		if err != nil {
+			return nil, err
+		}
+		if reloader != nil {
+			tlsConfig.Certificates = nil
+			tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+		}
+	}
+
 	// Load root CA
 	if opt.RootCaFile != "" || opt.RootCaString != "" {
 		caCertPool := x509.NewCertPool()
@@ -236,7 +534,7 @@ func NewAPIClient(opt *ApiClientOpt) (*APIClient, error) {
 
 		if opt.RootCaFile != "" {
 			if opt.Debug {
-				log.Printf("api_client.go: Reading root CA file: %s\n", opt.RootCaFile)
+				logger.Printf("api_client.go: Reading root CA file: %s\n", opt.RootCaFile)
 			}
 			rootCA, err = os.ReadFile(opt.RootCaFile)
 			if err != nil {
@@ -244,7 +542,7 @@ func NewAPIClient(opt *ApiClientOpt) (*APIClient, error) {
 			}
 		} else {
 			if opt.Debug {
-				log.Printf("api_client.go: Using provided root CA string\n")
+				logger.Printf("api_client.go: Using provided root CA string\n")
 			}
 			rootCA = []byte(opt.RootCaString)
 		}
@@ -259,6 +557,17 @@ func NewAPIClient(opt *ApiClientOpt) (*APIClient, error) {
 		TLSClientConfig: tlsConfig,
 		Proxy:           http.ProxyFromEnvironment,
 	}
+	if opt.Http2PriorKnowledge {
+		/* Without HTTP/1, the transport speaks HTTP/2 right away on cleartext connections */
+		protocols := new(http.Protocols)
+		protocols.SetUnencryptedHTTP2(true)
+		protocols.SetHTTP2(true)
+		tr.Protocols = protocols
+	}
+	if reloader != nil {
+		/* The kept-alive connections would go on with the previous certificate */
+		reloader.onReload = tr.CloseIdleConnections
+	}
 
 	var cookieJar http.CookieJar
 
@@ -266,74 +575,231 @@ func NewAPIClient(opt *ApiClientOpt) (*APIClient, error) {
 		cookieJar, _ = cookiejar.New(nil)
 	}
 
-	rateLimit := rate.Limit(opt.RateLimit)
-	bucketSize := int(math.Max(math.Round(opt.RateLimit), 1))
-	log.Printf("limit: %f bucket: %d", opt.RateLimit, bucketSize)
-	rateLimiter := rate.NewLimiter(rateLimit, bucketSize)
+	rateLimiter := newRateLimiter(opt.RateLimit)
+	if opt.Debug {
+		logger.Printf("api_client.go: Rate limit: %s", describeRateLimit(rateLimiter))
+	}
 
 	client := APIClient{
 		HttpClient: &http.Client{
-			Timeout:   time.Second * time.Duration(opt.Timeout),
-			Transport: tr,
-			Jar:       cookieJar,
+			Transport:     tr,
+			Jar:           cookieJar,
+			CheckRedirect: checkRedirect(opt.StripHeadersOnRedirect, opt.RestrictRedirectsToSameHost, opt.NoFollowRedirects),
 		},
-		RateLimiter:         rateLimiter,
-		Uri:                 opt.Uri,
-		Jwt:                 opt.Jwt,
-		Insecure:            opt.Insecure,
-		Username:            opt.Username,
-		Password:            opt.Password,
-		Headers:             opt.Headers,
-		IdAttribute:         opt.IdAttribute,
-		CreateMethod:        opt.CreateMethod,
-		ReadMethod:          opt.ReadMethod,
-		ReadData:            opt.ReadData,
-		UpdateMethod:        opt.UpdateMethod,
-		UpdateData:          opt.UpdateData,
-		DestroyMethod:       opt.DestroyMethod,
-		DestroyData:         opt.DestroyData,
-		CopyKeys:            opt.CopyKeys,
-		WriteReturnsObject:  opt.WriteReturnsObject,
-		CreateReturnsObject: opt.CreateReturnsObject,
-		XssiPrefix:          opt.XssiPrefix,
-		Debug:               opt.Debug,
-	}
-
-	if opt.OauthClientID != "" && opt.OauthClientSecret != "" && opt.OauthTokenURL != "" {
+		RateLimiter:             rateLimiter,
+		Uri:                     opt.Uri,
+		Jwt:                     opt.Jwt,
+		Insecure:                opt.Insecure,
+		Username:                opt.Username,
+		Password:                opt.Password,
+		Token:                   opt.Token,
+		Login:                   opt.Login,
+		ReauthOn401:             opt.ReauthOn401,
+		StatusMessages:          opt.StatusMessages,
+		ExpectedStatus:          opt.ExpectedStatus,
+		Headers:                 opt.Headers,
+		AcceptLanguage:          opt.AcceptLanguage,
+		IdempotencyKeyHeader:    opt.IdempotencyKeyHeader,
+		IdAttribute:             opt.IdAttribute,
+		CreateMethod:            opt.CreateMethod,
+		ReadMethod:              opt.ReadMethod,
+		ReadData:                opt.ReadData,
+		UpdateMethod:            opt.UpdateMethod,
+		UpdateData:              opt.UpdateData,
+		DestroyMethod:           opt.DestroyMethod,
+		DestroyData:             opt.DestroyData,
+		CopyKeys:                opt.CopyKeys,
+		WriteReturnsObject:      opt.WriteReturnsObject,
+		CreateReturnsObject:     opt.CreateReturnsObject,
+		XssiPrefix:              opt.XssiPrefix,
+		ResponseErrorsPath:      opt.ResponseErrorsPath,
+		ResponseFormat:          opt.ResponseFormat,
+		ResponseRoot:            opt.ResponseRoot,
+		JsonDecodeRetries:       opt.JsonDecodeRetries,
+		EscapeHTML:              opt.EscapeHTML,
+		MaxResponseSize:         opt.MaxResponseSize,
+		CompressRequestMinBytes: opt.CompressRequestMinBytes,
+		AppendTrailingSlash:     opt.AppendTrailingSlash,
+		Timeout:                 time.Second * time.Duration(opt.Timeout),
+		TraceHttp:               opt.TraceHttp,
+		IdentifierQueryParam:    opt.IdentifierQueryParam,
+		DefaultPath:             opt.DefaultPath,
+		AuthHeaderName:          opt.AuthHeaderName,
+		AuthHeaderPrefix:        opt.AuthHeaderPrefix,
+		MaxRetries:              opt.MaxRetries,
+		RetryConditions:         opt.RetryConditions,
+		RetryJitter:             opt.RetryJitter,
+		retryBaseWait:           defaultRetryBaseWait,
+		retryMaxWait:            retryMaxWait,
+		retryMaxElapsedTime:     time.Second * time.Duration(opt.RetryMaxElapsedTime),
+		Debug:                   opt.Debug,
+		MaxLogBodyBytes:         opt.MaxLogBodyBytes,
+		RedactLogBodies:         opt.RedactLogBodies,
+		Logger:                  logger,
+		certReloader:            reloader,
+	}
+
+	if opt.FailFast {
+		client.failFast = &failFastBreaker{}
+	}
+
+	if opt.MaxConcurrentRequests > 0 {
+		client.ConcurrencyLimiter = semaphore.NewWeighted(opt.MaxConcurrentRequests)
+	}
+
+	if opt.OauthRootCaFile != "" || opt.OauthCertFile != "" || opt.OauthKeyFile != "" {
+		oauthHttpClient, err := newOauthHttpClient(opt.OauthRootCaFile, opt.OauthCertFile, opt.OauthKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		client.OauthHttpClient = oauthHttpClient
+	}
+
+	endpointParams := oauthEndpointParams(opt.OauthEndpointParams, opt.OauthAudience)
+	if opt.OauthRefreshToken != "" && opt.OauthTokenURL != "" {
+		tokenSource, err := NewRefreshTokenSource(&oauth2.Config{
+			ClientID:     opt.OauthClientID,
+			ClientSecret: opt.OauthClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: opt.OauthTokenURL, AuthStyle: opt.OauthAuthMethod.authStyle()},
+			Scopes:       opt.OauthScopes,
+		}, opt.OauthRefreshToken, opt.OauthTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		tokenSource.endpointParams = endpointParams
+		client.OauthRefreshTokenSource = tokenSource
+	} else if opt.OauthClientID != "" && (opt.OauthClientSecret != "" || opt.OauthAuthMethod == OauthTlsClientAuth) && opt.OauthTokenURL != "" {
 		client.OauthConfig = &clientcredentials.Config{
 			ClientID:       opt.OauthClientID,
 			ClientSecret:   opt.OauthClientSecret,
 			TokenURL:       opt.OauthTokenURL,
 			Scopes:         opt.OauthScopes,
-			EndpointParams: opt.OauthEndpointParams,
+			EndpointParams: endpointParams,
+			AuthStyle:      opt.OauthAuthMethod.authStyle(),
+		}
+	}
+	if (client.OauthConfig != nil || client.OauthRefreshTokenSource != nil) && opt.OauthAuthMethod != "" {
+		client.OauthAuthMethod = opt.OauthAuthMethod
+		if opt.OauthAuthMethod == OauthTlsClientAuth && !hasClientCertificate(client.oauthHttpClient()) {
+			return nil, errors.New("the tls_client_auth OAuth method requires a client certificate of the token requests, the OAuth one or else the API one")
 		}
 	}
 
 	if opt.Debug {
-		log.Printf("api_client.go: Constructed client:\n%s", client.toString())
+		logger.Printf("api_client.go: Constructed client:\n%s", client.toString())
 	}
 	return &client, nil
 }
 
+// Returns a redirect policy removing the given headers from every redirected
+// request, whatever the target host, and, when sameHostOnly is set, rejecting
+// the redirects to another host than the original request's. The default
+// limit of 10 redirects is kept. With noFollow, the redirect responses are
+// returned instead.
+func checkRedirect(stripHeaders []string, sameHostOnly bool, noFollow bool) func(req *http.Request, via []*http.Request) error {
+	if noFollow {
+		return func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	if len(stripHeaders) == 0 && !sameHostOnly {
+		return nil
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if sameHostOnly && len(via) > 0 && req.URL.Host != via[0].URL.Host {
+			return fmt.Errorf("redirect to %s rejected: the host differs from %s", req.URL.Redacted(), via[0].URL.Host)
+		}
+		for _, h := range stripHeaders {
+			req.Header.Del(h)
+		}
+		return nil
+	}
+}
+
+// Ensures the path part of a request path ends with a single slash, keeping
+// the query string and fragment untouched.
+func appendTrailingSlash(path string) string {
+	suffix := ""
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path, suffix = path[:i], path[i:]
+	}
+	return strings.TrimRight(path, "/") + "/" + suffix
+}
+
+// Reads the response body, decompressing it when the server sent it gzip
+// encoded without Go's transport handling it (e.g. when Accept-Encoding was
+// set manually or the server compresses unconditionally). When maxSize is set,
+// a body longer than maxSize decoded bytes is an error, whether the server
+// sent a Content-Length or streamed it chunked.
+func readResponseBody(resp *http.Response, maxSize int64) ([]byte, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") || resp.Uncompressed {
+		return readLimited(resp.Body, maxSize)
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("the gzip encoded response body can't be decompressed: %v", err)
+	}
+	defer reader.Close()
+	return readLimited(reader, maxSize)
+}
+
+// Returns the gzip encoded request body, sent with "Content-Encoding: gzip".
+func gzipRequestBody(data string) (*bytes.Buffer, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := io.WriteString(writer, data); err != nil {
+		return nil, fmt.Errorf("the request body can't be compressed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("the request body can't be compressed: %v", err)
+	}
+	return &buffer, nil
+}
+
+// Reads at most maxSize bytes, reading one more to detect a longer content.
+func readLimited(reader io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(reader)
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("the response body exceeds the maximum size of %d bytes (max_response_size)", maxSize)
+	}
+	return data, nil
+}
+
+// Sets the JWT or OAuth token on the request, with the configured header and
+// scheme, e.g. "Authorization: Bearer <token>".
+func (client *APIClient) setAuthToken(req *http.Request, token string) {
+	req.Header.Set(client.AuthHeaderName, client.AuthHeaderPrefix+" "+token)
+}
+
 // Convert the important bits about this object to string representation
-// This is useful for debugging.
+// This is useful for debugging. The secrets and the header values are masked.
 func (client *APIClient) toString() string {
 	var buffer bytes.Buffer
 	buffer.WriteString(fmt.Sprintf("uri: %s\n", client.Uri))
 	if client.Jwt != nil {
-		buffer.WriteString(fmt.Sprintf("jwt_hashed_token.secret: %s\n", client.Jwt.Secret))
+		buffer.WriteString(fmt.Sprintf("jwt_hashed_token.secret: %s\n", redactSecret(string(client.Jwt.Secret))))
 		buffer.WriteString(fmt.Sprintf("jwt_hashed_token.algorithm: %s\n", client.Jwt.Algortithm))
 		buffer.WriteString(fmt.Sprintf("jwt_hashed_token.claimsJson: %s\n", client.Jwt.Claims))
 	}
 	buffer.WriteString(fmt.Sprintf("insecure: %t\n", client.Insecure))
 	buffer.WriteString(fmt.Sprintf("username: %s\n", client.Username))
-	buffer.WriteString(fmt.Sprintf("password: %s\n", client.Password))
+	buffer.WriteString(fmt.Sprintf("password: %s\n", redactSecret(client.Password)))
 	buffer.WriteString(fmt.Sprintf("id_attribute: %s\n", client.IdAttribute))
 	buffer.WriteString(fmt.Sprintf("write_returns_object: %t\n", client.WriteReturnsObject))
 	buffer.WriteString(fmt.Sprintf("create_returns_object: %t\n", client.CreateReturnsObject))
 	buffer.WriteString("headers:\n")
 	for k, v := range client.Headers {
-		buffer.WriteString(fmt.Sprintf("  %s: %s\n", k, v))
+		buffer.WriteString(fmt.Sprintf("  %s: %s\n", k, redactSecret(v)))
 	}
 	for _, n := range client.CopyKeys {
 		buffer.WriteString(fmt.Sprintf("  %s", n))
@@ -341,30 +807,211 @@ func (client *APIClient) toString() string {
 	return buffer.String()
 }
 
+// SendJsonRequestWithOpt is SendRequestWithOpt for requests expecting
+// a JSON response, e.g. reads. When the response body can't be parsed as JSON
+// (e.g. truncated by a gateway), the request is sent again up to
+// JsonDecodeRetries times. HTTP status errors are not retried. With
+// opt.RawResponse, the response is not checked.
+func (client *APIClient) SendJsonRequestWithOpt(ctx context.Context, method string, path string, data string, opt *RequestOpt) (string, error) {
+	var attempt int64
+
+	if opt != nil && opt.RawResponse {
+		return client.SendRequestWithOpt(ctx, method, path, data, opt)
+	}
+
+	for {
+		body, err := client.SendRequestWithOpt(ctx, method, path, data, opt)
+		if err != nil || json.Valid([]byte(body)) {
+			return body, err
+		}
+		if client.Debug {
+			client.Logger.Printf("api_client.go: Unparseable JSON response (attempt %d):\n%s\n", attempt+1, client.logBody(body))
+		}
+		if attempt >= client.JsonDecodeRetries {
+			return body, fmt.Errorf("the response of %s %s can't be parsed as JSON after %d attempt(s)", method, path, attempt+1)
+		}
+		attempt++
+	}
+}
+
 /*
 Helper function that handles sending/receiving and handling
 
 	of HTTP data in and out.
 */
 func (client *APIClient) SendRequest(method string, path string, data string) (string, error) {
+	return client.SendRequestWithContext(context.Background(), method, path, data)
+}
+
+// SendRequestWithContext is SendRequest bound to a context. Cancelling the
+// context aborts the request, including the OAuth token retrieval. A deadline
+// set on the context bounds the request and its retries, each attempt being
+// bound to the client's global timeout too, the earlier deadline applying.
+func (client *APIClient) SendRequestWithContext(ctx context.Context, method string, path string, data string) (string, error) {
+	return client.SendRequestWithOpt(ctx, method, path, data, nil)
+}
+
+// RequestOpt holds the settings of a single request sent with SendRequestWithOpt.
+type RequestOpt struct {
+	// Headers set on this request only, overriding the client headers,
+	// e.g. conditional headers like If-Unmodified-Since.
+	Headers map[string]string
+	// Names of client headers (e.g. provider defaults) not sent on this request.
+	SuppressHeaders []string
+	// Operation of the request, checking its response status against the
+	// client ExpectedStatus of the operation.
+	Operation Operation
+	// When not nil, receives the headers of the response, e.g. the Location
+	// of a created object. With retries, those of the last attempt.
+	ResponseHeader http.Header
+	// Receives the status code of the response along with ResponseHeader.
+	ResponseStatus int
+	// Content type of the request body, e.g. application/xml for a body sent
+	// verbatim, overriding the client headers. application/json when empty.
+	ContentType string
+	// Returns the response body as is, e.g. XML or text: it is not converted
+	// from JSON Lines nor checked for ResponseErrorsPath, and an empty body
+	// stays empty.
+	RawResponse bool
+	// Removes the leading and trailing whitespace of the response body, e.g.
+	// a newline following the XSSI prefix. The UTF-8 BOM, then the client
+	// XssiPrefix, then the whitespace are removed, in this order.
+	TrimResponse bool
+	// Sends the request without the login session, e.g. the login itself.
+	skipLogin bool
+}
+
+// Returns whether the body of a request sent with opt is JSON: its content
+// type, application/json unless set by opt or the client headers, is JSON or
+// a +json type, e.g. application/merge-patch+json.
+func (client *APIClient) isJsonBody(opt *RequestOpt) bool {
+	contentType := "application/json"
+	for n, v := range client.Headers {
+		if strings.EqualFold(n, "Content-Type") {
+			contentType = v
+		}
+	}
+	if opt.ContentType != "" {
+		contentType = opt.ContentType
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// SendRequestWithOpt is SendRequestWithContext with per-request settings. A
+// nil opt behaves like SendRequestWithContext. With a login or ReauthOn401,
+// a 401 response renews the credentials and the request is sent once more.
+// Only the idempotent requests are retried, see isIdempotent. With FailFast,
+// a 5xx left once the retries are exhausted fails all the next requests.
+func (client *APIClient) SendRequestWithOpt(ctx context.Context, method string, path string, data string, opt *RequestOpt) (string, error) {
+	/* Generation of the login session set on the last attempt */
+	var session uint64
+	send := func() (string, error) {
+		return client.sendRequestAttempt(ctx, method, path, data, opt, &session)
+	}
+	idempotent := client.isIdempotent(method, opt)
+	body, err := client.sendWithRetries(ctx, idempotent, send)
+	if (client.Login != nil || client.ReauthOn401) && StatusCode(err) == http.StatusUnauthorized {
+		/* The credentials expired or were revoked */
+		if reauthErr := client.reauthenticate(ctx, session); reauthErr != nil {
+			return body, fmt.Errorf("%w, and the re-authentication failed: %v", err, reauthErr)
+		}
+		body, err = client.sendWithRetries(ctx, idempotent, send)
+	}
+	client.failFast.trip(err)
+	return body, err
+}
+
+// Discards the cached credentials after a 401: the access token of the OAuth
+// refresh token grant, refreshed on the next request, and the login session,
+// replaced by a new one unless another request already renewed the session
+// generation the failed request used. The JWT and the OAuth client
+// credentials token are obtained anew for each request.
+func (client *APIClient) reauthenticate(ctx context.Context, session uint64) error {
+	if client.OauthRefreshTokenSource != nil {
+		client.OauthRefreshTokenSource.Invalidate()
+	}
+	if client.Login != nil {
+		return client.renewSession(ctx, session)
+	}
+	return nil
+}
+
+// Sends the request once. The client timeout applies to each attempt, not
+// counting the waits for the rate limit and a concurrent request slot, which
+// are only bound to ctx. With a login, session receives the generation of the
+// session set on the request, when not nil.
+func (client *APIClient) sendRequestAttempt(ctx context.Context, method string, path string, data string, opt *RequestOpt, session *uint64) (string, error) {
+	if opt == nil {
+		opt = &RequestOpt{}
+	}
+	if client.RateLimiter != nil {
+		// Rate limiting
+		if client.Debug {
+			client.Logger.Printf("Waiting for rate limit availability\n")
+		}
+		if err := client.RateLimiter.Wait(ctx); err != nil {
+			return "", err
+		}
+	}
+	if client.ConcurrencyLimiter != nil && !opt.skipLogin {
+		// Cap the number of in-flight requests, a login being sent within
+		// the slot of the request needing it
+		if client.Debug {
+			client.Logger.Printf("Waiting for a concurrent request slot\n")
+		}
+		if err := client.ConcurrencyLimiter.Acquire(ctx, 1); err != nil {
+			return "", err
+		}
+		defer client.ConcurrencyLimiter.Release(1)
+	}
+	if client.Timeout > 0 {
+		/* The earlier of the ctx deadline and the client timeout applies */
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+	if client.certReloader != nil {
+		/* Checked before each request as the kept-alive connections skip the handshake */
+		if err := client.certReloader.reloadIfChanged(); err != nil {
+			return "", err
+		}
+	}
+	if client.AppendTrailingSlash {
+		path = appendTrailingSlash(path)
+	}
 	fullURI := client.Uri + path
 	var req *http.Request
 	var err error
 
+	if client.EscapeHTML && data != "" && client.isJsonBody(opt) {
+		var escaped bytes.Buffer
+		json.HTMLEscape(&escaped, []byte(data))
+		data = escaped.String()
+	}
 	if client.Debug {
-		log.Printf("api_client.go: method=%s, path=%s, full uri (derived)=%s, data=%s\n", method, path, fullURI, data)
+		client.Logger.Printf("api_client.go: method=%s, path=%s, full uri (derived)=%s, data=%s\n", method, path, fullURI, client.logBody(data))
 	}
 
 	buffer := bytes.NewBuffer([]byte(data))
+	gzipBody := client.CompressRequestMinBytes > 0 && int64(len(data)) >= client.CompressRequestMinBytes
+	if gzipBody {
+		if buffer, err = gzipRequestBody(data); err != nil {
+			return "", err
+		}
+	}
 
 	if data == "" {
-		req, err = http.NewRequest(method, fullURI, nil)
+		req, err = http.NewRequestWithContext(ctx, method, fullURI, nil)
 	} else {
-		req, err = http.NewRequest(method, fullURI, buffer)
+		req, err = http.NewRequestWithContext(ctx, method, fullURI, buffer)
 
 		/* Default of application/json, but allow headers array to overwrite later */
 		if err == nil {
 			req.Header.Set("Content-Type", "application/json")
+			if gzipBody {
+				req.Header.Set("Content-Encoding", "gzip")
+			}
 		}
 	}
 
@@ -374,7 +1021,7 @@ func (client *APIClient) SendRequest(method string, path string, data string) (s
 	}
 
 	if client.Debug {
-		log.Printf("api_client.go: Sending HTTP request to %s...\n", req.URL)
+		client.Logger.Printf("api_client.go: Sending HTTP request to %s...\n", req.URL)
 	}
 
 	/* Allow for tokens or other pre-created secrets */
@@ -383,21 +1030,57 @@ func (client *APIClient) SendRequest(method string, path string, data string) (s
 			req.Header.Set(n, v)
 		}
 	}
+	if client.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", client.AcceptLanguage)
+	}
+	if client.IdempotencyKeyHeader != "" && opt.Operation == OperationCreate {
+		req.Header.Set(client.IdempotencyKeyHeader, idempotencyKey(method, path, data))
+	}
+	for _, n := range opt.SuppressHeaders {
+		req.Header.Del(n)
+	}
+	if opt.ContentType != "" && data != "" {
+		req.Header.Set("Content-Type", opt.ContentType)
+	}
+	for n, v := range opt.Headers {
+		req.Header.Set(n, v)
+	}
+
+	if client.Token != "" {
+		client.setAuthToken(req, client.Token)
+	}
 
 	if client.Jwt != nil {
 		client.Jwt.completeClaimValidityTime()
-		jwt, _ := client.Jwt.getSignedJwt()
-		req.Header.Set("Authorization", "Bearer "+jwt)
+		jwt, err := client.Jwt.getSignedJwt()
+		if err != nil {
+			return "", err
+		}
+		client.setAuthToken(req, jwt)
 	}
 
 	if client.OauthConfig != nil {
-		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client.HttpClient)
-		tokenSource := client.OauthConfig.TokenSource(ctx)
+		tokenCtx := context.WithValue(ctx, oauth2.HTTPClient, client.oauthHttpClient())
+		tokenSource := client.OauthConfig.TokenSource(tokenCtx)
 		token, err := tokenSource.Token()
 		if err != nil {
 			return "", err
 		}
-		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		client.setAuthToken(req, token.AccessToken)
+	}
+
+	if client.OauthRefreshTokenSource != nil {
+		token, err := client.OauthRefreshTokenSource.Token(ctx, client.oauthHttpClient())
+		if err != nil {
+			return "", err
+		}
+		client.setAuthToken(req, token.AccessToken)
+	}
+
+	if client.Login != nil && !opt.skipLogin {
+		if err := client.setSession(ctx, req, session); err != nil {
+			return "", err
+		}
 	}
 
 	if client.Username != "" && client.Password != "" {
@@ -406,59 +1089,106 @@ func (client *APIClient) SendRequest(method string, path string, data string) (s
 	}
 
 	if client.Debug {
-		log.Printf("api_client.go: Request headers:\n")
+		client.Logger.Printf("api_client.go: Request headers:\n")
 		for name, headers := range req.Header {
 			for _, h := range headers {
-				log.Printf("api_client.go:   %v: %v", name, h)
+				client.Logger.Printf("api_client.go:   %v: %v", name, h)
 			}
 		}
 
-		log.Printf("api_client.go: BODY:\n")
+		client.Logger.Printf("api_client.go: BODY:\n")
 		body := "<none>"
 		if req.Body != nil {
-			body = data
+			body = client.logBody(data)
 		}
-		log.Printf("%s\n", body)
+		client.Logger.Printf("%s\n", body)
 	}
 
-	if client.RateLimiter != nil {
-		// Rate limiting
-		if client.Debug {
-			log.Printf("Waiting for rate limit availability\n")
-		}
-		_ = client.RateLimiter.Wait(context.Background())
+	if client.TraceHttp {
+		traceRequest(ctx, req, client.RedactLogBodies, client.AuthHeaderName)
 	}
 
 	resp, err := client.HttpClient.Do(req)
 
 	if err != nil {
-		//log.Printf("api_client.go: Error detected: %s\n", err)
+		//client.Logger.Printf("api_client.go: Error detected: %s\n", err)
 		return "", err
 	}
 
+	if opt.ResponseHeader != nil {
+		clear(opt.ResponseHeader)
+		for name, values := range resp.Header {
+			opt.ResponseHeader[name] = values
+		}
+		opt.ResponseStatus = resp.StatusCode
+	}
+
 	if client.Debug {
-		log.Printf("api_client.go: Response code: %d\n", resp.StatusCode)
-		log.Printf("api_client.go: Response headers:\n")
+		client.Logger.Printf("api_client.go: Response code: %d\n", resp.StatusCode)
+		client.Logger.Printf("api_client.go: Response headers:\n")
 		for name, headers := range resp.Header {
 			for _, h := range headers {
-				log.Printf("api_client.go:   %v: %v", name, h)
+				client.Logger.Printf("api_client.go:   %v: %v", name, h)
 			}
 		}
 	}
 
-	bodyBytes, err2 := io.ReadAll(resp.Body)
+	wireBody := &countingReader{ReadCloser: resp.Body}
+	resp.Body = wireBody
+	bodyBytes, err2 := readResponseBody(resp, client.MaxResponseSize)
 	resp.Body.Close()
+	if client.TraceHttp {
+		traceResponse(ctx, resp, bodyBytes, client.RedactLogBodies)
+	}
 
 	if err2 != nil {
 		return "", err2
 	}
+	compressed := strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") && !resp.Uncompressed
+	logTransferSizes(ctx, method, path, resp.StatusCode, len(data), len(bodyBytes), wireBody.n, compressed)
+	/* Some servers prefix the body with a UTF-8 BOM, which JSON decoding rejects */
+	bodyBytes = bytes.TrimPrefix(bodyBytes, utf8BOM)
 	body := strings.TrimPrefix(string(bodyBytes), client.XssiPrefix)
+	if opt.TrimResponse {
+		body = strings.TrimSpace(body)
+	}
 	if client.Debug {
-		log.Printf("api_client.go: BODY:\n%s\n", body)
+		client.Logger.Printf("api_client.go: BODY:\n%s\n", client.logBody(body))
+	}
+
+	if !client.isExpectedStatus(opt.Operation, resp.StatusCode) {
+		return body, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       body,
+			Method:     method,
+			Path:       path,
+		}
+	}
+
+	if opt.RawResponse {
+		return body, nil
+	}
+
+	/* The hypertext note of an expected redirect (RFC 9110, section 15.4) is no object */
+	if IsRedirect(resp.StatusCode) && !json.Valid([]byte(body)) {
+		body = ""
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return body, fmt.Errorf("unexpected response code '%d': %s", resp.StatusCode, body)
+	if client.ResponseFormat == ResponseFormatJsonLines {
+		if body, err = JsonLinesToArray(body); err != nil {
+			return "", err
+		}
+	}
+
+	if client.ResponseErrorsPath != "" {
+		if errs, found := responseErrors(body, client.ResponseErrorsPath); found {
+			return body, &ResponseErrorsError{
+				Errors: errs,
+				Body:   body,
+				Method: method,
+				Path:   path,
+			}
+		}
 	}
 
 	if body == "" {
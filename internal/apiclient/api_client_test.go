@@ -1,6 +1,8 @@
 package apiclient
 
 import (
+	"compress/gzip"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -9,13 +11,19 @@ import (
 	"crypto/x509/pkix"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -114,6 +122,81 @@ func TestCreateHashedJWT_withDuration(t *testing.T) {
 	}
 }
 
+func TestCreateHashedJWT_withoutAlgorithm(t *testing.T) {
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri: "http://127.0.0.1:8083/",
+		Jwt: &JwtHashedToken{
+			Secret: []byte("NotTheMostSecuredSecret"),
+			Claims: map[string]any{"a": "b"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	if client.Jwt.Algortithm != DefaultJwtAlgorithm {
+		t.Errorf("The JWT algorithm = %s; want %s", client.Jwt.Algortithm, DefaultJwtAlgorithm)
+	}
+	if _, err := client.Jwt.getSignedJwt(); err != nil {
+		t.Errorf("The jwt signing with the default algorithm returned the error: %s", err)
+	}
+
+	unknownAlgorithm := &JwtHashedToken{
+		Secret:     []byte("NotTheMostSecuredSecret"),
+		Algortithm: "unknown",
+		Claims:     map[string]any{"a": "b"},
+	}
+	if _, err := unknownAlgorithm.getSignedJwt(); err == nil {
+		t.Error("The jwt signing with an unknown algorithm should return an error")
+	}
+}
+
+func TestAPIClient_authHeader(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("X-Auth-Token"), r.Header.Get("Authorization"))
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:              svr.URL,
+		Timeout:          2,
+		AuthHeaderName:   "X-Auth-Token",
+		AuthHeaderPrefix: "JWT",
+		Jwt: &JwtHashedToken{
+			Secret: []byte("NotTheMostSecuredSecret"),
+			Claims: map[string]any{"a": "b"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	res, err := client.SendRequest("GET", "/ok", "")
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	token, authorization, _ := strings.Cut(res, "|")
+	if !strings.HasPrefix(token, "JWT ey") {
+		t.Errorf("X-Auth-Token = %q; want the JWT prefixed with \"JWT \"", token)
+	}
+	if authorization != "" {
+		t.Errorf("Authorization = %q; want no header", authorization)
+	}
+
+	client, err = NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, Token: "t0ken"})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	res, err = client.SendRequest("GET", "/ok", "")
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if res != "|Bearer t0ken" {
+		t.Errorf("The static token headers = %q; want \"|Bearer t0ken\"", res)
+	}
+	if client.AuthHeaderName != DefaultAuthHeaderName || client.AuthHeaderPrefix != DefaultAuthHeaderPrefix {
+		t.Errorf("The auth header = %s: %s; want %s: %s", client.AuthHeaderName, client.AuthHeaderPrefix, DefaultAuthHeaderName, DefaultAuthHeaderPrefix)
+	}
+}
+
 func TestAPIClient(t *testing.T) {
 	debug := false
 
@@ -252,6 +335,709 @@ func TestAPIClient(t *testing.T) {
 	}
 }
 
+func TestAPIClient_oauthTokenCancellation(t *testing.T) {
+	unblock := make(chan struct{})
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		/* Simulate an unresponsive identity server */
+		<-unblock
+	}))
+	defer tokenServer.Close()
+	defer close(unblock)
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:               "http://127.0.0.1:8083/",
+		Timeout:           30,
+		RateLimit:         1,
+		OauthClientID:     "client",
+		OauthClientSecret: "secret",
+		OauthTokenURL:     tokenServer.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	startTime := time.Now()
+	_, err = client.SendRequestWithContext(ctx, "GET", "/ok", "")
+	if err == nil {
+		t.Fatal("The request should fail when the context is cancelled during the token retrieval")
+	}
+	if elapsed := time.Since(startTime); elapsed > 2*time.Second {
+		t.Errorf("The token retrieval was not cancelled with the context, it took %s", elapsed)
+	}
+}
+
+func TestAPIClient_stripHeadersOnRedirect(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/headers", http.StatusTemporaryRedirect)
+	})
+	serverMux.HandleFunc("/headers", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("X-Internal-Token"), r.Header.Get("X-Tenant"))
+	})
+	svr := httptest.NewServer(serverMux)
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:                    svr.URL,
+		Headers:                map[string]string{"X-Internal-Token": "secret", "X-Tenant": "tenant_1"},
+		Timeout:                2,
+		RateLimit:              10,
+		StripHeadersOnRedirect: []string{"x-internal-token"},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	res, err := client.SendRequest("GET", "/headers", "")
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if res != "secret|tenant_1" {
+		t.Errorf("Got back '%s' without redirect but expected 'secret|tenant_1'", res)
+	}
+
+	res, err = client.SendRequest("GET", "/redirect", "")
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if res != "|tenant_1" {
+		t.Errorf("Got back '%s' after the redirect but expected '|tenant_1'", res)
+	}
+}
+
+func TestAPIClient_restrictRedirectsToSameHost(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "internal")
+	}))
+	defer internal.Close()
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/relative", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/target", http.StatusTemporaryRedirect)
+	})
+	serverMux.HandleFunc("/absolute", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/target", http.StatusTemporaryRedirect)
+	})
+	serverMux.HandleFunc("/target", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "target")
+	})
+	svr := httptest.NewServer(serverMux)
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:                         svr.URL,
+		Timeout:                     2,
+		RateLimit:                   10,
+		RestrictRedirectsToSameHost: true,
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	res, err := client.SendRequest("GET", "/relative", "")
+	if err != nil {
+		t.Fatalf("A redirect to the same host should be followed: %s", err)
+	}
+	if res != "target" {
+		t.Errorf("Got back '%s' after the redirect but expected 'target'", res)
+	}
+
+	if _, err := client.SendRequest("GET", "/absolute", ""); err == nil {
+		t.Error("A redirect to another host should be rejected")
+	}
+}
+
+func TestAPIClient_noFollowRedirects(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("POST /api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/api/objects/42")
+		w.WriteHeader(http.StatusSeeOther)
+		fmt.Fprint(w, `<a href="/api/objects/42">See Other</a>`)
+	})
+	serverMux.HandleFunc("GET /api/objects/42", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"42"}`)
+	})
+	svr := httptest.NewServer(serverMux)
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:               svr.URL,
+		Timeout:           2,
+		RateLimit:         10,
+		NoFollowRedirects: true,
+		ExpectedStatus:    map[Operation][]int{OperationCreate: {http.StatusCreated, http.StatusSeeOther}},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	/* The expected 303 succeeds, its HTML note dropped, and its Location is captured */
+	opt := &RequestOpt{Operation: OperationCreate, ResponseHeader: http.Header{}}
+	res, err := client.SendRequestWithOpt(context.Background(), "POST", "/api/objects", `{"name":"a"}`, opt)
+	if err != nil {
+		t.Fatalf("The expected 303 should succeed: %s", err)
+	}
+	if res != "{}" || opt.ResponseStatus != http.StatusSeeOther || opt.ResponseHeader.Get("Location") != "/api/objects/42" {
+		t.Errorf("Unexpected response '%s' with status %d and Location '%s'", res, opt.ResponseStatus, opt.ResponseHeader.Get("Location"))
+	}
+
+	/* Out of the expected codes of the operation, the 303 fails */
+	if _, err := client.SendRequest("POST", "/api/objects", `{"name":"a"}`); StatusCode(err) != http.StatusSeeOther {
+		t.Errorf("The unexpected 303 should fail, got: %v", err)
+	}
+
+	client.HttpClient.CheckRedirect = checkRedirect(nil, false, false)
+	if res, err := client.SendRequest("POST", "/api/objects", `{"name":"a"}`); err != nil || res != `{"id":"42"}` {
+		t.Errorf("The followed redirect returned '%s' and the error: %v", res, err)
+	}
+}
+
+func TestAPIClient_maxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int64
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:                   svr.URL,
+		Timeout:               5,
+		RateLimit:             100,
+		MaxConcurrentRequests: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.SendRequest("GET", "/ok", ""); err != nil {
+				t.Errorf("api_client_test.go: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight.Load() > 2 {
+		t.Errorf("%d requests were in flight at the same time; want at most 2", maxInFlight.Load())
+	}
+}
+
+func TestAPIClient_jsonDecodeRetries(t *testing.T) {
+	var calls atomic.Int64
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := `{"id":"1","identifier":"tenant_1"}`
+		if calls.Add(1) < 3 {
+			/* Simulate a gateway cutting the response short */
+			body = body[:10]
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("Error on sending the response: %s", err)
+		}
+	}))
+	defer svr.Close()
+
+	opt := &ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, JsonDecodeRetries: 1}
+	client, err := NewAPIClient(opt)
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	if _, err := client.SendJsonRequestWithOpt(context.Background(), "GET", "/api/objects/1", "", nil); err == nil {
+		t.Error("The request should fail when the body is still truncated after the retries")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("%d requests were sent; want 2", calls.Load())
+	}
+
+	calls.Store(0)
+	client.JsonDecodeRetries = 2
+	res, err := client.SendJsonRequestWithOpt(context.Background(), "GET", "/api/objects/1", "", nil)
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if id, _ := GetKeyValue(res, "id"); id != "1" {
+		t.Errorf("Got back '%s' but expected the complete object", res)
+	}
+}
+
+func TestAppendTrailingSlash(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"", "/"},
+		{"/api/objects", "/api/objects/"},
+		{"/api/objects/", "/api/objects/"},
+		{"/api/objects//", "/api/objects/"},
+		{"/api/objects?identifier=tenant_1", "/api/objects/?identifier=tenant_1"},
+		{"/api/objects/?identifier=a/b", "/api/objects/?identifier=a/b"},
+		{"/api/objects#top", "/api/objects/#top"},
+	}
+
+	for _, test := range tests {
+		if result := appendTrailingSlash(test.path); result != test.expected {
+			t.Errorf("appendTrailingSlash(%s) = %s; want %s", test.path, result, test.expected)
+		}
+	}
+}
+
+func TestCanonicalJson(t *testing.T) {
+	expected := `{"a":[{"x":1,"y":2}],"big":12345678901234567890,"html":"<b>&</b>","z":{"k":1.50}}`
+	inputs := []string{
+		`{"z": {"k": 1.50}, "html": "<b>&</b>", "big": 12345678901234567890, "a": [{"y": 2, "x": 1}]}`,
+		"{\n  \"a\": [ {\"x\": 1, \"y\": 2} ],\n  \"big\": 12345678901234567890,\n  \"html\": \"<b>&</b>\",\n  \"z\": {\"k\": 1.50}\n}\n",
+		expected,
+	}
+
+	for _, input := range inputs {
+		for i := 0; i < 3; i++ {
+			result, err := CanonicalJson(input)
+			if err != nil {
+				t.Fatalf("CanonicalJson(%s) returned an error: %s", input, err)
+			}
+			if result != expected {
+				t.Errorf("CanonicalJson(%s) = %s; want %s", input, result, expected)
+			}
+		}
+	}
+
+	for _, invalid := range []string{`{"a":`, `{"a":1} {"b":2}`} {
+		if _, err := CanonicalJson(invalid); err == nil {
+			t.Errorf("CanonicalJson(%s) should return an error", invalid)
+		}
+	}
+}
+
+func TestJsonMarshal_escapeHTML(t *testing.T) {
+	data := map[string]any{"filter": "a<b && c>d"}
+	expected := `{"filter":"a<b && c>d"}`
+
+	result, err := JsonEncode(data)
+	if err != nil {
+		t.Fatalf("JsonEncode(%v) returned an error: %s", data, err)
+	}
+	if result != expected {
+		t.Errorf("JsonEncode(%v) = %s; want %s", data, result, expected)
+	}
+	updated, err := SetJsonAtPath(`{}`, "filter", `"a<b && c>d"`)
+	if err != nil || updated != expected {
+		t.Errorf("SetJsonAtPath = %s, %v; want %s", updated, err, expected)
+	}
+}
+
+func TestAPIClient_escapeHTML(t *testing.T) {
+	var body string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer svr.Close()
+
+	data := `{"filter":"a<b && c>d"}`
+	for _, test := range []struct {
+		escapeHTML  bool
+		contentType string
+		expected    string
+	}{
+		{false, "", data},
+		{true, "", `{"filter":"a\u003cb \u0026\u0026 c\u003ed"}`},
+		{true, MergePatchContentType, `{"filter":"a\u003cb \u0026\u0026 c\u003ed"}`},
+		{true, "application/xml", data},
+	} {
+		client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, EscapeHTML: test.escapeHTML})
+		if err != nil {
+			t.Fatalf("NewAPIClient returned an error: %s", err)
+		}
+		if _, err := client.SendRequestWithOpt(context.Background(), "PUT", "/api/objects/1", data, &RequestOpt{ContentType: test.contentType}); err != nil {
+			t.Fatalf("The request returned an error: %s", err)
+		}
+		if body != test.expected {
+			t.Errorf("With EscapeHTML=%v and the content type '%s', the body sent is %s; want %s", test.escapeHTML, test.contentType, body, test.expected)
+		}
+	}
+}
+
+func TestAPIClient_gzipErrorBody(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		gz := gzip.NewWriter(w)
+		if _, err := gz.Write([]byte(`)]}'{"error":"invalid tenant"}`)); err != nil {
+			t.Errorf("Error on sending the gzip response: %s", err)
+		}
+		gz.Close()
+	}))
+	defer svr.Close()
+
+	/* Setting Accept-Encoding manually disables Go's transparent decompression */
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:        svr.URL,
+		Headers:    map[string]string{"Accept-Encoding": "gzip"},
+		Timeout:    2,
+		RateLimit:  10,
+		XssiPrefix: ")]}'",
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	res, err := client.SendRequest("POST", "/api/objects", `{"id":"1"}`)
+	if err == nil {
+		t.Fatal("The request should fail on a 400 response")
+	}
+	if res != `{"error":"invalid tenant"}` {
+		t.Errorf("Got back '%s' but expected the decompressed error body", res)
+	}
+	if err.Error() != `unexpected response code '400': {"error":"invalid tenant"}` {
+		t.Errorf("Unexpected error message: %s", err)
+	}
+}
+
+func TestAPIClient_compressRequestMinBytes(t *testing.T) {
+	/* Answers the Content-Encoding of the request and its decoded body */
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer gz.Close()
+			body = gz
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "%s:%s", r.Header.Get("Content-Encoding"), data)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, CompressRequestMinBytes: 10})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{`{"a":"1"}`, `:{"a":"1"}`},
+		{`{"a":"12"}`, `gzip:{"a":"12"}`},
+		{`{"a":"123"}`, `gzip:{"a":"123"}`},
+		{"", ":"},
+	}
+	for _, test := range tests {
+		res, err := client.SendRequest("POST", "/api/objects", test.data)
+		if err != nil {
+			t.Fatalf("The request of %d bytes failed: %s", len(test.data), err)
+		}
+		if res != test.expected {
+			t.Errorf("The request of %d bytes was received as '%s'; want '%s'", len(test.data), res, test.expected)
+		}
+	}
+
+	client.CompressRequestMinBytes = 0
+	if res, _ := client.SendRequest("POST", "/api/objects", `{"a":"123"}`); res != `:{"a":"123"}` {
+		t.Errorf("The request was received as '%s' with the compression disabled", res)
+	}
+}
+
+func TestAPIClient_maxResponseSize(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("The response writer can't stream")
+		}
+		if r.URL.Path == "/gzip" {
+			/* A few bytes on the wire, 1 KiB decoded */
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			if _, err := gz.Write([]byte(`"` + strings.Repeat("a", 1022) + `"`)); err != nil {
+				t.Errorf("Error on sending the gzip response: %s", err)
+			}
+			gz.Close()
+			return
+		}
+		/* Flushing before the end sends a chunked response without Content-Length */
+		for i := 0; i < 4; i++ {
+			fmt.Fprint(w, strings.Repeat("a", 64))
+			flusher.Flush()
+		}
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:             svr.URL,
+		Headers:         map[string]string{"Accept-Encoding": "gzip"},
+		Timeout:         2,
+		RateLimit:       10,
+		MaxResponseSize: 200,
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	for _, path := range []string{"/chunked", "/gzip"} {
+		_, err = client.SendRequest("GET", path, "")
+		if err == nil || !strings.Contains(err.Error(), "exceeds the maximum size of 200 bytes") {
+			t.Errorf("%s: expected the max_response_size error, got: %v", path, err)
+		}
+	}
+
+	client.MaxResponseSize = 256
+	if res, err := client.SendRequest("GET", "/chunked", ""); err != nil || len(res) != 256 {
+		t.Errorf("A response of exactly max_response_size bytes should be read, got %d bytes: %v", len(res), err)
+	}
+}
+
+func TestAPIClient_rateLimitCancellation(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "It works!")
+	}))
+	defer svr.Close()
+
+	// A request every 100 seconds: the second request waits for the rate limit.
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 0.01})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	if _, err := client.SendRequest("GET", "/ok", ""); err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err = client.SendRequestWithContext(ctx, "GET", "/ok", "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a context cancellation error during the rate limit wait, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("The cancelled request returned after %s", elapsed)
+	}
+}
+
+func TestAPIClient_contextDeadlineAndTimeout(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+		if _, err := w.Write([]byte("It works!")); err != nil {
+			t.Errorf("Error on sending the response: %s", err)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 1, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	if _, err := client.SendRequest("GET", "/slow", ""); err == nil {
+		t.Error("The global timeout did not trigger on the slow request")
+	}
+
+	/* A later deadline, e.g. of a polling loop, doesn't lift the global timeout */
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.SendRequestWithContext(ctx, "GET", "/slow", ""); err == nil {
+		t.Error("The global timeout should apply within a later context deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 1400*time.Millisecond {
+		t.Errorf("The request returned after %s, past the global timeout", elapsed)
+	}
+
+	/* An earlier deadline applies */
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := client.SendRequestWithContext(ctx, "GET", "/slow", ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context deadline error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("The request returned after %s, past the context deadline", elapsed)
+	}
+}
+
+func TestAPIClient_requestHeaders(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifUnmodifiedSince, err := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+		if err == nil && ifUnmodifiedSince.Before(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			http.Error(w, "modified since", http.StatusPreconditionFailed)
+			return
+		}
+		if tenant := r.Header.Get("X-Tenant"); tenant != "" {
+			fmt.Fprintf(w, "%s", tenant)
+			return
+		}
+		fmt.Fprint(w, "no tenant")
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:       svr.URL,
+		Headers:   map[string]string{"X-Tenant": "tenant_1"},
+		Timeout:   2,
+		RateLimit: 10,
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	res, err := client.SendRequestWithOpt(context.Background(), "PUT", "/api/objects/1", `{"id":"1"}`, &RequestOpt{
+		Headers: map[string]string{"X-Tenant": "tenant_2"},
+	})
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if res != "tenant_2" {
+		t.Errorf("Got back '%s' but expected the request header to override the client one", res)
+	}
+
+	res, err = client.SendRequestWithOpt(context.Background(), "GET", "/api/objects/1", "", &RequestOpt{
+		SuppressHeaders: []string{"x-tenant"},
+	})
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if res != "no tenant" {
+		t.Errorf("Got back '%s' but expected the suppressed header not to be sent", res)
+	}
+
+	lastUpdated := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	_, err = client.SendRequestWithOpt(context.Background(), "PUT", "/api/objects/1", `{"id":"1"}`, &RequestOpt{
+		Headers: map[string]string{"If-Unmodified-Since": lastUpdated.Format(http.TimeFormat)},
+	})
+	if StatusCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("Expected a 412 APIError on the conditional request, got: %v", err)
+	}
+}
+
+func TestAPIClient_debugLogFile(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1"}`)
+	}))
+	defer svr.Close()
+
+	logFile := filepath.Join(t.TempDir(), "debug.log")
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 10, Debug: true, DebugLogFile: logFile})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	if _, err := client.SendRequest("GET", "/api/objects/1", ""); err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("The debug log file can't be read: %s", err)
+	}
+	if !strings.Contains(string(content), "Sending HTTP request to "+svr.URL+"/api/objects/1") {
+		t.Errorf("The debug log file doesn't hold the request debug output:\n%s", content)
+	}
+}
+
+func TestAPIClient_stripBOM(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("\xEF\xBB\xBF" + `{"id":"1","identifier":"tenant_1"}`)); err != nil {
+			t.Errorf("Error on sending the response: %s", err)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	res, err := client.SendRequest("GET", "/api/objects/1", "")
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	mapData, err := JsonDecodeApiResponse(res)
+	if err != nil {
+		t.Fatalf("The BOM prefixed response can't be decoded: %s", err)
+	}
+	if mapData["identifier"] != "tenant_1" {
+		t.Errorf("Unexpected decoded response: %v", mapData)
+	}
+}
+
+func TestAPIClient_trimResponse(t *testing.T) {
+	const object = `{"id":"1"}`
+	var response string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(response)); err != nil {
+			t.Errorf("Error on sending the response: %s", err)
+		}
+	}))
+	defer svr.Close()
+
+	/* The BOM, then the XSSI prefix, then the whitespace are removed */
+	for _, bom := range []bool{false, true} {
+		for _, xssi := range []bool{false, true} {
+			for _, trim := range []bool{false, true} {
+				for _, raw := range []bool{false, true} {
+					response = ")]}'\n" + object + "\n"
+					expected := "\n" + object + "\n"
+					xssiPrefix := ")]}'"
+					if !xssi {
+						response = "\n" + object + "\n"
+						xssiPrefix = ""
+					}
+					if bom {
+						response = "\xEF\xBB\xBF" + response
+					}
+					if trim {
+						expected = object
+					}
+
+					client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, XssiPrefix: xssiPrefix})
+					if err != nil {
+						t.Fatalf("NewAPIClient returned an error: %s", err)
+					}
+					res, err := client.SendRequestWithOpt(context.Background(), "GET", "/api/objects/1", "", &RequestOpt{TrimResponse: trim, RawResponse: raw})
+					if err != nil {
+						t.Fatalf("api_client_test.go: %s", err)
+					}
+					if res != expected {
+						t.Errorf("With bom=%t xssi=%t trim=%t raw=%t, got back %q; want %q", bom, xssi, trim, raw, res, expected)
+					}
+				}
+			}
+		}
+	}
+
+	/* The whitespace before the XSSI prefix is not removed first: the prefix is kept */
+	response = "\n)]}'\n" + object
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, XssiPrefix: ")]}'"})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	res, err := client.SendRequestWithOpt(context.Background(), "GET", "/api/objects/1", "", &RequestOpt{TrimResponse: true, RawResponse: true})
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if res != ")]}'\n"+object {
+		t.Errorf("Got back %q; want the XSSI prefix kept", res)
+	}
+}
+
 func extractBearerToken(r *http.Request) (string, error) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
@@ -412,3 +1198,29 @@ func generateCertificates() {
 	rootCAPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootCABytes})
 	_ = os.WriteFile(rootCAFilePath, rootCAPEM, 0644)
 }
+
+func TestAPIClient_http2PriorKnowledge(t *testing.T) {
+	svr := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"proto":"%s"}`, r.Proto)
+	}))
+	/* The server speaks both HTTP/1.1 and h2c with prior knowledge */
+	svr.Config.Protocols = new(http.Protocols)
+	svr.Config.Protocols.SetHTTP1(true)
+	svr.Config.Protocols.SetUnencryptedHTTP2(true)
+	svr.Start()
+	defer svr.Close()
+
+	for _, priorKnowledge := range []bool{false, true} {
+		client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, Http2PriorKnowledge: priorKnowledge})
+		if err != nil {
+			t.Fatalf("NewAPIClient returned an error: %s", err)
+		}
+		expected := `{"proto":"HTTP/1.1"}`
+		if priorKnowledge {
+			expected = `{"proto":"HTTP/2.0"}`
+		}
+		if res, err := client.SendRequest("GET", "/", ""); err != nil || res != expected {
+			t.Errorf("With http2_prior_knowledge=%t, the server got %s, %v; want %s", priorKnowledge, res, err, expected)
+		}
+	}
+}
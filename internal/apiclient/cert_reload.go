@@ -0,0 +1,107 @@
+package apiclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// certReloader serves the client certificate of the TLS handshakes and loads
+// it again when its files change on disk, e.g. after an hourly rotation. A
+// certificate that can't be loaded, e.g. while its files are being replaced,
+// keeps the previous one until the next check.
+type certReloader struct {
+	mu     sync.Mutex
+	load   func() (tls.Certificate, error)
+	files  []string
+	stamps []fileStamp
+	cert   *tls.Certificate
+	// Called after a reload, e.g. to close the connections authenticated
+	// with the previous certificate.
+	onReload func()
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func (s fileStamp) equal(other fileStamp) bool {
+	return s.modTime.Equal(other.modTime) && s.size == other.size
+}
+
+// Returns a reloader of the client certificate of the files, loaded with load.
+func newCertReloader(load func() (tls.Certificate, error), files ...string) (*certReloader, error) {
+	reloader := &certReloader{load: load, files: files}
+	if err := reloader.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// Returns the reloader of the file-based client certificate of the options,
+// the PKCS#12 bundle taking precedence like in NewAPIClient, or nil without
+// certificate file.
+func clientCertReloader(opt *ApiClientOpt) (*certReloader, error) {
+	if opt.Pkcs12File != "" {
+		return newCertReloader(func() (tls.Certificate, error) {
+			return loadPkcs12Certificate(opt.Pkcs12File, opt.Pkcs12Password)
+		}, opt.Pkcs12File)
+	}
+	if opt.CertFile != "" && opt.KeyFile != "" {
+		return newCertReloader(func() (tls.Certificate, error) {
+			return tls.LoadX509KeyPair(opt.CertFile, opt.KeyFile)
+		}, opt.CertFile, opt.KeyFile)
+	}
+	return nil, nil
+}
+
+// Loads the certificate again when the modification time or the size of one
+// of its files changed since the last load.
+func (r *certReloader) reloadIfChanged() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamps := make([]fileStamp, len(r.files))
+	for i, file := range r.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			if r.cert != nil {
+				return nil
+			}
+			return fmt.Errorf("could not read client certificate file: %v", err)
+		}
+		stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	if r.cert != nil && slices.EqualFunc(stamps, r.stamps, fileStamp.equal) {
+		return nil
+	}
+
+	cert, err := r.load()
+	if err != nil {
+		if r.cert != nil {
+			return nil
+		}
+		return err
+	}
+	reloaded := r.cert != nil
+	r.cert = &cert
+	r.stamps = stamps
+	if reloaded && r.onReload != nil {
+		r.onReload()
+	}
+	return nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if err := r.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, nil
+}
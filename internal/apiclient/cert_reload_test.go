@@ -0,0 +1,79 @@
+package apiclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAPIClient_certReload(t *testing.T) {
+	svr := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	svr.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	svr.StartTLS()
+	defer svr.Close()
+
+	certFile, keyFile, _ := writeTestCertificate(t, "client-1", x509.ExtKeyUsageClientAuth)
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:        svr.URL,
+		Insecure:   true,
+		Timeout:    2,
+		RateLimit:  100,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		CertReload: true,
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	/* Replaces the certificate files, with a later modification time */
+	rotate := func(certContent []byte, keyContent []byte, modTime time.Time) {
+		if err := os.WriteFile(certFile, certContent, 0600); err != nil {
+			t.Fatalf("Writing the certificate failed: %s", err)
+		}
+		if err := os.WriteFile(keyFile, keyContent, 0600); err != nil {
+			t.Fatalf("Writing the key failed: %s", err)
+		}
+		for _, file := range []string{certFile, keyFile} {
+			if err := os.Chtimes(file, modTime, modTime); err != nil {
+				t.Fatalf("Changing the modification time failed: %s", err)
+			}
+		}
+	}
+	expectCommonName := func(expected string) {
+		t.Helper()
+		res, err := client.SendRequest("GET", "/", "")
+		if err != nil {
+			t.Fatalf("The request failed: %s", err)
+		}
+		if res != expected {
+			t.Errorf("The server got the client certificate %s; want %s", res, expected)
+		}
+	}
+
+	expectCommonName("client-1")
+
+	/* The kept-alive connection of the first request is not reused */
+	newCertFile, newKeyFile, _ := writeTestCertificate(t, "client-2", x509.ExtKeyUsageClientAuth)
+	newCert, _ := os.ReadFile(newCertFile)
+	newKey, _ := os.ReadFile(newKeyFile)
+	rotate(newCert, newKey, time.Now().Add(time.Hour))
+	expectCommonName("client-2")
+
+	/* A certificate being replaced, not matching its key yet, keeps the previous one */
+	otherCertFile, _, _ := writeTestCertificate(t, "client-3", x509.ExtKeyUsageClientAuth)
+	otherCert, _ := os.ReadFile(otherCertFile)
+	rotate(otherCert, newKey, time.Now().Add(2*time.Hour))
+	expectCommonName("client-2")
+
+	if _, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, CertFile: certFile + ".missing", KeyFile: keyFile, CertReload: true}); err == nil {
+		t.Error("NewAPIClient should fail on a missing certificate file")
+	}
+}
@@ -0,0 +1,142 @@
+package apiclient
+
+import (
+	"net/http"
+	"sort"
+)
+
+// EffectiveConfig returns the configuration of the client once its defaults
+// are resolved, as structured log fields, e.g. to show how the provider
+// interpreted its configuration. No secret is included: the headers are
+// given by name only, their values possibly holding credentials.
+func (client *APIClient) EffectiveConfig() map[string]interface{} {
+	headerNames := make([]string, 0, len(client.Headers))
+	for name := range client.Headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	idAttribute := client.IdAttribute
+	if idAttribute == "" {
+		idAttribute = "<detected>"
+	}
+	responseFormat := client.ResponseFormat
+	if responseFormat == "" {
+		responseFormat = ResponseFormatJson
+	}
+
+	config := map[string]interface{}{
+		"uri":                        client.Uri,
+		"auth_modes":                 client.authModes(),
+		"auth_header":                client.AuthHeaderName + ": " + client.AuthHeaderPrefix + " <redacted>",
+		"headers":                    headerNames,
+		"accept_language":            client.AcceptLanguage,
+		"idempotency_key_header":     client.IdempotencyKeyHeader,
+		"create_method":              client.CreateMethod,
+		"read_method":                client.ReadMethod,
+		"update_method":              client.UpdateMethod,
+		"destroy_method":             client.DestroyMethod,
+		"id_attribute":               idAttribute,
+		"identifier_query_param":     client.IdentifierQueryParam,
+		"default_path":               client.DefaultPath,
+		"timeout":                    client.Timeout.String(),
+		"rate_limit":                 describeRateLimit(client.RateLimiter),
+		"max_retries":                client.MaxRetries,
+		"retry_jitter":               string(client.RetryJitter),
+		"retry_max_wait":             client.retryMaxWait.String(),
+		"retry_max_elapsed_time":     client.retryMaxElapsedTime.String(),
+		"fail_fast":                  client.failFast != nil,
+		"reauth_on_401":              client.ReauthOn401,
+		"response_format":            responseFormat,
+		"response_errors_path":       client.ResponseErrorsPath,
+		"response_root":              client.ResponseRoot,
+		"max_response_size":          client.MaxResponseSize,
+		"json_decode_retries":        client.JsonDecodeRetries,
+		"compress_request_min_bytes": client.CompressRequestMinBytes,
+		"create_returns_object":      client.CreateReturnsObject,
+		"append_trailing_slash":      client.AppendTrailingSlash,
+	}
+	if transport, ok := client.HttpClient.Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		tlsConfig := transport.TLSClientConfig
+		config["tls_insecure_skip_verify"] = tlsConfig.InsecureSkipVerify
+		config["tls_pinned_certificate"] = tlsConfig.VerifyPeerCertificate != nil
+		config["tls_custom_root_ca"] = tlsConfig.RootCAs != nil
+		config["tls_client_certificate"] = len(tlsConfig.Certificates) > 0 || tlsConfig.GetClientCertificate != nil
+		config["tls_client_certificate_reload"] = client.certReloader != nil
+	}
+	if client.Jwt != nil {
+		config["jwt_algorithm"] = client.Jwt.Algortithm
+		config["jwt_certificate_chain_length"] = len(client.Jwt.CertificateChain)
+	}
+	if client.OauthConfig != nil {
+		config["oauth_token_url"] = client.OauthConfig.TokenURL
+		config["oauth_client_id"] = client.OauthConfig.ClientID
+		config["oauth_scopes"] = client.OauthConfig.Scopes
+		config["oauth_auth_method"] = oauthAuthMethod(client.OauthAuthMethod)
+	}
+	if client.OauthRefreshTokenSource != nil {
+		config["oauth_token_url"] = client.OauthRefreshTokenSource.config.Endpoint.TokenURL
+		config["oauth_client_id"] = client.OauthRefreshTokenSource.config.ClientID
+		config["oauth_scopes"] = client.OauthRefreshTokenSource.config.Scopes
+		config["oauth_token_file"] = client.OauthRefreshTokenSource.tokenFile
+		config["oauth_auth_method"] = oauthAuthMethod(client.OauthAuthMethod)
+	}
+	if client.Login != nil {
+		method := client.Login.Method
+		if method == "" {
+			method = "POST"
+		}
+		config["login_request"] = method + " " + client.Login.Path
+		if client.Login.CookieName != "" {
+			config["login_session_cookie"] = client.Login.CookieName
+		} else {
+			config["login_token_path"] = client.Login.TokenPath
+		}
+	}
+	return config
+}
+
+// Returns the mask of a secret in the debug output, or an empty string for an
+// unset secret.
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "<redacted>"
+}
+
+// Returns the client authentication method of the token requests,
+// "<detected>" when detected on the first request.
+func oauthAuthMethod(method OauthAuthMethod) string {
+	if method == "" {
+		return "<detected>"
+	}
+	return string(method)
+}
+
+// Returns the authentication modes of the requests, "none" when there is
+// none, e.g. ["jwt"] or ["login", "basic"].
+func (client *APIClient) authModes() []string {
+	var modes []string
+	if client.Token != "" {
+		modes = append(modes, "token")
+	}
+	if client.Jwt != nil {
+		modes = append(modes, "jwt")
+	}
+	if client.OauthConfig != nil {
+		modes = append(modes, "oauth_client_credentials")
+	}
+	if client.OauthRefreshTokenSource != nil {
+		modes = append(modes, "oauth_refresh_token")
+	}
+	if client.Login != nil {
+		modes = append(modes, "login")
+	}
+	if client.Username != "" && client.Password != "" {
+		modes = append(modes, "basic")
+	}
+	if len(modes) == 0 {
+		modes = append(modes, "none")
+	}
+	return modes
+}
@@ -0,0 +1,54 @@
+package apiclient
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAPIClient_effectiveConfig(t *testing.T) {
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:       "https://api.example.com/",
+		Timeout:   10,
+		RateLimit: 0.5,
+		Headers:   map[string]string{"X-Api-Key": "k3y-s3cret"},
+		Username:  "terraform",
+		Password:  "passw0rd-s3cret",
+		Jwt:       &JwtHashedToken{Secret: []byte("jwt-s3cret"), Claims: map[string]any{"sub": "terraform"}},
+		Login:     &SessionLogin{Path: "/login", Body: `{"password":"login-s3cret"}`, CookieName: "SESSIONID"},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient failed: %s", err)
+	}
+
+	config := client.EffectiveConfig()
+	expected := map[string]string{
+		"uri":                      "https://api.example.com",
+		"auth_modes":               "[jwt login basic]",
+		"auth_header":              "Authorization: Bearer <redacted>",
+		"headers":                  "[X-Api-Key]",
+		"read_method":              "GET",
+		"update_method":            "PUT",
+		"id_attribute":             "<detected>",
+		"timeout":                  "10s",
+		"rate_limit":               "0.5 requests/s, in bursts of 1: one request every 2s",
+		"retry_jitter":             "full",
+		"retry_max_wait":           "30s",
+		"response_format":          "json",
+		"tls_insecure_skip_verify": "false",
+		"jwt_algorithm":            "HS256",
+		"login_request":            "POST /login",
+		"login_session_cookie":     "SESSIONID",
+	}
+	for key, value := range expected {
+		if fmt.Sprint(config[key]) != value {
+			t.Errorf("EffectiveConfig()[%s] = %v; want %s", key, config[key], value)
+		}
+	}
+
+	for _, dump := range []string{fmt.Sprint(config), client.toString()} {
+		if strings.Contains(dump, "s3cret") {
+			t.Errorf("A secret is not masked in the client configuration:\n%s", dump)
+		}
+	}
+}
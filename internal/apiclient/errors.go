@@ -0,0 +1,97 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned by SendRequest when the API answers with an
+// unexpected status code. Use errors.As to branch on the status.
+type APIError struct {
+	StatusCode int
+	Body       string
+	Method     string
+	Path       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected response code '%d': %s", e.StatusCode, e.Body)
+}
+
+// ResponseErrorsError is returned by SendRequest when a successful response
+// reports errors at the ResponseErrorsPath of its body, like GraphQL APIs
+// answering 200 with an "errors" array.
+type ResponseErrorsError struct {
+	// JSON encoding of the errors
+	Errors string
+	Body   string
+	Method string
+	Path   string
+}
+
+func (e *ResponseErrorsError) Error() string {
+	return fmt.Sprintf("the response reports errors: %s", e.Errors)
+}
+
+// Returns the JSON encoding of the value at the dot-separated path of the
+// response body, and whether it reports errors: it is not null, nor an empty
+// array, object or string. A body that is not JSON reports no errors.
+func responseErrors(body string, path string) (string, bool) {
+	data, err := decodeJsonNumbers(body)
+	if err != nil {
+		return "", false
+	}
+	value, ok := lookupPath(data, path)
+	if !ok {
+		return "", false
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case []any:
+		if len(v) == 0 {
+			return "", false
+		}
+	case map[string]any:
+		if len(v) == 0 {
+			return "", false
+		}
+	case string:
+		if v == "" {
+			return "", false
+		}
+	}
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value), true
+	}
+	return string(jsonBytes), true
+}
+
+// Returns the status code of the API error wrapped in err, or 0 when err is
+// not an API error.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// Returns whether err is an API error answering a request of the method with
+// 405 Method Not Allowed, e.g. a DELETE on an object the API can't delete.
+func IsMethodNotAllowed(err error, method string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusMethodNotAllowed && strings.EqualFold(apiErr.Method, method)
+}
+
+// Returns the configured message of the status code of the API error wrapped
+// in err. The bool is false when err is not an API error or its status code
+// has no message.
+func (client *APIClient) StatusMessage(err error) (string, bool) {
+	message, ok := client.StatusMessages[StatusCode(err)]
+	return message, ok
+}
@@ -0,0 +1,139 @@
+package apiclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such object", http.StatusNotFound)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	_, err = client.SendRequest("GET", "/api/objects/1", "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("SendRequest error should be an APIError, got: %T %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Method != "GET" || apiErr.Path != "/api/objects/1" {
+		t.Errorf("Unexpected APIError fields: %+v", apiErr)
+	}
+	if err.Error() != "unexpected response code '404': no such object\n" {
+		t.Errorf("Unexpected APIError message: %q", err.Error())
+	}
+
+	wrapped := fmt.Errorf("read failed: %w", err)
+	if StatusCode(wrapped) != http.StatusNotFound {
+		t.Errorf("StatusCode(wrapped) = %d; want %d", StatusCode(wrapped), http.StatusNotFound)
+	}
+	if StatusCode(errors.New("network error")) != 0 {
+		t.Error("StatusCode should return 0 for errors that are not API errors")
+	}
+}
+
+func TestIsMethodNotAllowed(t *testing.T) {
+	notAllowed := fmt.Errorf("delete failed: %w", &APIError{StatusCode: http.StatusMethodNotAllowed, Method: "DELETE", Path: "/api/objects/1"})
+	tests := []struct {
+		err      error
+		method   string
+		expected bool
+	}{
+		{notAllowed, "DELETE", true},
+		{notAllowed, "delete", true},
+		{notAllowed, "PUT", false},
+		{&APIError{StatusCode: http.StatusNotFound, Method: "DELETE"}, "DELETE", false},
+		{errors.New("network error"), "DELETE", false},
+		{nil, "DELETE", false},
+	}
+
+	for _, test := range tests {
+		if IsMethodNotAllowed(test.err, test.method) != test.expected {
+			t.Errorf("IsMethodNotAllowed(%v, %s) = %t; want %t", test.err, test.method, !test.expected, test.expected)
+		}
+	}
+}
+
+func TestAPIClient_StatusMessage(t *testing.T) {
+	client := &APIClient{StatusMessages: map[int]string{http.StatusUnauthorized: "check credentials"}}
+
+	message, ok := client.StatusMessage(fmt.Errorf("create failed: %w", &APIError{StatusCode: http.StatusUnauthorized}))
+	if !ok || message != "check credentials" {
+		t.Errorf("StatusMessage(401) = %q, %t; want \"check credentials\", true", message, ok)
+	}
+	if _, ok := client.StatusMessage(&APIError{StatusCode: http.StatusForbidden}); ok {
+		t.Error("StatusMessage should not find a message for an unmapped status code")
+	}
+	if _, ok := client.StatusMessage(errors.New("network error")); ok {
+		t.Error("StatusMessage should not find a message for errors that are not API errors")
+	}
+}
+
+func TestAPIClient_responseErrors(t *testing.T) {
+	responses := map[string]string{
+		"/failed":       `{"errors":[{"message":"tenant already exists","path":["createTenant"]}],"data":null}`,
+		"/message":      `{"errors":"quota exceeded","data":null}`,
+		"/empty":        `{"errors":[],"data":{"id":1}}`,
+		"/null":         `{"errors":null,"data":{"id":1}}`,
+		"/missing":      `{"data":{"id":1}}`,
+		"/text":         `OK`,
+		"/unauthorized": `{"errors":[{"message":"unauthorized"}]}`,
+	}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unauthorized" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		fmt.Fprint(w, responses[r.URL.Path])
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, ResponseErrorsPath: "errors"})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	tests := []struct {
+		path   string
+		errors string
+	}{
+		{"/failed", `[{"message":"tenant already exists","path":["createTenant"]}]`},
+		{"/message", `"quota exceeded"`},
+		{"/empty", ""},
+		{"/null", ""},
+		{"/missing", ""},
+		{"/text", ""},
+	}
+	for _, test := range tests {
+		body, err := client.SendRequest("POST", test.path, "{}")
+		var errorsErr *ResponseErrorsError
+		if test.errors == "" {
+			if err != nil {
+				t.Errorf("SendRequest(%s) returned the error: %s", test.path, err)
+			}
+			continue
+		}
+		if !errors.As(err, &errorsErr) {
+			t.Errorf("SendRequest(%s) error should be a ResponseErrorsError, got: %T %v", test.path, err, err)
+			continue
+		}
+		if errorsErr.Errors != test.errors || errorsErr.Method != "POST" || errorsErr.Path != test.path || body != responses[test.path] {
+			t.Errorf("Unexpected ResponseErrorsError fields: %+v", errorsErr)
+		}
+		if err.Error() != "the response reports errors: "+test.errors {
+			t.Errorf("Unexpected ResponseErrorsError message: %q", err.Error())
+		}
+	}
+
+	/* The status code is checked first */
+	if _, err := client.SendRequest("GET", "/unauthorized", ""); StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("SendRequest(/unauthorized) should return the 401 API error, got: %v", err)
+	}
+}
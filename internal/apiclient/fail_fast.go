@@ -0,0 +1,42 @@
+package apiclient
+
+import (
+	"fmt"
+	"sync"
+)
+
+// failFastBreaker stops sending requests once one of them failed with a 5xx,
+// the API server being considered down: the next requests fail at once
+// instead of each waiting for its own error, e.g. the remaining resources of
+// an apply. It stays open for the life of the client.
+type failFastBreaker struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Opens the breaker when err is a 5xx API error, keeping the first one. A nil
+// breaker, fail_fast being unset, is never opened.
+func (b *failFastBreaker) trip(err error) {
+	if b == nil || StatusCode(err) < 500 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Returns the error of a request not sent once the breaker is open, nil
+// otherwise.
+func (b *failFastBreaker) check() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		return nil
+	}
+	return fmt.Errorf("request not sent with fail_fast set, a previous request failed: %v", b.err)
+}
@@ -0,0 +1,70 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAPIClient_failFast(t *testing.T) {
+	var requests atomic.Int64
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/down":
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		case "/missing":
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		default:
+			fmt.Fprint(w, `{"id":"1"}`)
+		}
+	}))
+	defer svr.Close()
+
+	newClient := func(failFast bool) *APIClient {
+		client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, MaxRetries: 1, FailFast: failFast})
+		if err != nil {
+			t.Fatalf("NewAPIClient returned an error: %s", err)
+		}
+		client.retryBaseWait = time.Millisecond
+		return client
+	}
+
+	/* Without fail_fast, each request fails on its own */
+	client := newClient(false)
+	if _, err := client.SendRequest("GET", "/down", ""); StatusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("Unexpected error of the unavailable API: %v", err)
+	}
+	if _, err := client.SendRequest("GET", "/api/objects/1", ""); err != nil {
+		t.Errorf("The request following a 503 should be sent without fail_fast: %s", err)
+	}
+
+	/* A 4xx doesn't open the circuit */
+	client = newClient(true)
+	if _, err := client.SendRequest("GET", "/missing", ""); StatusCode(err) != http.StatusNotFound {
+		t.Fatalf("Unexpected error of the missing object: %v", err)
+	}
+	if _, err := client.SendRequest("GET", "/api/objects/1", ""); err != nil {
+		t.Errorf("The request following a 404 should be sent: %s", err)
+	}
+
+	/* The 503 is retried first, then fails the next requests without sending them */
+	requests.Store(0)
+	if _, err := client.SendRequest("GET", "/down", ""); StatusCode(err) != http.StatusServiceUnavailable || requests.Load() != 2 {
+		t.Fatalf("The 503 should be returned after 1 retry, got %v after %d request(s)", err, requests.Load())
+	}
+	_, err := client.SendRequest("GET", "/api/objects/1", "")
+	if err == nil || StatusCode(err) != 0 || !strings.Contains(err.Error(), "503") {
+		t.Errorf("The request should fail at once with the first error, got: %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("No request should be sent once the circuit is open, got %d", requests.Load())
+	}
+	if client.EffectiveConfig()["fail_fast"] != true {
+		t.Errorf("Unexpected effective fail_fast: %v", client.EffectiveConfig()["fail_fast"])
+	}
+}
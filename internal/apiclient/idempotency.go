@@ -0,0 +1,20 @@
+package apiclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Returns the idempotency key of a create request: the hex-encoded SHA-256 of
+// its method, path and body. Being derived from the request only, the key is
+// the same for all its attempts, so that the API server can answer a retry
+// of a create it already processed without creating the object again.
+func idempotencyKey(method string, path string, data string) string {
+	hash := sha256.New()
+	for _, part := range []string{method, path, data} {
+		hash.Write([]byte(part))
+		/* Separates the parts, e.g. the path "/a" and the body "b" from "/ab" */
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,63 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAPIClient_idempotencyKey(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		/* The first 2 attempts of each request fail */
+		if len(keys)%3 != 0 {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1"}`)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, MaxRetries: 2, IdempotencyKeyHeader: "Idempotency-Key"})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	client.retryBaseWait = time.Millisecond
+	send := func(method string, data string, operation Operation) []string {
+		keys = nil
+		if _, err := client.SendRequestWithOpt(context.Background(), method, "/api/objects", data, &RequestOpt{Operation: operation}); err != nil {
+			t.Fatalf("The request should succeed after 2 retries: %s", err)
+		}
+		if len(keys) != 3 {
+			t.Fatalf("Unexpected number of attempts: %d; want 3", len(keys))
+		}
+		return keys
+	}
+
+	/* The same key is sent with all the attempts of a create */
+	created := send("POST", `{"name":"alice"}`, OperationCreate)
+	if created[0] == "" || created[1] != created[0] || created[2] != created[0] {
+		t.Errorf("The attempts of the create should reuse the same key, got: %v", created)
+	}
+	if again := send("POST", `{"name":"alice"}`, OperationCreate); again[0] != created[0] {
+		t.Errorf("The key should be derived from the request, got %s then %s", created[0], again[0])
+	}
+	if other := send("POST", `{"name":"bob"}`, OperationCreate); other[0] == created[0] {
+		t.Errorf("Another body should get another key than %s", created[0])
+	}
+
+	/* The other operations are sent without key */
+	for _, operation := range []Operation{OperationUpdate, ""} {
+		if updated := send("PUT", `{"name":"alice"}`, operation); updated[0] != "" {
+			t.Errorf("A request of the operation '%s' should have no idempotency key, got: %v", operation, updated)
+		}
+	}
+}
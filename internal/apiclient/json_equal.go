@@ -0,0 +1,147 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// JsonEqual reports whether two JSON documents are structurally equal,
+// whatever their key order and insignificant whitespace. Arrays are compared
+// element by element, numbers by their decimal value (1.0 equals 1). The
+// values at the dot-separated ignoredPaths, e.g. "meta.updated_at", are left
+// out of the comparison on both sides; an array element is ignored with its
+// index, e.g. "items.0".
+func JsonEqual(a string, b string, ignoredPaths ...string) (bool, error) {
+	dataA, err := decodeJsonNumbers(a)
+	if err != nil {
+		return false, fmt.Errorf("first document: %w", err)
+	}
+	dataB, err := decodeJsonNumbers(b)
+	if err != nil {
+		return false, fmt.Errorf("second document: %w", err)
+	}
+
+	for _, path := range ignoredPaths {
+		dataA = removePath(dataA, path)
+		dataB = removePath(dataB, path)
+	}
+	return jsonValuesEqual(dataA, dataB), nil
+}
+
+// JsonIncludes reports whether the JSON document b holds the document a: the
+// objects of b have all the keys of the matching objects of a with equal
+// values, and may have more, e.g. defaults added by the API server. Arrays
+// have the same length, their elements compared in order, and the other
+// values are compared as with JsonEqual.
+func JsonIncludes(a string, b string) (bool, error) {
+	dataA, err := decodeJsonNumbers(a)
+	if err != nil {
+		return false, fmt.Errorf("first document: %w", err)
+	}
+	dataB, err := decodeJsonNumbers(b)
+	if err != nil {
+		return false, fmt.Errorf("second document: %w", err)
+	}
+	return jsonValueIncluded(dataA, dataB), nil
+}
+
+// Removes the value at the dot-separated path of the decoded JSON data. An
+// array element is replaced by null to keep the indexes of the next ones. A
+// missing path leaves the data unchanged.
+func removePath(data any, path string) any {
+	if path == "" {
+		return nil
+	}
+	parentPath, key := "", path
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		parentPath, key = path[:i], path[i+1:]
+	}
+
+	parent, ok := lookupPath(data, parentPath)
+	if !ok {
+		return data
+	}
+	switch v := parent.(type) {
+	case map[string]any:
+		delete(v, key)
+	case []any:
+		if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(v) {
+			v[index] = nil
+		}
+	}
+	return data
+}
+
+// Reports whether the value b, decoded by decodeJsonNumbers, holds a.
+func jsonValueIncluded(a any, b any) bool {
+	switch va := a.(type) {
+	case map[string]any:
+		vb, ok := b.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, valueA := range va {
+			valueB, ok := vb[key]
+			if !ok || !jsonValueIncluded(valueA, valueB) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		vb, ok := b.([]any)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !jsonValueIncluded(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return jsonValuesEqual(a, b)
+	}
+}
+
+// Compares two values decoded by decodeJsonNumbers.
+func jsonValuesEqual(a any, b any) bool {
+	switch va := a.(type) {
+	case map[string]any:
+		vb, ok := b.(map[string]any)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for key, valueA := range va {
+			valueB, ok := vb[key]
+			if !ok || !jsonValuesEqual(valueA, valueB) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		vb, ok := b.([]any)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !jsonValuesEqual(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		vb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		ratA, okA := new(big.Rat).SetString(va.String())
+		ratB, okB := new(big.Rat).SetString(vb.String())
+		return okA && okB && ratA.Cmp(ratB) == 0
+	default:
+		// Strings, booleans and null.
+		return a == b
+	}
+}
@@ -0,0 +1,88 @@
+package apiclient
+
+import (
+	"testing"
+)
+
+func TestJsonEqual(t *testing.T) {
+	tests := []struct {
+		a            string
+		b            string
+		ignoredPaths []string
+		expected     bool
+	}{
+		{`{"a":1,"b":"x"}`, "{\n  \"b\": \"x\",\n  \"a\": 1\n}", nil, true},
+		{`{"a":{"b":{"c":[1,{"d":true}]}}}`, `{"a":{"b":{"c":[1,{"d":true}]}}}`, nil, true},
+		{`{"a":{"b":{"c":[1,{"d":true}]}}}`, `{"a":{"b":{"c":[1,{"d":false}]}}}`, nil, false},
+		{`{"a":[1,2,3]}`, `{"a":[3,2,1]}`, nil, false},
+		{`{"a":[1,2]}`, `{"a":[1,2,3]}`, nil, false},
+		{`{"a":1}`, `{"a":1.0}`, nil, true},
+		{`{"a":100}`, `{"a":1e2}`, nil, true},
+		{`{"a":12345678901234567890}`, `{"a":12345678901234567891}`, nil, false},
+		{`{"a":1}`, `{"a":"1"}`, nil, false},
+		{`{"a":null}`, `{"a":null}`, nil, true},
+		{`{"a":null}`, `{}`, nil, false},
+		{`{"a":null}`, `{"a":false}`, nil, false},
+		{`null`, `null`, nil, true},
+		{`{"a":1}`, `{"a":1,"b":2}`, nil, false},
+		{`{"a":1,"meta":{"updated_at":"today","v":1}}`, `{"a":1,"meta":{"updated_at":"yesterday","v":1}}`, []string{"meta.updated_at"}, true},
+		{`{"a":1,"meta":{"updated_at":"today","v":1}}`, `{"a":1,"meta":{"updated_at":"yesterday","v":2}}`, []string{"meta.updated_at"}, false},
+		{`{"a":1,"etag":"x"}`, `{"a":1}`, []string{"etag"}, true},
+		{`{"items":[{"id":1,"at":"t1"},{"id":2,"at":"t2"}]}`, `{"items":[{"id":1,"at":"t3"},{"id":2,"at":"t2"}]}`, []string{"items.0.at"}, true},
+		{`{"items":["t1","x"]}`, `{"items":["t2","x"]}`, []string{"items.0"}, true},
+		{`{"a":1}`, `{"a":1}`, []string{"missing.path"}, true},
+	}
+
+	for _, test := range tests {
+		result, err := JsonEqual(test.a, test.b, test.ignoredPaths...)
+		if err != nil {
+			t.Errorf("JsonEqual(%s, %s) returned an error: %s", test.a, test.b, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("JsonEqual(%s, %s, %v) = %t; want %t", test.a, test.b, test.ignoredPaths, result, test.expected)
+		}
+	}
+
+	if _, err := JsonEqual(`{"a":`, `{}`); err == nil {
+		t.Error("JsonEqual should fail on an invalid first document")
+	}
+	if _, err := JsonEqual(`{}`, `[1,`); err == nil {
+		t.Error("JsonEqual should fail on an invalid second document")
+	}
+}
+
+func TestJsonIncludes(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected bool
+	}{
+		{`{"a":1}`, `{"a":1}`, true},
+		{`{"a":1}`, `{"a":1.0,"b":2}`, true},
+		{`{"a":{"b":true}}`, `{"a":{"b":true,"c":"default"},"d":[]}`, true},
+		{`{"items":[{"id":1},{"id":2}]}`, `{"items":[{"id":1,"x":0},{"id":2,"x":0}]}`, true},
+		{`{"a":1}`, `{"a":2,"b":2}`, false},
+		{`{"a":1,"b":2}`, `{"a":1}`, false},
+		{`{"a":{"b":true}}`, `{"a":{"b":false,"c":"default"}}`, false},
+		{`{"items":[1,2]}`, `{"items":[1,2,3]}`, false},
+		{`{"a":null}`, `{}`, false},
+		{`{"a":{}}`, `{"a":null}`, false},
+		{`"x"`, `"x"`, true},
+	}
+
+	for _, test := range tests {
+		result, err := JsonIncludes(test.a, test.b)
+		if err != nil {
+			t.Errorf("JsonIncludes(%s, %s) returned an error: %s", test.a, test.b, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("JsonIncludes(%s, %s) = %t; want %t", test.a, test.b, result, test.expected)
+		}
+	}
+
+	if _, err := JsonIncludes(`{}`, `[1,`); err == nil {
+		t.Error("JsonIncludes should fail on an invalid second document")
+	}
+}
@@ -0,0 +1,44 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats of the API response bodies.
+const (
+	// A single JSON document, the default.
+	ResponseFormatJson = "json"
+	// Newline-delimited JSON values, e.g. the events of a log API, converted
+	// into a JSON array.
+	ResponseFormatJsonLines = "jsonl"
+)
+
+// Returns the JSON array of the successive JSON values of a JSON Lines body.
+// The values are kept as sent; blank lines are skipped.
+func JsonLinesToArray(body string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	var array bytes.Buffer
+
+	array.WriteByte('[')
+	for count := 0; ; count++ {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("the JSON Lines response can't be decoded after %d values: %w", count, err)
+		}
+		if count > 0 {
+			array.WriteByte(',')
+		}
+		array.Write(value)
+	}
+	array.WriteByte(']')
+	return array.String(), nil
+}
@@ -0,0 +1,73 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJsonLinesToArray(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+		fails    bool
+	}{
+		{"{\"id\":\"1\"}\n{\"id\":\"2\",\"tags\":[\"a\"]}\n", `[{"id":"1"},{"id":"2","tags":["a"]}]`, false},
+		{"{\"id\":\"1\"}\r\n\r\n{\"id\":\"2\"}", `[{"id":"1"},{"id":"2"}]`, false},
+		{"{\"id\":\"1\"}", `[{"id":"1"}]`, false},
+		{"", `[]`, false},
+		{"{\"id\":\"1\"}\n{\"id\":", "", true},
+		{"{\"id\":\"1\"}\nnot json", "", true},
+	}
+
+	for _, test := range tests {
+		result, err := JsonLinesToArray(test.body)
+		if test.fails {
+			if err == nil {
+				t.Errorf("JsonLinesToArray(%q) should return an error, got: %s", test.body, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("JsonLinesToArray(%q) returned an error: %s", test.body, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("JsonLinesToArray(%q) = %s; want %s", test.body, result, test.expected)
+		}
+	}
+}
+
+func TestAPIClient_jsonLinesResponse(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, "{\"event\":\"created\",\"id\":\"1\"}\n")
+		fmt.Fprint(w, "{\"event\":\"updated\",\"id\":\"1\"}\n")
+		fmt.Fprint(w, "{\"event\":\"deleted\",\"id\":\"1\"}\n")
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, ResponseFormat: ResponseFormatJsonLines})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	body, err := client.SendRequest("GET", "/events", "")
+	if err != nil {
+		t.Fatalf("SendRequest returned an error: %s", err)
+	}
+	expected := `[{"event":"created","id":"1"},{"event":"updated","id":"1"},{"event":"deleted","id":"1"}]`
+	if body != expected {
+		t.Errorf("SendRequest() = %s; want %s", body, expected)
+	}
+
+	/* The body of an error is kept as sent */
+	if _, err := client.SendRequest("GET", "/missing", ""); err == nil || err.Error() != "unexpected response code '404': {\"error\":\"not found\"}\n" {
+		t.Errorf("Unexpected error on a missing object: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package apiclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Returns the value found at the given dot-separated path of a JSON document,
+// e.g. "meta.found" or "items.0.id". A numeric path element indexes an array.
+// An empty path returns the whole document. Numbers are returned as
+// json.Number, keeping all their digits, e.g. of a large numeric identifier.
+func GetValueAtPath(jsonData string, path string) (any, error) {
+	data, err := decodeJsonNumbers(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := lookupPath(data, path)
+	if !ok {
+		return nil, fmt.Errorf("path %s not found", path)
+	}
+
+	return value, nil
+}
+
+// Nested version of GetKeyValue: returns the value at the dot-separated path
+// of the object in the API response (see JsonDecodeApiResponse). Strings are
+// returned as is, other values JSON encoded.
+func GetNestedKeyValue(jsonData string, path string) (string, error) {
+	mapData, err := JsonDecodeApiResponse(jsonData)
+	if err != nil {
+		return "", err
+	}
+	value, ok := lookupPath(mapData, path)
+	if !ok {
+		return "", fmt.Errorf("path %s not found", path)
+	}
+	if result, ok := value.(string); ok {
+		return result, nil
+	}
+
+	jsonBytes, err := JsonMarshal(value)
+	if err != nil {
+		return "", fmt.Errorf("the value of the path %s can't be encoded into JSON: %v", path, value)
+	}
+	return string(jsonBytes), nil
+}
+
+// Walks the decoded JSON data along the dot-separated path.
+func lookupPath(data any, path string) (any, bool) {
+	if path == "" {
+		return data, true
+	}
+
+	current := data
+	for _, key := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			value, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// Returns the JSON encoding of the value at the dot-separated path of a JSON
+// document, numbers kept as written. The bool is false when the path is not
+// found.
+func GetJsonAtPath(jsonData string, path string) (string, bool, error) {
+	data, err := decodeJsonNumbers(jsonData)
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := lookupPath(data, path)
+	if !ok {
+		return "", false, nil
+	}
+	jsonBytes, err := JsonMarshal(value)
+	if err != nil {
+		return "", false, fmt.Errorf("the value of the path %s can't be encoded into JSON: %v", path, value)
+	}
+	return string(jsonBytes), true, nil
+}
+
+// Returns the JSON document with the value at the dot-separated path set to
+// the JSON valueJson, creating the missing objects along the path. The other
+// values are kept, numbers as written.
+func SetJsonAtPath(jsonData string, path string, valueJson string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("the path is empty")
+	}
+	data, err := decodeJsonNumbers(jsonData)
+	if err != nil {
+		return "", err
+	}
+	value, err := decodeJsonNumbers(valueJson)
+	if err != nil {
+		return "", fmt.Errorf("the value: %w", err)
+	}
+	data, err = setPath(data, strings.Split(path, "."), value)
+	if err != nil {
+		return "", fmt.Errorf("the path %s can't be set: %w", path, err)
+	}
+	return encodeJsonValue(data)
+}
+
+// Returns the JSON document without the value at the dot-separated path (see
+// removePath). A missing path leaves the document unchanged.
+func RemoveJsonAtPath(jsonData string, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("the path is empty")
+	}
+	data, err := decodeJsonNumbers(jsonData)
+	if err != nil {
+		return "", err
+	}
+	return encodeJsonValue(removePath(data, path))
+}
+
+// Sets the value at the path keys of the decoded JSON data, creating the
+// missing objects. An array element must exist to be set.
+func setPath(data any, keys []string, value any) (any, error) {
+	if len(keys) == 0 {
+		return value, nil
+	}
+	switch v := data.(type) {
+	case nil:
+		return setPath(map[string]any{}, keys, value)
+	case map[string]any:
+		child, err := setPath(v[keys[0]], keys[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[keys[0]] = child
+		return v, nil
+	case []any:
+		index, err := strconv.Atoi(keys[0])
+		if err != nil || index < 0 || index >= len(v) {
+			return nil, fmt.Errorf("%s is not an index of the array", keys[0])
+		}
+		child, err := setPath(v[index], keys[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[index] = child
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%s can't be set in the non-object value %v", keys[0], v)
+	}
+}
+
+// JsonPredicate matches a JSON document when the value at Path, converted to
+// its string representation, equals Value.
+type JsonPredicate struct {
+	Path  string
+	Value string
+}
+
+// Returns whether the JSON document matches the predicate. A missing path
+// does not match. Numbers are compared as written, e.g. 1000000 and not 1e+06.
+func (p *JsonPredicate) Match(jsonData string) (bool, error) {
+	data, err := decodeJsonNumbers(jsonData)
+	if err != nil {
+		return false, err
+	}
+	value, ok := lookupPath(data, p.Path)
+	if !ok {
+		return false, nil
+	}
+
+	return fmt.Sprintf("%v", value) == p.Value, nil
+}
+
+// Returns the JSON of the single element of the JSON array matching the
+// predicate, e.g. to pick an object out of a filtering list endpoint
+// response. Zero or several matching elements are an error. A JSON object is
+// returned as is when it matches.
+func (p *JsonPredicate) SelectElement(jsonData string) (string, error) {
+	data, err := decodeJsonNumbers(jsonData)
+	if err != nil {
+		return "", err
+	}
+	elements, ok := data.([]any)
+	if !ok {
+		elements = []any{data}
+	}
+
+	var matches []any
+	for _, element := range elements {
+		if value, ok := lookupPath(element, p.Path); ok && fmt.Sprintf("%v", value) == p.Value {
+			matches = append(matches, element)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no element has the value %s at the path %s", p.Value, p.Path)
+	case 1:
+		jsonBytes, err := JsonMarshal(matches[0])
+		if err != nil {
+			return "", fmt.Errorf("the matching element can't be encoded into JSON: %v", matches[0])
+		}
+		return string(jsonBytes), nil
+	default:
+		return "", fmt.Errorf("%d elements have the value %s at the path %s", len(matches), p.Value, p.Path)
+	}
+}
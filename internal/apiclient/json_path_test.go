@@ -0,0 +1,228 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetValueAtPath(t *testing.T) {
+	jsonData := `{"meta":{"found":false,"count":2,"session":12345678901234567890},"items":[{"id":"1"},{"id":"2"}]}`
+	tests := []struct {
+		path     string
+		expected any
+		fails    bool
+	}{
+		{"meta.found", false, false},
+		{"meta.count", json.Number("2"), false},
+		{"meta.session", json.Number("12345678901234567890"), false},
+		{"items.1.id", "2", false},
+		{"items.2.id", nil, true},
+		{"items.first", nil, true},
+		{"meta.missing", nil, true},
+		{"meta.found.value", nil, true},
+	}
+
+	for _, test := range tests {
+		value, err := GetValueAtPath(jsonData, test.path)
+		if test.fails {
+			if err == nil {
+				t.Errorf("GetValueAtPath(%s) should return an error, got: %v", test.path, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("GetValueAtPath(%s) returned an error: %s", test.path, err)
+		}
+		if value != test.expected {
+			t.Errorf("GetValueAtPath(%s) = %v; want %v", test.path, value, test.expected)
+		}
+	}
+}
+
+func TestGetNestedKeyValue(t *testing.T) {
+	jsonData := `[{"id":"1","meta":{"version":3,"owner":"team_a","tags":["a","b"]}}]`
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"id", "1"},
+		{"meta.owner", "team_a"},
+		{"meta.version", "3"},
+		{"meta.tags", `["a","b"]`},
+		{"meta.tags.1", "b"},
+	}
+
+	for _, test := range tests {
+		value, err := GetNestedKeyValue(jsonData, test.path)
+		if err != nil {
+			t.Errorf("GetNestedKeyValue(%s) returned an error: %s", test.path, err)
+		}
+		if value != test.expected {
+			t.Errorf("GetNestedKeyValue(%s) = %s; want %s", test.path, value, test.expected)
+		}
+	}
+
+	if _, err := GetNestedKeyValue(jsonData, "meta.missing"); err == nil {
+		t.Error("GetNestedKeyValue should return an error on a missing path")
+	}
+}
+
+func TestJsonPredicate_notFoundEnvelope(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(`{"found": false}`)); err != nil {
+			t.Errorf("Error on sending the envelope: %s", err)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	res, err := client.SendRequest("GET", "/api/objects?identifier=missing", "")
+	if err != nil {
+		t.Fatalf("The envelope request failed: %s", err)
+	}
+
+	tests := []struct {
+		predicate JsonPredicate
+		expected  bool
+	}{
+		{JsonPredicate{Path: "found", Value: "false"}, true},
+		{JsonPredicate{Path: "found", Value: "true"}, false},
+		{JsonPredicate{Path: "missing", Value: "false"}, false},
+	}
+	for _, test := range tests {
+		match, err := test.predicate.Match(res)
+		if err != nil {
+			t.Errorf("Match(%+v) returned an error: %s", test.predicate, err)
+		}
+		if match != test.expected {
+			t.Errorf("Match(%+v) = %t; want %t", test.predicate, match, test.expected)
+		}
+	}
+
+	if _, err := (&JsonPredicate{Path: "found", Value: "false"}).Match("not json"); err == nil {
+		t.Error("Match should return an error on an invalid JSON document")
+	}
+}
+
+func TestJsonPredicate_SelectElement(t *testing.T) {
+	jsonData := `[{"id":"1","identifier":"tenant_1","meta":{"region":"eu"}},{"id":"2","identifier":"tenant_10","meta":{"region":"eu"}}]`
+	tests := []struct {
+		predicate JsonPredicate
+		jsonData  string
+		expected  string
+		fails     bool
+	}{
+		{JsonPredicate{Path: "identifier", Value: "tenant_1"}, jsonData, `{"id":"1","identifier":"tenant_1","meta":{"region":"eu"}}`, false},
+		{JsonPredicate{Path: "id", Value: "2"}, jsonData, `{"id":"2","identifier":"tenant_10","meta":{"region":"eu"}}`, false},
+		{JsonPredicate{Path: "id", Value: "1"}, `{"id":"1"}`, `{"id":"1"}`, false},
+		{JsonPredicate{Path: "identifier", Value: "tenant_3"}, jsonData, "", true},
+		{JsonPredicate{Path: "meta.region", Value: "eu"}, jsonData, "", true},
+		{JsonPredicate{Path: "id", Value: "1"}, `not json`, "", true},
+	}
+
+	for _, test := range tests {
+		element, err := test.predicate.SelectElement(test.jsonData)
+		if test.fails {
+			if err == nil {
+				t.Errorf("SelectElement(%+v) should return an error, got: %s", test.predicate, element)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SelectElement(%+v) returned an error: %s", test.predicate, err)
+		}
+		if element != test.expected {
+			t.Errorf("SelectElement(%+v) = %s; want %s", test.predicate, element, test.expected)
+		}
+	}
+}
+
+func TestJsonAtPath(t *testing.T) {
+	document := `{"features":{"new_ui":false,"limit":12345678901234567890},"items":[{"id":1}],"name":"main"}`
+
+	getTests := []struct {
+		path     string
+		expected string
+		found    bool
+	}{
+		{"features.new_ui", "false", true},
+		{"features.limit", "12345678901234567890", true},
+		{"items.0", `{"id":1}`, true},
+		{"features.missing", "", false},
+	}
+	for _, test := range getTests {
+		value, found, err := GetJsonAtPath(document, test.path)
+		if err != nil || value != test.expected || found != test.found {
+			t.Errorf("GetJsonAtPath(%s) = %s, %t, %v; want %s, %t", test.path, value, found, err, test.expected, test.found)
+		}
+	}
+
+	setTests := []struct {
+		path     string
+		value    string
+		expected string
+	}{
+		{"features.new_ui", "true", `{"features":{"limit":12345678901234567890,"new_ui":true},"items":[{"id":1}],"name":"main"}`},
+		{"features.beta.enabled", `"yes"`, `{"features":{"beta":{"enabled":"yes"},"limit":12345678901234567890,"new_ui":false},"items":[{"id":1}],"name":"main"}`},
+		{"items.0.id", "2", `{"features":{"limit":12345678901234567890,"new_ui":false},"items":[{"id":2}],"name":"main"}`},
+	}
+	for _, test := range setTests {
+		result, err := SetJsonAtPath(document, test.path, test.value)
+		if err != nil || result != test.expected {
+			t.Errorf("SetJsonAtPath(%s, %s) = %s, %v; want %s", test.path, test.value, result, err, test.expected)
+		}
+	}
+	for _, path := range []string{"", "name.first", "items.1.id", "items.x"} {
+		if _, err := SetJsonAtPath(document, path, "true"); err == nil {
+			t.Errorf("SetJsonAtPath(%s) should return an error", path)
+		}
+	}
+	if _, err := SetJsonAtPath(document, "name", "{invalid"); err == nil {
+		t.Error("SetJsonAtPath should fail on an invalid value")
+	}
+
+	result, err := RemoveJsonAtPath(document, "features.new_ui")
+	if expected := `{"features":{"limit":12345678901234567890},"items":[{"id":1}],"name":"main"}`; err != nil || result != expected {
+		t.Errorf("RemoveJsonAtPath() = %s, %v; want %s", result, err, expected)
+	}
+}
+
+func TestNumberPrecision(t *testing.T) {
+	/* Numbers float64 can't hold exactly, or that it would print in exponent form */
+	jsonData := `[{"id":12345678901234567890,"ratio":0.1000000000000000055,"count":1000000,"meta":{"big":12345678901234567890}}]`
+
+	id, _, err := ObjectId(jsonData, "")
+	if err != nil || id != "12345678901234567890" {
+		t.Errorf("ObjectId = %s, %v; want 12345678901234567890", id, err)
+	}
+	for path, expected := range map[string]string{"ratio": "0.1000000000000000055", "count": "1000000", "meta": `{"big":12345678901234567890}`} {
+		if value, err := GetNestedKeyValue(jsonData, path); err != nil || value != expected {
+			t.Errorf("GetNestedKeyValue(%s) = %s, %v; want %s", path, value, err, expected)
+		}
+	}
+
+	predicate := &JsonPredicate{Path: "count", Value: "1000000"}
+	if matched, err := predicate.Match(`{"count":1000000}`); err != nil || !matched {
+		t.Errorf("Match(count = 1000000) = %v, %v; want true", matched, err)
+	}
+	element, err := predicate.SelectElement(jsonData)
+	/* JsonEqual compares the numbers exactly */
+	if equal, _ := JsonEqual(element, jsonData[1:len(jsonData)-1]); err != nil || !equal {
+		t.Errorf("SelectElement = %s, %v; want the element with its numbers as written", element, err)
+	}
+	subtree, err := (&ResponseTransform{SelectSubtree: "meta"}).Apply(jsonData)
+	if err != nil || subtree != `{"big":12345678901234567890}` {
+		t.Errorf("Apply(select_subtree meta) = %s, %v; want the big integer as written", subtree, err)
+	}
+
+	/* The same numbers written differently are no change */
+	patch, err := MergePatch(`{"count":1000000,"ratio":0.10,"big":12345678901234567890}`, `{"count":1e6,"ratio":0.1,"big":12345678901234567891}`)
+	if err != nil || patch != `{"big":12345678901234567891}` {
+		t.Errorf("MergePatch = %s, %v; want only the big integer", patch, err)
+	}
+}
@@ -0,0 +1,71 @@
+package apiclient
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Returns whether the JWT algorithm signs with a private key instead of an
+// HMAC secret, i.e. the RS*, PS*, ES* and EdDSA algorithms.
+func IsAsymmetricJwtAlgorithm(algorithm string) bool {
+	return algorithm != "" && !strings.HasPrefix(algorithm, "HS")
+}
+
+// LoadJwtSigningKey parses the PEM private key of the asymmetric JWT
+// algorithms, in PKCS#1, PKCS#8 or SEC 1 format, and the optional PEM
+// certificate chain sent in the x5c header, leaf first. The leaf certificate
+// must match the private key. The chain is returned DER encoded.
+func LoadJwtSigningKey(keyPEM string, certPEM string) (crypto.Signer, [][]byte, error) {
+	if certPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, nil, fmt.Errorf("the JWT certificate and private key can't be loaded: %v", err)
+		}
+		signer, ok := cert.PrivateKey.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("the JWT private key of type %T can't sign", cert.PrivateKey)
+		}
+		return signer, cert.Certificate, nil
+	}
+
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, nil, errors.New("the JWT private key is not PEM encoded")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, nil, fmt.Errorf("unsupported JWT private key PEM block: %s", block.Type)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("the JWT private key can't be parsed: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("the JWT private key of type %T can't sign", key)
+	}
+	return signer, nil, nil
+}
+
+// Returns the x5c header of the DER certificate chain: the certificates
+// standard base64 encoded, not base64url (RFC 7515, section 4.1.6).
+func x5cHeader(chain [][]byte) []string {
+	x5c := make([]string, len(chain))
+	for i, der := range chain {
+		x5c[i] = base64.StdEncoding.EncodeToString(der)
+	}
+	return x5c
+}
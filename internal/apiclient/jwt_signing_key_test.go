@@ -0,0 +1,93 @@
+package apiclient
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLoadJwtSigningKey(t *testing.T) {
+	certFile, keyFile, cert := writeTestCertificate(t, "jwt-signer", x509.ExtKeyUsageClientAuth)
+	certPem, _ := os.ReadFile(certFile)
+	keyPem, _ := os.ReadFile(keyFile)
+
+	ecKey, chain, err := LoadJwtSigningKey(string(keyPem), string(certPem))
+	if err != nil {
+		t.Fatalf("LoadJwtSigningKey returned an error: %s", err)
+	}
+	if len(chain) != 1 || string(chain[0]) != string(cert.Certificate[0]) {
+		t.Fatalf("Unexpected certificate chain of %d certificates", len(chain))
+	}
+
+	/* The token carries the certificate in x5c and verifies with its key */
+	jwtToken := &JwtHashedToken{Algortithm: "ES256", Claims: map[string]any{"sub": "client-1"}, PrivateKey: ecKey, CertificateChain: chain}
+	signed, err := jwtToken.getSignedJwt()
+	if err != nil {
+		t.Fatalf("The jwt signing returned the error: %s", err)
+	}
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (any, error) {
+		x5c, ok := token.Header["x5c"].([]any)
+		if !ok || len(x5c) != 1 {
+			t.Fatalf("Unexpected x5c header: %v", token.Header["x5c"])
+		}
+		der, err := base64.StdEncoding.DecodeString(x5c[0].(string))
+		if err != nil {
+			return nil, err
+		}
+		leaf, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		return leaf.PublicKey, nil
+	})
+	if err != nil || !token.Valid {
+		t.Errorf("The token can't be verified with the x5c certificate: %v", err)
+	}
+
+	/* Without certificate, the key alone is loaded, here a PKCS#8 RSA one */
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Key generation failed: %s", err)
+	}
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	rsaPem := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+	privateKey, chain, err := LoadJwtSigningKey(string(rsaPem), "")
+	if err != nil {
+		t.Fatalf("LoadJwtSigningKey returned an error on a key without certificate: %s", err)
+	}
+	if _, ok := privateKey.(*rsa.PrivateKey); !ok || chain != nil {
+		t.Errorf("Unexpected key %T and chain %v", privateKey, chain)
+	}
+	jwtToken = &JwtHashedToken{Algortithm: "PS256", Claims: map[string]any{"sub": "client-1"}, PrivateKey: privateKey}
+	signed, err = jwtToken.getSignedJwt()
+	if err != nil {
+		t.Fatalf("The jwt signing returned the error: %s", err)
+	}
+	token, err = jwt.Parse(signed, func(*jwt.Token) (any, error) { return &rsaKey.PublicKey, nil })
+	if err != nil || !token.Valid || token.Header["x5c"] != nil {
+		t.Errorf("Unexpected token without certificate: %v", err)
+	}
+
+	/* The certificate of another key is rejected */
+	if _, _, err := LoadJwtSigningKey(string(rsaPem), string(certPem)); err == nil {
+		t.Error("LoadJwtSigningKey should fail on a certificate not matching the key")
+	}
+	if _, _, err := LoadJwtSigningKey("not a key", ""); err == nil {
+		t.Error("LoadJwtSigningKey should fail on a key not PEM encoded")
+	}
+
+	/* A key of another type than the algorithm's fails the client creation */
+	_, err = NewAPIClient(&ApiClientOpt{
+		Uri: "http://127.0.0.1:8083/",
+		Jwt: &JwtHashedToken{Algortithm: "RS256", Claims: map[string]any{}, PrivateKey: ecKey},
+	})
+	if err == nil {
+		t.Error("NewAPIClient should fail on an EC key with the RS256 algorithm")
+	}
+}
@@ -0,0 +1,41 @@
+package apiclient
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Returns the path, relative to the client URI, of the Location header of the
+// response to a request on requestPath, e.g. the URL of a created object. A
+// relative Location is resolved against the request URL; a Location out of the
+// client URI is an error.
+func (client *APIClient) LocationPath(requestPath string, location string) (string, error) {
+	if location == "" {
+		return "", errors.New("the response has no Location header")
+	}
+	base, err := url.Parse(client.Uri)
+	if err != nil {
+		return "", err
+	}
+	requestURL, err := url.Parse(client.Uri + requestPath)
+	if err != nil {
+		return "", err
+	}
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid Location header %s: %w", location, err)
+	}
+
+	resolved := requestURL.ResolveReference(locationURL)
+	basePath := strings.TrimRight(base.EscapedPath(), "/")
+	if resolved.Scheme != base.Scheme || !strings.EqualFold(resolved.Host, base.Host) || !strings.HasPrefix(resolved.EscapedPath(), basePath+"/") {
+		return "", fmt.Errorf("the Location %s is not under the base URI %s", location, client.Uri)
+	}
+	path := strings.TrimPrefix(resolved.EscapedPath(), basePath)
+	if resolved.RawQuery != "" {
+		path += "?" + resolved.RawQuery
+	}
+	return path, nil
+}
@@ -0,0 +1,70 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIClient_LocationPath(t *testing.T) {
+	client, err := NewAPIClient(&ApiClientOpt{Uri: "https://api.example.com/v1", Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	tests := []struct {
+		location string
+		expected string
+		fails    bool
+	}{
+		{"https://api.example.com/v1/tenants/42", "/tenants/42", false},
+		{"https://API.example.com/v1/tenants/42?expand=all", "/tenants/42?expand=all", false},
+		{"/v1/tenants/42", "/tenants/42", false},
+		{"42", "/tenants/42", false},
+		{"/v1/tenants/a%2Fb", "/tenants/a%2Fb", false},
+		{"", "", true},
+		{"https://other.example.com/v1/tenants/42", "", true},
+		{"http://api.example.com/v1/tenants/42", "", true},
+		{"/v2/tenants/42", "", true},
+		{"/v10/tenants/42", "", true},
+	}
+
+	for _, test := range tests {
+		path, err := client.LocationPath("/tenants/", test.location)
+		if test.fails {
+			if err == nil {
+				t.Errorf("LocationPath(%s) should return an error, got: %s", test.location, path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("LocationPath(%s) returned an error: %s", test.location, err)
+			continue
+		}
+		if path != test.expected {
+			t.Errorf("LocationPath(%s) = %s; want %s", test.location, path, test.expected)
+		}
+	}
+}
+
+func TestAPIClient_responseHeader(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/tenants/42")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	opt := &RequestOpt{ResponseHeader: http.Header{"Stale": []string{"previous response"}}}
+	if _, err := client.SendRequestWithOpt(context.Background(), "POST", "/tenants", "{}", opt.ForOperation(OperationCreate)); err != nil {
+		t.Fatalf("SendRequestWithOpt returned an error: %s", err)
+	}
+	if opt.ResponseHeader.Get("Location") != "/tenants/42" || opt.ResponseHeader.Get("Stale") != "" {
+		t.Errorf("Unexpected response headers: %v", opt.ResponseHeader)
+	}
+}
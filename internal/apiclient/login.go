@@ -0,0 +1,119 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// SessionLogin authenticates the requests with the session opened by an
+// explicit login request, e.g. a POST of credentials to /login. The session
+// is a token read from the JSON response, sent in the AuthHeaderName header,
+// or a cookie set by the response, sent back on each request.
+type SessionLogin struct {
+	Path string
+	// Method of the login request, POST when empty.
+	Method string
+	Body   string
+	// Dot-separated path of the token in the login response, e.g. "token".
+	TokenPath string
+	// Name of the session cookie, used instead of TokenPath when set.
+	CookieName string
+
+	mu     sync.Mutex
+	token  string
+	cookie *http.Cookie
+	// Incremented on each login, identifying the current session
+	generation uint64
+}
+
+// LogIn sends the login request and keeps the session of its response,
+// replacing the previous one.
+func (client *APIClient) LogIn(ctx context.Context) error {
+	login := client.Login
+	login.mu.Lock()
+	defer login.mu.Unlock()
+	return client.logIn(ctx)
+}
+
+// Logs in again after a 401 answering a request of the session generation,
+// unless another request already renewed it: concurrent requests failing
+// together log in once, a new login possibly closing the previous session.
+func (client *APIClient) renewSession(ctx context.Context, generation uint64) error {
+	login := client.Login
+	login.mu.Lock()
+	defer login.mu.Unlock()
+	if login.generation != generation {
+		return nil
+	}
+	return client.logIn(ctx)
+}
+
+// Sends the login request of LogIn, the login mutex being held.
+func (client *APIClient) logIn(ctx context.Context) error {
+	login := client.Login
+	method := login.Method
+	if method == "" {
+		method = "POST"
+	}
+	header := http.Header{}
+	/* Logging in again has no side effect, whatever the method */
+	body, err := client.sendWithRetries(ctx, true, func() (string, error) {
+		return client.sendRequestAttempt(ctx, method, login.Path, login.Body, &RequestOpt{ResponseHeader: header, skipLogin: true}, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("the login request failed: %w", err)
+	}
+
+	if login.CookieName != "" {
+		for _, cookie := range (&http.Response{Header: header}).Cookies() {
+			if cookie.Name == login.CookieName && cookie.Value != "" {
+				login.cookie = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+				login.generation++
+				return nil
+			}
+		}
+		return fmt.Errorf("the login response sets no %s cookie", login.CookieName)
+	}
+	value, err := GetValueAtPath(body, login.TokenPath)
+	if err != nil {
+		return fmt.Errorf("the login response has no token: %w", err)
+	}
+	/* A numeric session identifier keeps all its digits */
+	token, ok := value.(string)
+	if number, isNumber := value.(json.Number); isNumber {
+		token, ok = number.String(), true
+	}
+	if !ok || token == "" {
+		return fmt.Errorf("the value at %s of the login response is not a token", login.TokenPath)
+	}
+	login.token = token
+	login.generation++
+	return nil
+}
+
+// Sets the session on the request, logging in first when there is none yet,
+// and stores its generation in session when not nil. The mutex is held during
+// the login: the concurrent first requests wait for a single login.
+func (client *APIClient) setSession(ctx context.Context, req *http.Request, session *uint64) error {
+	login := client.Login
+	login.mu.Lock()
+	defer login.mu.Unlock()
+	if login.token == "" && login.cookie == nil {
+		if err := client.logIn(ctx); err != nil {
+			return err
+		}
+	}
+
+	if login.cookie != nil {
+		req.AddCookie(login.cookie)
+	} else {
+		client.setAuthToken(req, login.token)
+	}
+	if session != nil {
+		*session = login.generation
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package apiclient
+
+import (
+	"strings"
+)
+
+// Content type of the RFC 7386 JSON merge patch documents.
+const MergePatchContentType = "application/merge-patch+json"
+
+// Returns the RFC 7386 merge patch turning the JSON document original into
+// modified: fields that differ or are newly set, and nulls for the removed
+// ones. Nested objects are compared field by field, other values (arrays
+// included) are replaced as a whole. An empty object means no change.
+func MergePatch(original string, modified string) (string, error) {
+	originalData, err := decodeJsonNumbers(original)
+	if err != nil {
+		return "", err
+	}
+	modifiedData, err := decodeJsonNumbers(modified)
+	if err != nil {
+		return "", err
+	}
+
+	originalMap, originalIsMap := originalData.(map[string]any)
+	modifiedMap, modifiedIsMap := modifiedData.(map[string]any)
+	if !originalIsMap || !modifiedIsMap {
+		// A non-object patch replaces the whole document.
+		return strings.TrimSpace(modified), nil
+	}
+
+	return JsonEncode(mergePatchObject(originalMap, modifiedMap))
+}
+
+func mergePatchObject(original map[string]any, modified map[string]any) map[string]any {
+	patch := make(map[string]any)
+
+	for key, modifiedValue := range modified {
+		originalValue, ok := original[key]
+		if !ok {
+			patch[key] = modifiedValue
+			continue
+		}
+		originalMap, originalIsMap := originalValue.(map[string]any)
+		modifiedMap, modifiedIsMap := modifiedValue.(map[string]any)
+		if originalIsMap && modifiedIsMap {
+			if nested := mergePatchObject(originalMap, modifiedMap); len(nested) > 0 {
+				patch[key] = nested
+			}
+			continue
+		}
+		if !jsonValuesEqual(originalValue, modifiedValue) {
+			patch[key] = modifiedValue
+		}
+	}
+	for key := range original {
+		if _, ok := modified[key]; !ok {
+			patch[key] = nil
+		}
+	}
+
+	return patch
+}
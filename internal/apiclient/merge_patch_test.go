@@ -0,0 +1,49 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		original string
+		modified string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"b"}`, `{}`},
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"a":"b","b":"c"}`, `{"b":"c"}`},
+		{`{"a":"b","b":"c"}`, `{"a":"b"}`, `{"b":null}`},
+		{`{"a":{"b":"c","d":"e"}}`, `{"a":{"b":"c","d":"f"}}`, `{"a":{"d":"f"}}`},
+		{`{"a":{"b":"c","d":"e"}}`, `{"a":{"b":"c"}}`, `{"a":{"d":null}}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"c"}}`, `{}`},
+		{`{"a":["b","c"]}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":"d"}`, `{"a":"d"}`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+	}
+
+	for _, test := range tests {
+		patch, err := MergePatch(test.original, test.modified)
+		if err != nil {
+			t.Errorf("MergePatch(%s, %s) returned an error: %s", test.original, test.modified, err)
+			continue
+		}
+		var patchData, expectedData any
+		if err := json.Unmarshal([]byte(patch), &patchData); err != nil {
+			t.Errorf("MergePatch(%s, %s) returned an invalid JSON document: %s", test.original, test.modified, patch)
+			continue
+		}
+		if err := json.Unmarshal([]byte(test.expected), &expectedData); err != nil {
+			t.Fatalf("Invalid expected JSON document: %s", test.expected)
+		}
+		if !reflect.DeepEqual(patchData, expectedData) {
+			t.Errorf("MergePatch(%s, %s) = %s; want %s", test.original, test.modified, patch, test.expected)
+		}
+	}
+
+	if _, err := MergePatch("not json", `{}`); err == nil {
+		t.Error("MergePatch should return an error on an invalid original document")
+	}
+}
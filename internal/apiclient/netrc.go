@@ -0,0 +1,94 @@
+package apiclient
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// NetrcEntry holds the credentials of a .netrc machine. An entry with a
+// password and no login holds a token rather than basic auth credentials.
+type NetrcEntry struct {
+	Login    string
+	Password string
+}
+
+// ReadNetrc returns the entry of the host in a .netrc file, like curl and git
+// do, falling back to its default entry. The bool is false when neither
+// exists.
+func ReadNetrc(file string, host string) (NetrcEntry, bool, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return NetrcEntry{}, false, fmt.Errorf("could not read netrc file: %v", err)
+	}
+	entry, found := parseNetrc(string(content), host)
+	return entry, found, nil
+}
+
+// Parses the machine, default, login and password tokens of a .netrc file.
+// The account tokens, the comments and the macdef bodies, ending on an empty
+// line, are skipped.
+func parseNetrc(content string, host string) (NetrcEntry, bool) {
+	var tokens []string
+	inMacdef := false
+	for _, line := range strings.Split(content, "\n") {
+		if inMacdef {
+			inMacdef = strings.TrimSpace(line) != ""
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			if strings.HasPrefix(fields[i], "#") {
+				break
+			}
+			tokens = append(tokens, fields[i])
+			if fields[i] == "macdef" {
+				inMacdef = true
+				break
+			}
+		}
+	}
+
+	var current, machine, fallback *NetrcEntry
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(tokens) {
+				i++
+				if machine == nil && strings.EqualFold(tokens[i], host) {
+					machine = &NetrcEntry{}
+					current = machine
+				}
+			}
+		case "default":
+			current = nil
+			if fallback == nil {
+				fallback = &NetrcEntry{}
+				current = fallback
+			}
+		case "login", "password", "account":
+			key := tokens[i]
+			if i+1 >= len(tokens) {
+				break
+			}
+			i++
+			if current != nil && key == "login" {
+				current.Login = tokens[i]
+			}
+			if current != nil && key == "password" {
+				current.Password = tokens[i]
+			}
+		case "macdef":
+			current = nil
+		}
+	}
+
+	if machine != nil {
+		return *machine, true
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return NetrcEntry{}, false
+}
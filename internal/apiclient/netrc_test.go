@@ -0,0 +1,60 @@
+package apiclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	content := `# credentials of the test APIs
+machine api.example.com login terraform password s3cret
+machine token.example.com
+  password eyJhbGciOiJIUzI1NiJ9.e30.secret
+
+macdef init
+machine macro.example.com login nobody password nothing
+
+machine other.example.com account ops login other password other # trailing comment
+default login anonymous password guest
+`
+	tests := []struct {
+		host     string
+		expected NetrcEntry
+		found    bool
+	}{
+		{"api.example.com", NetrcEntry{Login: "terraform", Password: "s3cret"}, true},
+		{"API.example.com", NetrcEntry{Login: "terraform", Password: "s3cret"}, true},
+		{"token.example.com", NetrcEntry{Password: "eyJhbGciOiJIUzI1NiJ9.e30.secret"}, true},
+		{"other.example.com", NetrcEntry{Login: "other", Password: "other"}, true},
+		{"macro.example.com", NetrcEntry{Login: "anonymous", Password: "guest"}, true},
+		{"unknown.example.com", NetrcEntry{Login: "anonymous", Password: "guest"}, true},
+	}
+
+	for _, test := range tests {
+		entry, found := parseNetrc(content, test.host)
+		if entry != test.expected || found != test.found {
+			t.Errorf("parseNetrc(%s) = %+v, %t; want %+v, %t", test.host, entry, found, test.expected, test.found)
+		}
+	}
+
+	if _, found := parseNetrc("machine api.example.com login terraform password s3cret", "unknown.example.com"); found {
+		t.Error("parseNetrc should not find a host missing without default entry")
+	}
+}
+
+func TestReadNetrc(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".netrc")
+	if err := os.WriteFile(file, []byte("machine 127.0.0.1 login terraform password s3cret\n"), 0o600); err != nil {
+		t.Fatalf("Writing the netrc file failed: %s", err)
+	}
+
+	entry, found, err := ReadNetrc(file, "127.0.0.1")
+	if err != nil || !found || entry.Login != "terraform" || entry.Password != "s3cret" {
+		t.Errorf("ReadNetrc() = %+v, %t, %v; want the terraform credentials", entry, found, err)
+	}
+
+	if _, _, err := ReadNetrc(filepath.Join(t.TempDir(), "missing"), "127.0.0.1"); err == nil {
+		t.Error("ReadNetrc should fail on a missing file")
+	}
+}
@@ -0,0 +1,286 @@
+package apiclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// OauthAuthMethod is the client authentication method of the token requests,
+// as named by the token_endpoint_auth_method of RFC 7591.
+type OauthAuthMethod string
+
+const (
+	// The client id and secret in the Authorization header.
+	OauthClientSecretBasic OauthAuthMethod = "client_secret_basic"
+	// The client id and secret in the form of the request.
+	OauthClientSecretPost OauthAuthMethod = "client_secret_post"
+	// The client id in the form, the client being authenticated by its TLS
+	// certificate, which the issued tokens are bound to (RFC 8705).
+	OauthTlsClientAuth OauthAuthMethod = "tls_client_auth"
+)
+
+// OauthAuthMethods lists the supported client authentication methods.
+var OauthAuthMethods = []OauthAuthMethod{OauthClientSecretBasic, OauthClientSecretPost, OauthTlsClientAuth}
+
+// Returns the oauth2 authentication style of the method, detected on the
+// first token request when the method is empty.
+func (m OauthAuthMethod) authStyle() oauth2.AuthStyle {
+	switch m {
+	case OauthClientSecretBasic:
+		return oauth2.AuthStyleInHeader
+	case OauthClientSecretPost, OauthTlsClientAuth:
+		return oauth2.AuthStyleInParams
+	default:
+		return oauth2.AuthStyleAutoDetect
+	}
+}
+
+// Returns an error when the method is set but not supported.
+func (m OauthAuthMethod) validate() error {
+	if m == "" {
+		return nil
+	}
+	for _, method := range OauthAuthMethods {
+		if m == method {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported OAuth client authentication method: '%s'", m)
+}
+
+// Returns whether the HTTP client presents a TLS client certificate. A
+// transport other than *http.Transport is assumed to.
+func hasClientCertificate(httpClient *http.Client) bool {
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return true
+	}
+	tlsConfig := transport.TLSClientConfig
+	return tlsConfig != nil && (len(tlsConfig.Certificates) > 0 || tlsConfig.GetClientCertificate != nil)
+}
+
+// RefreshTokenSource mints access tokens with the OAuth2 refresh token grant.
+// The refresh token rotated by the identity provider is kept for the next
+// refresh and, when a token file is configured, persisted between runs.
+type RefreshTokenSource struct {
+	mu        sync.Mutex
+	config    *oauth2.Config
+	token     *oauth2.Token
+	tokenFile string
+	// Extra form parameters of the token requests, e.g. the audience
+	endpointParams url.Values
+}
+
+// NewRefreshTokenSource returns a token source seeded with the refresh token.
+// When the token file holds a previously persisted token, its (possibly
+// rotated) refresh token takes precedence over the given one.
+func NewRefreshTokenSource(config *oauth2.Config, refreshToken string, tokenFile string) (*RefreshTokenSource, error) {
+	token := &oauth2.Token{RefreshToken: refreshToken}
+
+	if tokenFile != "" {
+		content, err := os.ReadFile(tokenFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("could not read the OAuth token file: %v", err)
+		}
+		if err == nil {
+			persisted := &oauth2.Token{}
+			if err := json.Unmarshal(content, persisted); err != nil {
+				return nil, fmt.Errorf("the OAuth token file can't be JSON decoded: %v", err)
+			}
+			if persisted.RefreshToken != "" {
+				token = persisted
+			}
+		}
+	}
+
+	return &RefreshTokenSource{
+		config:    config,
+		token:     token,
+		tokenFile: tokenFile,
+	}, nil
+}
+
+// Token returns a valid access token, refreshing it with the given HTTP
+// client when expired. Cancelling the context aborts the refresh.
+func (s *RefreshTokenSource) Token(ctx context.Context, httpClient *http.Client) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+
+	if len(s.endpointParams) > 0 {
+		httpClient = withEndpointParams(httpClient, s.endpointParams)
+	}
+	tokenCtx := context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	token, err := s.config.TokenSource(tokenCtx, s.token).Token()
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+
+	if s.tokenFile != "" {
+		content, err := json.Marshal(token)
+		if err != nil {
+			return nil, fmt.Errorf("the OAuth token can't be JSON encoded: %v", err)
+		}
+		if err := os.WriteFile(s.tokenFile, content, 0600); err != nil {
+			return nil, fmt.Errorf("could not write the OAuth token file: %v", err)
+		}
+	}
+
+	return token, nil
+}
+
+// Invalidate discards the access token, e.g. revoked before its expiry, so
+// that the next Token call refreshes it. The refresh token is kept.
+func (s *RefreshTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = &oauth2.Token{RefreshToken: s.token.RefreshToken}
+}
+
+// Returns the HTTP client of the token requests of the token endpoint with
+// its own root CA and, when certFile and keyFile are set, client certificate.
+func newOauthHttpClient(rootCaFile string, certFile string, keyFile string) (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+
+	if rootCaFile != "" {
+		rootCA, err := os.ReadFile(rootCaFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read OAuth root CA file: %v", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(rootCA) {
+			return nil, errors.New("failed to append OAuth root CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load OAuth client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+	}, nil
+}
+
+// Returns the HTTP client of the token requests.
+func (client *APIClient) oauthHttpClient() *http.Client {
+	httpClient := client.HttpClient
+	if client.OauthHttpClient != nil {
+		httpClient = client.OauthHttpClient
+	}
+	if client.AcceptLanguage != "" {
+		return withHeader(httpClient, "Accept-Language", client.AcceptLanguage)
+	}
+	return httpClient
+}
+
+// Returns the OAuth endpoint parameters with the audience, when set.
+func oauthEndpointParams(params url.Values, audience string) url.Values {
+	merged := url.Values{}
+	for key, values := range params {
+		merged[key] = append([]string(nil), values...)
+	}
+	if audience != "" {
+		merged.Set("audience", audience)
+	}
+	return merged
+}
+
+// Returns a copy of the HTTP client adding the parameters to the form of its
+// requests. The refresh token grant of oauth2, unlike its client credentials
+// grant, has no endpoint parameters.
+func withEndpointParams(httpClient *http.Client, params url.Values) *http.Client {
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	paramsClient := *httpClient
+	paramsClient.Transport = &endpointParamsTransport{base: transport, params: params}
+	return &paramsClient
+}
+
+// endpointParamsTransport adds its parameters to the form body of the
+// requests. The parameters already set, e.g. grant_type, are kept.
+type endpointParamsTransport struct {
+	base   http.RoundTripper
+	params url.Values
+}
+
+func (t *endpointParamsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("the token request form can't be parsed: %v", err)
+	}
+	for key, values := range t.params {
+		if !form.Has(key) {
+			form[key] = values
+		}
+	}
+
+	encoded := form.Encode()
+	paramsReq := req.Clone(req.Context())
+	paramsReq.Body = io.NopCloser(strings.NewReader(encoded))
+	paramsReq.ContentLength = int64(len(encoded))
+	paramsReq.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(encoded)), nil
+	}
+	return t.base.RoundTrip(paramsReq)
+}
+
+// Returns a copy of the HTTP client setting the header on its requests, e.g.
+// the token requests sent by oauth2, which don't take headers.
+func withHeader(httpClient *http.Client, name string, value string) *http.Client {
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	headerClient := *httpClient
+	headerClient.Transport = &headerTransport{base: transport, name: name, value: value}
+	return &headerClient
+}
+
+// headerTransport sets its header on the requests.
+type headerTransport struct {
+	base  http.RoundTripper
+	name  string
+	value string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headerReq := req.Clone(req.Context())
+	headerReq.Header.Set(t.name, t.value)
+	return t.base.RoundTrip(headerReq)
+}
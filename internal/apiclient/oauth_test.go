@@ -0,0 +1,556 @@
+package apiclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAPIClient_oauthRefreshToken(t *testing.T) {
+	var mu sync.Mutex
+	currentRefreshToken := "refresh-0"
+	rotations := 0
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != currentRefreshToken {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		/* Rotate the refresh token on each use */
+		rotations++
+		currentRefreshToken = fmt.Sprintf("refresh-%d", rotations)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"access-%d","token_type":"Bearer","refresh_token":"%s","expires_in":1}`, rotations, currentRefreshToken)
+	}))
+	defer tokenServer.Close()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer apiServer.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token.json")
+	opt := &ApiClientOpt{
+		Uri:               apiServer.URL,
+		Timeout:           2,
+		RateLimit:         10,
+		OauthClientID:     "client",
+		OauthClientSecret: "secret",
+		OauthTokenURL:     tokenServer.URL,
+		OauthRefreshToken: "refresh-0",
+		OauthTokenFile:    tokenFile,
+	}
+	client, err := NewAPIClient(opt)
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	/* The tokens expire within oauth2's expiry delta, so each request refreshes */
+	for i := 1; i <= 2; i++ {
+		res, err := client.SendRequest("GET", "/ok", "")
+		if err != nil {
+			t.Fatalf("api_client_test.go: %s", err)
+		}
+		if res != fmt.Sprintf("Bearer access-%d", i) {
+			t.Errorf("Got back '%s' but expected 'Bearer access-%d'", res, i)
+		}
+	}
+
+	content, err := os.ReadFile(tokenFile)
+	if err != nil {
+		t.Fatalf("The token file was not written: %s", err)
+	}
+	persisted := &oauth2.Token{}
+	if err := json.Unmarshal(content, persisted); err != nil {
+		t.Fatalf("The token file can't be decoded: %s", err)
+	}
+	if persisted.RefreshToken != "refresh-2" {
+		t.Errorf("The persisted refresh token is %s; want refresh-2", persisted.RefreshToken)
+	}
+
+	/* A new run starts from the persisted rotated token, not the configured one */
+	client, err = NewAPIClient(opt)
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	res, err := client.SendRequest("GET", "/ok", "")
+	if err != nil {
+		t.Fatalf("The persisted refresh token was not used: %s", err)
+	}
+	if res != "Bearer access-3" {
+		t.Errorf("Got back '%s' but expected 'Bearer access-3'", res)
+	}
+}
+
+func TestAPIClient_oauthEndpointParams(t *testing.T) {
+	var mu sync.Mutex
+	var forms []url.Values
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		forms = append(forms, r.PostForm)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","token_type":"Bearer","refresh_token":"refresh","expires_in":3600}`)
+	}))
+	defer tokenServer.Close()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer apiServer.Close()
+
+	for _, grant := range []string{"refresh_token", "client_credentials"} {
+		opt := &ApiClientOpt{
+			Uri:                 apiServer.URL,
+			Timeout:             2,
+			RateLimit:           10,
+			OauthClientID:       "client",
+			OauthClientSecret:   "secret",
+			OauthTokenURL:       tokenServer.URL,
+			OauthEndpointParams: url.Values{"resource": {"https://api.example.com"}},
+			OauthAudience:       "https://api.example.com/",
+		}
+		if grant == "refresh_token" {
+			opt.OauthRefreshToken = "refresh-0"
+		}
+		client, err := NewAPIClient(opt)
+		if err != nil {
+			t.Fatalf("NewAPIClient returned an error: %s", err)
+		}
+		res, err := client.SendRequest("GET", "/ok", "")
+		if err != nil {
+			t.Fatalf("api_client_test.go: %s", err)
+		}
+		if res != "Bearer access" {
+			t.Errorf("Got back '%s' with the %s grant but expected 'Bearer access'", res, grant)
+		}
+
+		mu.Lock()
+		form := forms[len(forms)-1]
+		mu.Unlock()
+		if form.Get("audience") != "https://api.example.com/" {
+			t.Errorf("The %s token request has the audience '%s'; want 'https://api.example.com/'", grant, form.Get("audience"))
+		}
+		if form.Get("resource") != "https://api.example.com" {
+			t.Errorf("The %s token request has the resource '%s'; want 'https://api.example.com'", grant, form.Get("resource"))
+		}
+		if form.Get("grant_type") != grant {
+			t.Errorf("The %s token request has the grant type '%s'", grant, form.Get("grant_type"))
+		}
+		if grant == "refresh_token" && form.Get("refresh_token") != "refresh-0" {
+			t.Errorf("The refresh token request lost its refresh token: %v", form)
+		}
+	}
+
+	params := url.Values{"audience": {"a"}}
+	if oauthEndpointParams(params, "b").Get("audience") != "b" || params.Get("audience") != "a" {
+		t.Error("The audience attribute should take precedence over the endpoint params without changing them")
+	}
+}
+
+func TestAPIClient_acceptLanguage(t *testing.T) {
+	var mu sync.Mutex
+	var tokenLanguages []string
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		tokenLanguages = append(tokenLanguages, r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","token_type":"Bearer","refresh_token":"refresh","expires_in":3600}`)
+	}))
+	defer tokenServer.Close()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Accept-Language"))
+	}))
+	defer apiServer.Close()
+
+	for _, grant := range []string{"refresh_token", "client_credentials"} {
+		opt := &ApiClientOpt{
+			Uri:               apiServer.URL,
+			Timeout:           2,
+			RateLimit:         100,
+			AcceptLanguage:    "fr-FR",
+			OauthClientID:     "client",
+			OauthClientSecret: "secret",
+			OauthTokenURL:     tokenServer.URL,
+		}
+		if grant == "refresh_token" {
+			opt.OauthRefreshToken = "refresh-0"
+		}
+		client, err := NewAPIClient(opt)
+		if err != nil {
+			t.Fatalf("NewAPIClient returned an error: %s", err)
+		}
+		res, err := client.SendRequest("GET", "/ok", "")
+		if err != nil {
+			t.Fatalf("api_client_test.go: %s", err)
+		}
+		if res != "fr-FR" {
+			t.Errorf("The API request with the %s grant has the Accept-Language '%s'; want 'fr-FR'", grant, res)
+		}
+
+		mu.Lock()
+		tokenLanguage := tokenLanguages[len(tokenLanguages)-1]
+		mu.Unlock()
+		if tokenLanguage != "fr-FR" {
+			t.Errorf("The %s token request has the Accept-Language '%s'; want 'fr-FR'", grant, tokenLanguage)
+		}
+	}
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: apiServer.URL, Timeout: 2, RateLimit: 100, AcceptLanguage: "fr-FR"})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	res, err := client.SendRequestWithOpt(context.Background(), "GET", "/ok", "", &RequestOpt{Headers: map[string]string{"Accept-Language": "de"}})
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if res != "de" {
+		t.Errorf("The request headers should override the Accept-Language; got '%s'", res)
+	}
+}
+
+// Writes a self-signed certificate for 127.0.0.1 and its key as PEM files.
+func writeTestCertificate(t *testing.T, name string, usage x509.ExtKeyUsage) (certFile string, keyFile string, cert tls.Certificate) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Key generation failed: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		ExtKeyUsage:           []x509.ExtKeyUsage{usage},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("Certificate creation failed: %s", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Key encoding failed: %s", err)
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	certFile = filepath.Join(t.TempDir(), name+".crt")
+	keyFile = filepath.Join(t.TempDir(), name+".key")
+	if err := os.WriteFile(certFile, certPem, 0600); err != nil {
+		t.Fatalf("Writing the certificate failed: %s", err)
+	}
+	if err := os.WriteFile(keyFile, keyPem, 0600); err != nil {
+		t.Fatalf("Writing the key failed: %s", err)
+	}
+	cert, err = tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		t.Fatalf("Loading the key pair failed: %s", err)
+	}
+	return certFile, keyFile, cert
+}
+
+func TestAPIClient_oauthTls(t *testing.T) {
+	oauthCaFile, _, oauthServerCert := writeTestCertificate(t, "oauth-server", x509.ExtKeyUsageServerAuth)
+	oauthClientCertFile, oauthClientKeyFile, _ := writeTestCertificate(t, "oauth-client", x509.ExtKeyUsageClientAuth)
+
+	/* The token endpoint has its own CA and requires a client certificate */
+	tokenServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"split-pki","token_type":"Bearer","expires_in":3600}`)
+	}))
+	tokenServer.TLS = &tls.Config{
+		Certificates: []tls.Certificate{oauthServerCert},
+		ClientAuth:   tls.RequireAnyClientCert,
+	}
+	tokenServer.StartTLS()
+	defer tokenServer.Close()
+
+	apiServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer apiServer.Close()
+	apiCa := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: apiServer.Certificate().Raw}))
+
+	opt := &ApiClientOpt{
+		Uri:               apiServer.URL,
+		Timeout:           2,
+		RateLimit:         10,
+		RootCaString:      apiCa,
+		OauthClientID:     "client",
+		OauthClientSecret: "secret",
+		OauthTokenURL:     tokenServer.URL,
+		OauthRootCaFile:   oauthCaFile,
+		OauthCertFile:     oauthClientCertFile,
+		OauthKeyFile:      oauthClientKeyFile,
+	}
+	client, err := NewAPIClient(opt)
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	res, err := client.SendRequest("GET", "/ok", "")
+	if err != nil {
+		t.Fatalf("api_client_test.go: %s", err)
+	}
+	if res != "Bearer split-pki" {
+		t.Errorf("Got back '%s' but expected 'Bearer split-pki'", res)
+	}
+
+	/* Without its own CA, the token endpoint is checked against the API's */
+	opt.OauthRootCaFile, opt.OauthCertFile, opt.OauthKeyFile = "", "", ""
+	client, err = NewAPIClient(opt)
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	if _, err := client.SendRequest("GET", "/ok", ""); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("The token request should fail on the certificate of the token endpoint, got: %v", err)
+	}
+
+	opt.OauthRootCaFile = filepath.Join(t.TempDir(), "missing.crt")
+	if _, err := NewAPIClient(opt); err == nil {
+		t.Error("NewAPIClient should fail on a missing OAuth root CA file")
+	}
+}
+
+func TestAPIClient_oauthTlsClientAuth(t *testing.T) {
+	serverCaFile, _, serverCert := writeTestCertificate(t, "mtls-server", x509.ExtKeyUsageServerAuth)
+	apiCertFile, apiKeyFile, _ := writeTestCertificate(t, "api-client", x509.ExtKeyUsageClientAuth)
+	oauthCertFile, oauthKeyFile, _ := writeTestCertificate(t, "oauth-client", x509.ExtKeyUsageClientAuth)
+
+	/* An mTLS token endpoint binding the tokens to the client certificate (RFC 8705) */
+	svr := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer := r.TLS.PeerCertificates[0].Subject.CommonName
+		if r.URL.Path != "/token" {
+			if r.Header.Get("Authorization") != "Bearer "+peer {
+				http.Error(w, "token not bound to the client certificate", http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, _, basic := r.BasicAuth()
+		if r.PostForm.Get("client_id") != "client" || r.PostForm.Has("client_secret") || basic {
+			http.Error(w, fmt.Sprintf("unexpected client authentication: %v", r.PostForm), http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600}`, peer)
+	}))
+	svr.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAnyClientCert,
+	}
+	svr.StartTLS()
+	defer svr.Close()
+
+	opt := &ApiClientOpt{
+		Uri:             svr.URL,
+		Timeout:         2,
+		RateLimit:       100,
+		RootCaFile:      serverCaFile,
+		CertFile:        apiCertFile,
+		KeyFile:         apiKeyFile,
+		OauthClientID:   "client",
+		OauthTokenURL:   svr.URL + "/token",
+		OauthAuthMethod: OauthTlsClientAuth,
+	}
+	send := func() string {
+		t.Helper()
+		client, err := NewAPIClient(opt)
+		if err != nil {
+			t.Fatalf("NewAPIClient returned an error: %s", err)
+		}
+		res, err := client.SendRequest("GET", "/ok", "")
+		if err != nil {
+			t.Fatalf("The request failed: %s", err)
+		}
+		return res
+	}
+
+	/* The token request presents the API client certificate without secret */
+	if res := send(); res != "Bearer api-client" {
+		t.Errorf("Got back '%s' but expected the token bound to the API certificate", res)
+	}
+
+	/* The refresh token grant authenticates the same way */
+	opt.OauthRefreshToken = "refresh"
+	if res := send(); res != "Bearer api-client" {
+		t.Errorf("Got back '%s' but expected the refreshed token bound to the API certificate", res)
+	}
+	opt.OauthRefreshToken = ""
+
+	/* With its own certificate, the token is bound to it and rejected by the API */
+	opt.OauthRootCaFile, opt.OauthCertFile, opt.OauthKeyFile = serverCaFile, oauthCertFile, oauthKeyFile
+	client, err := NewAPIClient(opt)
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	if _, err := client.SendRequest("GET", "/ok", ""); StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("A token bound to another certificate should be rejected, got: %v", err)
+	}
+	if method := client.EffectiveConfig()["oauth_auth_method"]; method != "tls_client_auth" {
+		t.Errorf("Unexpected effective oauth_auth_method: %v", method)
+	}
+
+	opt.OauthClientSecret = "secret"
+	if _, err := NewAPIClient(opt); err == nil {
+		t.Error("NewAPIClient should reject a client secret with tls_client_auth")
+	}
+	opt.OauthClientSecret = ""
+	opt.OauthCertFile, opt.OauthKeyFile = "", ""
+	if _, err := NewAPIClient(opt); err == nil {
+		t.Error("NewAPIClient should reject tls_client_auth without certificate of the token requests")
+	}
+	opt.OauthAuthMethod = "private_key_jwt"
+	if _, err := NewAPIClient(opt); err == nil {
+		t.Error("NewAPIClient should reject an unsupported OAuth method")
+	}
+}
+
+func TestAPIClient_oauthClientSecretMethods(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/token" {
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		method := "none"
+		if id, secret, ok := r.BasicAuth(); ok && id == "client" && secret == "secret" {
+			method = "basic"
+		} else if r.PostForm.Get("client_id") == "client" && r.PostForm.Get("client_secret") == "secret" {
+			method = "post"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600}`, method)
+	}))
+	defer svr.Close()
+
+	for method, expected := range map[OauthAuthMethod]string{
+		"":                     "Bearer basic",
+		OauthClientSecretBasic: "Bearer basic",
+		OauthClientSecretPost:  "Bearer post",
+	} {
+		client, err := NewAPIClient(&ApiClientOpt{
+			Uri:               svr.URL,
+			Timeout:           2,
+			RateLimit:         100,
+			OauthClientID:     "client",
+			OauthClientSecret: "secret",
+			OauthTokenURL:     svr.URL + "/token",
+			OauthAuthMethod:   method,
+		})
+		if err != nil {
+			t.Fatalf("NewAPIClient returned an error: %s", err)
+		}
+		if res, err := client.SendRequest("GET", "/ok", ""); err != nil || res != expected {
+			t.Errorf("With the method '%s', got back '%s' (%v); want '%s'", method, res, err, expected)
+		}
+	}
+}
+
+func TestAPIClient_reauthOn401(t *testing.T) {
+	var mu sync.Mutex
+	tokens := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		tokens++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"access-%d","token_type":"Bearer","refresh_token":"refresh-%d","expires_in":3600}`, tokens, tokens)
+	}))
+	defer tokenServer.Close()
+
+	/* The first access token is revoked before its expiry, as is the first JWT sent */
+	requests := 0
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		authorization := r.Header.Get("Authorization")
+		if authorization == "Bearer access-1" || (strings.HasPrefix(authorization, "Bearer ey") && requests == 1) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer apiServer.Close()
+
+	tests := []struct {
+		name string
+		opt  ApiClientOpt
+	}{
+		{"oauth", ApiClientOpt{OauthClientID: "client", OauthTokenURL: tokenServer.URL, OauthRefreshToken: "refresh-0"}},
+		{"jwt", ApiClientOpt{Jwt: &JwtHashedToken{Secret: []byte("NotTheMostSecuredSecret"), Claims: map[string]any{"a": "b"}}}},
+	}
+	for _, test := range tests {
+		for _, reauth := range []bool{false, true} {
+			mu.Lock()
+			tokens, requests = 0, 0
+			mu.Unlock()
+			opt := test.opt
+			opt.Uri, opt.Timeout, opt.RateLimit, opt.ReauthOn401 = apiServer.URL, 2, 100, reauth
+			client, err := NewAPIClient(&opt)
+			if err != nil {
+				t.Fatalf("NewAPIClient returned an error: %s", err)
+			}
+			_, err = client.SendRequest("GET", "/ok", "")
+			if reauth && err != nil {
+				t.Errorf("%s: the request should be sent again with new credentials, got: %s", test.name, err)
+			}
+			if !reauth && StatusCode(err) != http.StatusUnauthorized {
+				t.Errorf("%s: the request should fail with a 401 without reauth_on_401, got: %v", test.name, err)
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if tokens != 0 || requests != 2 {
+		t.Errorf("Unexpected JWT exchange: %d token and %d API requests; want 0 and 2", tokens, requests)
+	}
+}
@@ -0,0 +1,46 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Attributes tried in order for the id of an object when no id attribute is
+// configured.
+var DefaultIdAttributes = []string{"id", "uuid", "_id", "name"}
+
+// Returns the id of the object of the API response (see
+// JsonDecodeApiResponse) and the attribute holding it: the dot-separated
+// idAttribute when set, or else the first of the DefaultIdAttributes present.
+// Numbers are returned as written; other non-string values are an error.
+func ObjectId(jsonData string, idAttribute string) (string, string, error) {
+	mapData, err := JsonDecodeApiResponse(jsonData)
+	if err != nil {
+		return "", "", err
+	}
+
+	attributes := DefaultIdAttributes
+	if idAttribute != "" {
+		attributes = []string{idAttribute}
+	}
+	for _, attribute := range attributes {
+		value, ok := lookupPath(mapData, attribute)
+		if !ok || value == nil {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			return v, attribute, nil
+		case json.Number:
+			return v.String(), attribute, nil
+		default:
+			return "", "", fmt.Errorf("the value of the key %s is not a string or a number: %v", attribute, value)
+		}
+	}
+
+	if idAttribute != "" {
+		return "", "", fmt.Errorf("key %s not found", idAttribute)
+	}
+	return "", "", fmt.Errorf("none of the keys %s found", strings.Join(DefaultIdAttributes, ", "))
+}
@@ -0,0 +1,43 @@
+package apiclient
+
+import (
+	"testing"
+)
+
+func TestObjectId(t *testing.T) {
+	tests := []struct {
+		jsonData    string
+		idAttribute string
+		id          string
+		attribute   string
+		fails       bool
+	}{
+		{`{"id":"1","uuid":"u-1","name":"a"}`, "", "1", "id", false},
+		{`{"uuid":"u-1","_id":"m-1","name":"a"}`, "", "u-1", "uuid", false},
+		{`[{"_id":"m-1","name":"a"}]`, "", "m-1", "_id", false},
+		{`{"id":null,"name":"a"}`, "", "a", "name", false},
+		{`{"id":12345678901}`, "", "12345678901", "id", false},
+		{`{"id":"1","data":{"key":"k-1"}}`, "data.key", "k-1", "data.key", false},
+		{`{"identifier":"a"}`, "", "", "", true},
+		{`{"id":"1"}`, "key", "", "", true},
+		{`{"id":{"value":"1"}}`, "", "", "", true},
+		{`not json`, "", "", "", true},
+	}
+
+	for _, test := range tests {
+		id, attribute, err := ObjectId(test.jsonData, test.idAttribute)
+		if test.fails {
+			if err == nil {
+				t.Errorf("ObjectId(%s, %s) should return an error, got the id %s", test.jsonData, test.idAttribute, id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ObjectId(%s, %s) returned an error: %s", test.jsonData, test.idAttribute, err)
+			continue
+		}
+		if id != test.id || attribute != test.attribute {
+			t.Errorf("ObjectId(%s, %s) = %s, %s; want %s, %s", test.jsonData, test.idAttribute, id, attribute, test.id, test.attribute)
+		}
+	}
+}
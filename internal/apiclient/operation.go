@@ -0,0 +1,40 @@
+package apiclient
+
+import "slices"
+
+// Operation is the resource operation a request is sent for, selecting the
+// status codes expected in its response.
+type Operation string
+
+const (
+	OperationCreate  Operation = "create"
+	OperationRead    Operation = "read"
+	OperationUpdate  Operation = "update"
+	OperationDestroy Operation = "destroy"
+)
+
+// ForOperation returns a copy of the request settings, nil meaning none, for
+// a request of the operation.
+func (opt *RequestOpt) ForOperation(operation Operation) *RequestOpt {
+	operationOpt := RequestOpt{}
+	if opt != nil {
+		operationOpt = *opt
+	}
+	operationOpt.Operation = operation
+	return &operationOpt
+}
+
+// Returns whether the status code is a success of the operation: one of its
+// ExpectedStatus codes when set, any 2xx code otherwise.
+func (client *APIClient) isExpectedStatus(operation Operation, statusCode int) bool {
+	if expected := client.ExpectedStatus[operation]; len(expected) > 0 {
+		return slices.Contains(expected, statusCode)
+	}
+	return statusCode >= 200 && statusCode < 300
+}
+
+// Returns whether the status code is a redirect, only returned to the
+// caller with NoFollowRedirects.
+func IsRedirect(statusCode int) bool {
+	return statusCode >= 300 && statusCode < 400
+}
@@ -0,0 +1,79 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAPIClient_expectedStatus(t *testing.T) {
+	/* Answers with the status code of the last path element, e.g. /status/201 */
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, err := strconv.Atoi(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(code)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:       svr.URL,
+		Timeout:   2,
+		RateLimit: 100,
+		ExpectedStatus: map[Operation][]int{
+			OperationCreate:  {http.StatusCreated},
+			OperationDestroy: {http.StatusNoContent, http.StatusNotFound},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	tests := []struct {
+		operation Operation
+		status    int
+		success   bool
+	}{
+		{OperationCreate, http.StatusCreated, true},
+		{OperationCreate, http.StatusOK, false},
+		{OperationDestroy, http.StatusNoContent, true},
+		{OperationDestroy, http.StatusNotFound, true},
+		{OperationDestroy, http.StatusOK, false},
+		/* Operations without expected status accept any 2xx code */
+		{OperationRead, http.StatusOK, true},
+		{OperationRead, http.StatusAccepted, true},
+		{OperationRead, http.StatusNotFound, false},
+		{"", http.StatusCreated, true},
+	}
+	for _, test := range tests {
+		path := "/status/" + strconv.Itoa(test.status)
+		_, err := client.SendRequestWithOpt(context.Background(), "POST", path, "{}", &RequestOpt{Operation: test.operation})
+		if (err == nil) != test.success {
+			t.Errorf("A %d response to a %q request returned the error %v; want success %t", test.status, test.operation, err, test.success)
+		}
+		if err != nil && StatusCode(err) != test.status {
+			t.Errorf("The %q request error has the status %d; want %d", test.operation, StatusCode(err), test.status)
+		}
+	}
+}
+
+func TestRequestOpt_ForOperation(t *testing.T) {
+	var nilOpt *RequestOpt
+	if opt := nilOpt.ForOperation(OperationRead); opt.Operation != OperationRead {
+		t.Errorf("ForOperation on nil settings = %+v; want the read operation", opt)
+	}
+
+	opt := &RequestOpt{SuppressHeaders: []string{"X-Tenant"}, Operation: OperationRead}
+	createOpt := opt.ForOperation(OperationCreate)
+	if createOpt.Operation != OperationCreate || len(createOpt.SuppressHeaders) != 1 {
+		t.Errorf("ForOperation = %+v; want the create operation with the suppressed headers", createOpt)
+	}
+	if opt.Operation != OperationRead {
+		t.Error("ForOperation should not change the original settings")
+	}
+}
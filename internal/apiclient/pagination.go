@@ -0,0 +1,161 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ListOpt describes the pages of a list endpoint.
+type ListOpt struct {
+	// Dot-separated path of the items array in a page. When empty, the page
+	// itself is the items array.
+	ItemsKey string
+	// Dot-separated path of the link to the next page, an absolute URL under
+	// the client URI or a path. When empty or missing, the page is the last one.
+	NextKey string
+	// Dot-separated path of the total number of items reported by the API.
+	TotalKey string
+	// Maximum number of pages read, DefaultMaxPages when 0. It stops the
+	// pagination when the next page link never clears, e.g. on a server bug.
+	MaxPages int
+	// Method of the page requests, GET when empty, e.g. POST for the search
+	// endpoints taking a JSON query.
+	Method string
+	// JSON body sent with each page request, e.g. the search query.
+	Body string
+}
+
+// Default maximum number of pages read by ListObjects.
+const DefaultMaxPages = 1000
+
+// ListResult holds the items collected over all the pages of a list endpoint.
+type ListResult struct {
+	Items []any
+	// Number of items over all the pages, kept or not (see WalkPages).
+	Count int64
+	// Total reported by the API on the first page, -1 when not reported.
+	Total int64
+	Pages int
+	// Set when MaxPages was reached while a next page link remained.
+	Truncated bool
+}
+
+// Returns whether the API reported a total differing from the number of
+// collected items, e.g. when the pagination silently truncated the list.
+func (r *ListResult) TotalMismatch() bool {
+	return r.Total >= 0 && r.Total != r.Count
+}
+
+// ListObjects reads the list endpoint at path, following the next page links,
+// and returns the collected items. Past the maximum number of pages, the items
+// collected so far are returned with Truncated set.
+func (client *APIClient) ListObjects(ctx context.Context, path string, opt *ListOpt) (*ListResult, error) {
+	var items []any
+	result, err := client.WalkPages(ctx, path, opt, func(page []any) error {
+		items = append(items, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Items = items
+	return result, nil
+}
+
+// WalkPages reads the list endpoint at path like ListObjects, passing the
+// items of each page to handle instead of collecting them, e.g. to stream a
+// large list to a file. An error of handle stops the pagination.
+func (client *APIClient) WalkPages(ctx context.Context, path string, opt *ListOpt, handle func(items []any) error) (*ListResult, error) {
+	result := &ListResult{Total: -1}
+	maxPages := opt.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	method := opt.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	for path != "" {
+		if result.Pages >= maxPages {
+			result.Truncated = true
+			break
+		}
+		responseData, err := client.SendJsonRequestWithOpt(ctx, method, path, opt.Body, nil)
+		if err != nil {
+			return nil, err
+		}
+		page, err := decodeJsonNumbers(responseData)
+		if err != nil {
+			return nil, err
+		}
+		result.Pages++
+
+		items, ok := lookupPath(page, opt.ItemsKey)
+		if !ok {
+			return nil, fmt.Errorf("page %d: path %s not found", result.Pages, opt.ItemsKey)
+		}
+		array, ok := items.([]any)
+		if !ok {
+			return nil, fmt.Errorf("page %d: the items are not an array", result.Pages)
+		}
+		if err := handle(array); err != nil {
+			return nil, err
+		}
+		result.Count += int64(len(array))
+
+		if result.Pages == 1 && opt.TotalKey != "" {
+			total, ok := lookupPath(page, opt.TotalKey)
+			if !ok {
+				return nil, fmt.Errorf("path %s not found", opt.TotalKey)
+			}
+			if result.Total, err = parseTotal(total); err != nil {
+				return nil, err
+			}
+		}
+
+		path, err = client.nextPagePath(page, opt.NextKey)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", result.Pages, err)
+		}
+	}
+
+	return result, nil
+}
+
+// Returns the path of the page following page, empty on the last page.
+func (client *APIClient) nextPagePath(page any, nextKey string) (string, error) {
+	if nextKey == "" {
+		return "", nil
+	}
+	next, ok := lookupPath(page, nextKey)
+	if !ok || next == nil {
+		return "", nil
+	}
+	link, ok := next.(string)
+	if !ok {
+		return "", fmt.Errorf("the next page link is not a string: %v", next)
+	}
+	if strings.HasPrefix(link, client.Uri) {
+		return strings.TrimPrefix(link, client.Uri), nil
+	}
+	if strings.Contains(link, "://") {
+		return "", fmt.Errorf("the next page link %s is not under the API URI %s", link, client.Uri)
+	}
+	return link, nil
+}
+
+// Converts a total reported as a JSON number or a numeric string.
+func parseTotal(total any) (int64, error) {
+	switch v := total.(type) {
+	case json.Number:
+		return v.Int64()
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("the total is not a number: %v", total)
+	}
+}
@@ -0,0 +1,112 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAPIClient_listObjects(t *testing.T) {
+	var svrURL string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			fmt.Fprintf(w, `{"total":5,"items":[{"id":"1"},{"id":"2"}],"links":{"next":"%s/api/objects?page=2"}}`, svrURL)
+		case "2":
+			fmt.Fprint(w, `{"total":5,"items":[{"id":"3"},{"id":"4"}],"links":{"next":"/api/objects?page=3"}}`)
+		case "3":
+			fmt.Fprint(w, `{"total":5,"items":[{"id":"5"}],"links":{"next":null}}`)
+		}
+	}))
+	defer svr.Close()
+	svrURL = svr.URL
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	result, err := client.ListObjects(context.Background(), "/api/objects", &ListOpt{ItemsKey: "items", NextKey: "links.next", TotalKey: "total"})
+	if err != nil {
+		t.Fatalf("ListObjects returned an error: %s", err)
+	}
+	if len(result.Items) != 5 || result.Pages != 3 || result.Total != 5 {
+		t.Errorf("ListObjects collected %d item(s) over %d page(s) with a total of %d; want 5 over 3 with 5", len(result.Items), result.Pages, result.Total)
+	}
+	if result.TotalMismatch() {
+		t.Error("TotalMismatch should be false when all the items are collected")
+	}
+
+	// Without the next link, only the first page is read: the truncation is detected.
+	result, err = client.ListObjects(context.Background(), "/api/objects", &ListOpt{ItemsKey: "items", TotalKey: "total"})
+	if err != nil {
+		t.Fatalf("ListObjects returned an error: %s", err)
+	}
+	if len(result.Items) != 2 || !result.TotalMismatch() {
+		t.Errorf("ListObjects collected %d item(s), TotalMismatch %t; want 2 and true", len(result.Items), result.TotalMismatch())
+	}
+
+	result, err = client.ListObjects(context.Background(), "/api/objects", &ListOpt{ItemsKey: "items"})
+	if err != nil {
+		t.Fatalf("ListObjects returned an error: %s", err)
+	}
+	if result.Total != -1 || result.TotalMismatch() {
+		t.Errorf("Without total_key, Total = %d and TotalMismatch = %t; want -1 and false", result.Total, result.TotalMismatch())
+	}
+
+	if _, err := client.ListObjects(context.Background(), "/api/objects", &ListOpt{ItemsKey: "missing"}); err == nil {
+		t.Error("ListObjects should return an error on a missing items path")
+	}
+}
+
+func TestAPIClient_listObjectsMaxPages(t *testing.T) {
+	var requests atomic.Int64
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The next page link never clears.
+		fmt.Fprintf(w, `{"items":[{"id":"%d"}],"next":"/api/objects?page=%d"}`, requests.Add(1), requests.Load()+1)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 1000})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+
+	result, err := client.ListObjects(context.Background(), "/api/objects", &ListOpt{ItemsKey: "items", NextKey: "next", MaxPages: 3})
+	if err != nil {
+		t.Fatalf("ListObjects returned an error: %s", err)
+	}
+	if !result.Truncated || result.Pages != 3 || len(result.Items) != 3 || requests.Load() != 3 {
+		t.Errorf("ListObjects read %d page(s) with %d request(s), Truncated %t; want 3 and true", result.Pages, requests.Load(), result.Truncated)
+	}
+}
+
+func TestParseTotal(t *testing.T) {
+	tests := []struct {
+		total    any
+		expected int64
+		fails    bool
+	}{
+		{json.Number("5000"), 5000, false},
+		{"42", 42, false},
+		{"many", 0, true},
+		{true, 0, true},
+	}
+
+	for _, test := range tests {
+		total, err := parseTotal(test.total)
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseTotal(%v) should return an error, got: %d", test.total, total)
+			}
+			continue
+		}
+		if err != nil || total != test.expected {
+			t.Errorf("parseTotal(%v) = %d, %v; want %d", test.total, total, err, test.expected)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Matches the {name} placeholders of a path template.
+var pathPlaceholder = regexp.MustCompile(`\{([^{}/?]+)\}`)
+
+// Returns the path template with its {name} placeholders replaced by the
+// path-escaped values of the same name, e.g. /tenants/{tenant}/things/{id}.
+// An unknown placeholder, or one without value, is an error listing the
+// available names.
+func ExpandPathTemplate(template string, values map[string]string) (string, error) {
+	var unknown []string
+	var empty []string
+	for _, match := range pathPlaceholder.FindAllStringSubmatch(template, -1) {
+		value, ok := values[match[1]]
+		switch {
+		case !ok:
+			unknown = append(unknown, match[0])
+		case value == "":
+			empty = append(empty, match[0])
+		}
+	}
+	if len(unknown) > 0 {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return "", fmt.Errorf("unknown placeholders %s in the path %s, the available ones are: %s", strings.Join(unknown, ", "), template, strings.Join(names, ", "))
+	}
+	if len(empty) > 0 {
+		return "", fmt.Errorf("the placeholders %s of the path %s have no value", strings.Join(empty, ", "), template)
+	}
+
+	return pathPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return url.PathEscape(values[placeholder[1:len(placeholder)-1]])
+	}), nil
+}
@@ -0,0 +1,39 @@
+package apiclient
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandPathTemplate(t *testing.T) {
+	values := map[string]string{"id": "42", "tenant": "tenant_1", "owner": "team a/b", "version": ""}
+	tests := []struct {
+		template string
+		expected string
+		error    string
+	}{
+		{"/tenants/{tenant}/things/{id}", "/tenants/tenant_1/things/42", ""},
+		{"/api/objects?identifier={tenant}", "/api/objects?identifier=tenant_1", ""},
+		{"/owners/{owner}", "/owners/team%20a%2Fb", ""},
+		{"/api/objects", "/api/objects", ""},
+		{"/tenants/{tenant}/things/{thing}/{name}", "", "unknown placeholders {thing}, {name} in the path /tenants/{tenant}/things/{thing}/{name}, the available ones are: id, owner, tenant, version"},
+		{"/things/{id}/versions/{version}", "", "the placeholders {version} of the path /things/{id}/versions/{version} have no value"},
+	}
+
+	for _, test := range tests {
+		path, err := ExpandPathTemplate(test.template, values)
+		if test.error != "" {
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Errorf("ExpandPathTemplate(%s) returned the error %v; want %s", test.template, err, test.error)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ExpandPathTemplate(%s) returned an error: %s", test.template, err)
+			continue
+		}
+		if path != test.expected {
+			t.Errorf("ExpandPathTemplate(%s) = %s; want %s", test.template, path, test.expected)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package apiclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Returns a tls.Config.VerifyPeerCertificate callback rejecting the
+// connections whose server (leaf) certificate doesn't have the given SHA-256
+// fingerprint, written in hexadecimal with or without colons.
+func pinnedCertificateVerifier(fingerprint string) (func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error, error) {
+	pinned, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+	if err != nil || len(pinned) != sha256.Size {
+		return nil, fmt.Errorf("invalid pinned certificate SHA-256 fingerprint: '%s'", fingerprint)
+	}
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("the server presented no certificate")
+		}
+		actual := sha256.Sum256(rawCerts[0])
+		if !bytes.Equal(actual[:], pinned) {
+			return fmt.Errorf("the server certificate SHA-256 fingerprint %s doesn't match the pinned one", hex.EncodeToString(actual[:]))
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,66 @@
+package apiclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIClient_pinnedCertSha256(t *testing.T) {
+	svr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "It works!")
+	}))
+	defer svr.Close()
+
+	sum := sha256.Sum256(svr.Certificate().Raw)
+	fingerprint := strings.ToUpper(hex.EncodeToString(sum[:2])) + ":" + hex.EncodeToString(sum[2:])
+	wrongFingerprint := strings.Repeat("00", sha256.Size)
+	serverCertPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: svr.Certificate().Raw}))
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		pinnedOnly  bool
+		rootCa      string
+		fails       bool
+	}{
+		{"pinned only", fingerprint, true, "", false},
+		{"pinned only, wrong fingerprint", wrongFingerprint, true, "", true},
+		{"pinned and chain validated", fingerprint, false, serverCertPEM, false},
+		{"pinned, untrusted chain", fingerprint, false, "", true},
+		{"trusted chain, wrong fingerprint", wrongFingerprint, false, serverCertPEM, true},
+	}
+
+	for _, test := range tests {
+		client, err := NewAPIClient(&ApiClientOpt{
+			Uri:              svr.URL,
+			Timeout:          2,
+			RateLimit:        100,
+			PinnedCertSha256: test.fingerprint,
+			PinnedCertOnly:   test.pinnedOnly,
+			RootCaString:     test.rootCa,
+		})
+		if err != nil {
+			t.Fatalf("%s: NewAPIClient returned an error: %s", test.name, err)
+		}
+		res, err := client.SendRequest("GET", "/ok", "")
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: the connection should be rejected", test.name)
+			}
+			continue
+		}
+		if err != nil || res != "It works!" {
+			t.Errorf("%s: got back '%s', %v; want 'It works!'", test.name, res, err)
+		}
+	}
+
+	if _, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, PinnedCertSha256: "not a fingerprint"}); err == nil {
+		t.Error("NewAPIClient should fail on an invalid pinned fingerprint")
+	}
+}
@@ -0,0 +1,38 @@
+package apiclient
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Loads the client certificate, its private key and the CA chain from a
+// password-protected PKCS#12 bundle. The CA chain is sent along with the
+// client certificate.
+func loadPkcs12Certificate(file string, password string) (tls.Certificate, error) {
+	pfxData, err := os.ReadFile(file)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("could not read PKCS#12 file: %v", err)
+	}
+
+	privateKey, certificate, caCerts, err := pkcs12.DecodeChain(pfxData, password)
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		return tls.Certificate{}, fmt.Errorf("could not decrypt PKCS#12 file %s: wrong password", file)
+	}
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("could not parse PKCS#12 file %s: %v", file, err)
+	}
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{certificate.Raw},
+		PrivateKey:  privateKey,
+		Leaf:        certificate,
+	}
+	for _, caCert := range caCerts {
+		cert.Certificate = append(cert.Certificate, caCert.Raw)
+	}
+	return cert, nil
+}
@@ -0,0 +1,65 @@
+package apiclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestLoadPkcs12Certificate(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Key generation failed: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("Certificate creation failed: %s", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Certificate parsing failed: %s", err)
+	}
+	pfxData, err := pkcs12.Modern.Encode(privateKey, certificate, []*x509.Certificate{certificate}, "p12secret")
+	if err != nil {
+		t.Fatalf("PKCS#12 encoding failed: %s", err)
+	}
+	file := filepath.Join(t.TempDir(), "client.p12")
+	if err := os.WriteFile(file, pfxData, 0600); err != nil {
+		t.Fatalf("PKCS#12 file writing failed: %s", err)
+	}
+
+	cert, err := loadPkcs12Certificate(file, "p12secret")
+	if err != nil {
+		t.Fatalf("loadPkcs12Certificate returned an error: %s", err)
+	}
+	if len(cert.Certificate) != 2 || cert.Leaf.Subject.CommonName != "terraform-client" {
+		t.Errorf("Unexpected certificate chain of %d certificate(s) for %s", len(cert.Certificate), cert.Leaf.Subject.CommonName)
+	}
+
+	if _, err := loadPkcs12Certificate(file, "wrong"); err == nil || !strings.Contains(err.Error(), "wrong password") {
+		t.Errorf("loadPkcs12Certificate should report a wrong password, got: %v", err)
+	}
+	if _, err := NewAPIClient(&ApiClientOpt{Uri: "https://localhost", Pkcs12File: file, Pkcs12Password: "wrong", Timeout: 2, RateLimit: 10}); err == nil {
+		t.Error("NewAPIClient should fail on a wrong PKCS#12 password")
+	}
+	if _, err := loadPkcs12Certificate(filepath.Join(t.TempDir(), "missing.p12"), "p12secret"); err == nil {
+		t.Error("loadPkcs12Certificate should fail on a missing file")
+	}
+}
@@ -0,0 +1,35 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Returns path with the raw queryString and the structured params appended to
+// its query. The raw query string is parsed and re-encoded, so a malformed one
+// is reported; params take precedence over the raw query string on key
+// conflicts.
+func WithQuery(path string, queryString string, params map[string]string) (string, error) {
+	basePath, pathQuery, _ := strings.Cut(path, "?")
+
+	query, err := url.ParseQuery(pathQuery)
+	if err != nil {
+		return "", fmt.Errorf("invalid query string in the path %s: %w", path, err)
+	}
+	rawQuery, err := url.ParseQuery(strings.TrimPrefix(queryString, "?"))
+	if err != nil {
+		return "", fmt.Errorf("invalid query string %s: %w", queryString, err)
+	}
+	for key, values := range rawQuery {
+		query[key] = values
+	}
+	for key, value := range params {
+		query.Set(key, value)
+	}
+
+	if len(query) == 0 {
+		return basePath, nil
+	}
+	return basePath + "?" + query.Encode(), nil
+}
@@ -0,0 +1,36 @@
+package apiclient
+
+import (
+	"testing"
+)
+
+func TestWithQuery(t *testing.T) {
+	tests := []struct {
+		path        string
+		queryString string
+		params      map[string]string
+		expected    string
+	}{
+		{"/api/objects", "", nil, "/api/objects"},
+		{"/api/objects", "filter=name eq 'a'", nil, "/api/objects?filter=name+eq+%27a%27"},
+		{"/api/objects", "?tag=a&tag=b", nil, "/api/objects?tag=a&tag=b"},
+		{"/api/objects", "", map[string]string{"identifier": "tenant_1"}, "/api/objects?identifier=tenant_1"},
+		{"/api/objects", "limit=10&sort=name", map[string]string{"limit": "50"}, "/api/objects?limit=50&sort=name"},
+		{"/api/objects?version=2", "sort=name", nil, "/api/objects?sort=name&version=2"},
+	}
+
+	for _, test := range tests {
+		path, err := WithQuery(test.path, test.queryString, test.params)
+		if err != nil {
+			t.Errorf("WithQuery(%s, %s, %v) returned an error: %s", test.path, test.queryString, test.params, err)
+			continue
+		}
+		if path != test.expected {
+			t.Errorf("WithQuery(%s, %s, %v) = %s; want %s", test.path, test.queryString, test.params, path, test.expected)
+		}
+	}
+
+	if _, err := WithQuery("/api/objects", "filter=%zz", nil); err == nil {
+		t.Error("WithQuery should return an error on a malformed query string")
+	}
+}
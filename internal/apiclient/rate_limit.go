@@ -0,0 +1,30 @@
+package apiclient
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Returns the limiter of rateLimit requests per second. Its burst, the number
+// of requests sent at once after an idle time, is the rate rounded up so that
+// it holds a second of throughput, and at least 1 as no request could be sent
+// otherwise: a sub-1 rate sends a single request every 1/rate seconds.
+func newRateLimiter(rateLimit float64) *rate.Limiter {
+	burst := int(math.Max(math.Ceil(rateLimit), 1))
+	return rate.NewLimiter(rate.Limit(rateLimit), burst)
+}
+
+// Describes the effective throughput of the limiter, e.g. "0.2 requests/s,
+// in bursts of 1: one request every 5s".
+func describeRateLimit(limiter *rate.Limiter) string {
+	limit := float64(limiter.Limit())
+	description := fmt.Sprintf("%g requests/s, in bursts of %d", limit, limiter.Burst())
+	if limit > 0 && limit < 1 {
+		interval := time.Duration(float64(time.Second) / limit).Round(time.Millisecond)
+		description += fmt.Sprintf(": one request every %s", interval)
+	}
+	return description
+}
@@ -0,0 +1,47 @@
+package apiclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	tests := []struct {
+		rateLimit   float64
+		burst       int
+		interval    time.Duration
+		description string
+	}{
+		{0.5, 1, 2 * time.Second, "0.5 requests/s, in bursts of 1: one request every 2s"},
+		{0.2, 1, 5 * time.Second, "0.2 requests/s, in bursts of 1: one request every 5s"},
+		{2.5, 3, 400 * time.Millisecond, "2.5 requests/s, in bursts of 3"},
+		{10, 10, 100 * time.Millisecond, "10 requests/s, in bursts of 10"},
+	}
+
+	for _, test := range tests {
+		limiter := newRateLimiter(test.rateLimit)
+		if limiter.Burst() != test.burst {
+			t.Errorf("newRateLimiter(%g) has a burst of %d; want %d", test.rateLimit, limiter.Burst(), test.burst)
+		}
+		if description := describeRateLimit(limiter); description != test.description {
+			t.Errorf("describeRateLimit(%g) = %q; want %q", test.rateLimit, description, test.description)
+		}
+
+		/* The burst goes at once, then one request per interval */
+		start := time.Now()
+		for i := 0; i < test.burst; i++ {
+			if !limiter.AllowN(start, 1) {
+				t.Errorf("newRateLimiter(%g) should allow the request %d of the burst", test.rateLimit, i+1)
+			}
+		}
+		if limiter.AllowN(start, 1) {
+			t.Errorf("newRateLimiter(%g) should not allow a request after the burst", test.rateLimit)
+		}
+		if limiter.AllowN(start.Add(test.interval-time.Millisecond), 1) {
+			t.Errorf("newRateLimiter(%g) should not allow a request before %s", test.rateLimit, test.interval)
+		}
+		if !limiter.AllowN(start.Add(test.interval), 1) {
+			t.Errorf("newRateLimiter(%g) should allow a request after %s", test.rateLimit, test.interval)
+		}
+	}
+}
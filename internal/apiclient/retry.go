@@ -0,0 +1,182 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JitterStrategy randomizes the backoff between retries so that the requests
+// failing together (e.g. many resources during an outage) are not retried in
+// sync. See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+type JitterStrategy string
+
+const (
+	// Plain exponential backoff.
+	JitterNone JitterStrategy = "none"
+	// Random wait between 0 and the exponential backoff.
+	JitterFull JitterStrategy = "full"
+	// Half of the exponential backoff plus a random wait up to the other half.
+	JitterEqual JitterStrategy = "equal"
+	// Random wait between the base wait and 3 times the previous wait.
+	JitterDecorrelated JitterStrategy = "decorrelated"
+)
+
+// JitterStrategies lists the supported jitter strategies.
+var JitterStrategies = []JitterStrategy{JitterNone, JitterFull, JitterEqual, JitterDecorrelated}
+
+const (
+	// Wait before the first retry, doubled on each attempt.
+	defaultRetryBaseWait = 500 * time.Millisecond
+	// Upper bound of the wait between two attempts.
+	defaultRetryMaxWait = 30 * time.Second
+)
+
+// Returns min(maxWait, base * 2^attempt).
+func exponentialBackoff(base time.Duration, maxWait time.Duration, attempt int) time.Duration {
+	wait := base
+	for i := 0; i < attempt && wait < maxWait; i++ {
+		wait *= 2
+	}
+	return min(wait, maxWait)
+}
+
+// Returns a random duration in [0, d]. randN returns a random number in [0, n).
+func randomDuration(d time.Duration, randN func(int64) int64) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(randN(int64(d) + 1))
+}
+
+func fullJitter(base time.Duration, maxWait time.Duration, attempt int, randN func(int64) int64) time.Duration {
+	return randomDuration(exponentialBackoff(base, maxWait, attempt), randN)
+}
+
+func equalJitter(base time.Duration, maxWait time.Duration, attempt int, randN func(int64) int64) time.Duration {
+	half := exponentialBackoff(base, maxWait, attempt) / 2
+	return half + randomDuration(half, randN)
+}
+
+func decorrelatedJitter(base time.Duration, maxWait time.Duration, previous time.Duration, randN func(int64) int64) time.Duration {
+	upper := max(previous*3, base)
+	return min(base+randomDuration(upper-base, randN), maxWait)
+}
+
+// Returns the wait before the retry following the attempt (0 for the first
+// request), previous being the last wait.
+func (s JitterStrategy) delay(base time.Duration, maxWait time.Duration, attempt int, previous time.Duration, randN func(int64) int64) time.Duration {
+	switch s {
+	case JitterNone:
+		return exponentialBackoff(base, maxWait, attempt)
+	case JitterEqual:
+		return equalJitter(base, maxWait, attempt, randN)
+	case JitterDecorrelated:
+		return decorrelatedJitter(base, maxWait, previous, randN)
+	default:
+		return fullJitter(base, maxWait, attempt, randN)
+	}
+}
+
+// Returns an error when the strategy is not supported.
+func (s JitterStrategy) validate() error {
+	for _, strategy := range JitterStrategies {
+		if s == strategy {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported retry jitter strategy: '%s'", s)
+}
+
+// RetryCondition classifies the API errors whose body matches its predicate
+// as retryable, for APIs throttling with another status code than 429, e.g.
+// a 400 with {"code": "RATE_LIMITED"}.
+type RetryCondition struct {
+	// Status code of the matching errors, any when 0.
+	StatusCode int
+	Predicate  JsonPredicate
+}
+
+// Returns whether the API error matches the condition. A body that is not
+// JSON doesn't match.
+func (c *RetryCondition) match(apiErr *APIError) bool {
+	if c.StatusCode != 0 && c.StatusCode != apiErr.StatusCode {
+		return false
+	}
+	matched, err := c.Predicate.Match(apiErr.Body)
+	return err == nil && matched
+}
+
+// Returns whether a failed request may succeed when sent again: the API is
+// throttling or temporarily unavailable, its error matches one of the
+// RetryConditions, or the connection failed.
+func (client *APIClient) isRetryable(err error) bool {
+	switch StatusCode(err) {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		for _, condition := range client.RetryConditions {
+			if condition.match(apiErr) {
+				return true
+			}
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Returns whether the request may be sent again after a failure: the request
+// of an idempotent method, or a creation carrying the IdempotencyKeyHeader. A
+// POST or PATCH may have reached the API server before failing, e.g. on a 502
+// or a network error, and sending it again could e.g. duplicate an object.
+func (client *APIClient) isIdempotent(method string, opt *RequestOpt) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return client.IdempotencyKeyHeader != "" && opt != nil && opt.Operation == OperationCreate
+}
+
+// Sends the request, retrying the retryable failures up to MaxRetries times
+// with a jittered exponential backoff, within the retryMaxElapsedTime budget.
+// The last error is returned once the retries are exhausted, or at once when
+// the request is not idempotent. No attempt is sent once the fail_fast breaker
+// is open, stopping the pending retries.
+func (client *APIClient) sendWithRetries(ctx context.Context, idempotent bool, send func() (string, error)) (string, error) {
+	var previous time.Duration
+	start := time.Now()
+
+	for attempt := 0; ; attempt++ {
+		if err := client.failFast.check(); err != nil {
+			return "", err
+		}
+		body, err := send()
+		if err == nil || !idempotent || int64(attempt) >= client.MaxRetries || !client.isRetryable(err) || ctx.Err() != nil {
+			return body, err
+		}
+
+		wait := client.RetryJitter.delay(client.retryBaseWait, client.retryMaxWait, attempt, previous, rand.Int64N)
+		previous = wait
+		if client.retryMaxElapsedTime > 0 && time.Since(start)+wait > client.retryMaxElapsedTime {
+			if client.Debug {
+				client.Logger.Printf("api_client.go: Not retrying past the %s budget after the error: %s\n", client.retryMaxElapsedTime, err)
+			}
+			return body, err
+		}
+		if client.Debug {
+			client.Logger.Printf("api_client.go: Retrying in %s after the error: %s\n", wait, err)
+		}
+		select {
+		case <-ctx.Done():
+			return body, err
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{100, time.Second},
+	}
+
+	for _, test := range tests {
+		if wait := exponentialBackoff(100*time.Millisecond, time.Second, test.attempt); wait != test.expected {
+			t.Errorf("exponentialBackoff(attempt %d) = %s; want %s", test.attempt, wait, test.expected)
+		}
+	}
+}
+
+func TestJitterStrategy_delayBounds(t *testing.T) {
+	base := 100 * time.Millisecond
+	maxWait := 5 * time.Second
+	tests := []struct {
+		strategy JitterStrategy
+		attempt  int
+		previous time.Duration
+		lower    time.Duration
+		upper    time.Duration
+	}{
+		{JitterNone, 2, 0, 400 * time.Millisecond, 400 * time.Millisecond},
+		{JitterFull, 2, 0, 0, 400 * time.Millisecond},
+		{JitterFull, 10, 0, 0, maxWait},
+		{JitterEqual, 2, 0, 200 * time.Millisecond, 400 * time.Millisecond},
+		{JitterDecorrelated, 0, 0, base, base},
+		{JitterDecorrelated, 3, time.Second, base, 3 * time.Second},
+		{JitterDecorrelated, 5, 4 * time.Second, base, maxWait},
+	}
+	// The smallest and largest random values, then random ones.
+	randNs := []func(int64) int64{
+		func(int64) int64 { return 0 },
+		func(n int64) int64 { return n - 1 },
+	}
+	for range 100 {
+		randNs = append(randNs, rand.Int64N)
+	}
+
+	for _, test := range tests {
+		for _, randN := range randNs {
+			wait := test.strategy.delay(base, maxWait, test.attempt, test.previous, randN)
+			if wait < test.lower || wait > test.upper {
+				t.Errorf("%s jitter delay(attempt %d, previous %s) = %s; want in [%s, %s]", test.strategy, test.attempt, test.previous, wait, test.lower, test.upper)
+				break
+			}
+		}
+	}
+}
+
+func TestJitterStrategy_validate(t *testing.T) {
+	for _, strategy := range JitterStrategies {
+		if err := strategy.validate(); err != nil {
+			t.Errorf("validate(%s) returned an error: %s", strategy, err)
+		}
+	}
+	if err := JitterStrategy("random").validate(); err == nil {
+		t.Error("validate should return an error on an unsupported strategy")
+	}
+	if _, err := NewAPIClient(&ApiClientOpt{Uri: "http://localhost", RetryJitter: "random"}); err == nil {
+		t.Error("NewAPIClient should fail on an unsupported retry jitter strategy")
+	}
+}
+
+func TestAPIClient_retries(t *testing.T) {
+	var requests atomic.Int64
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/bad_request":
+			http.Error(w, "bad request", http.StatusBadRequest)
+		case requests.Add(1) <= 2:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, `{"id":"1"}`)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, MaxRetries: 2})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	client.retryBaseWait = time.Millisecond
+
+	res, err := client.SendRequestWithContext(context.Background(), "GET", "/api/objects/1", "")
+	if err != nil {
+		t.Fatalf("The request should succeed after 2 retries: %s", err)
+	}
+	if res != `{"id":"1"}` || requests.Load() != 3 {
+		t.Errorf("Got back '%s' after %d request(s); want the object after 3", res, requests.Load())
+	}
+
+	requests.Store(0)
+	client.MaxRetries = 1
+	if _, err := client.SendRequest("GET", "/api/objects/1", ""); StatusCode(err) != http.StatusServiceUnavailable {
+		t.Errorf("The last 503 should be returned once the retries are exhausted, got: %v", err)
+	}
+
+	if _, err := client.SendRequest("GET", "/bad_request", ""); StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("A 400 should be returned without retry, got: %v", err)
+	}
+}
+
+func TestAPIClient_retriesIdempotentOnly(t *testing.T) {
+	var requests atomic.Int64
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, MaxRetries: 2})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	client.retryBaseWait = time.Millisecond
+	create := &RequestOpt{Operation: OperationCreate}
+
+	for _, method := range []string{"POST", "PATCH"} {
+		requests.Store(0)
+		if _, err := client.SendRequestWithOpt(context.Background(), method, "/api/objects", `{"id":"1"}`, create); StatusCode(err) != http.StatusBadGateway || requests.Load() != 1 {
+			t.Errorf("A %s should not be retried, got %v after %d request(s)", method, err, requests.Load())
+		}
+	}
+
+	requests.Store(0)
+	if _, err := client.SendRequest("PUT", "/api/objects/1", `{"id":"1"}`); StatusCode(err) != http.StatusBadGateway || requests.Load() != 3 {
+		t.Errorf("A PUT should be retried twice, got %v after %d request(s)", err, requests.Load())
+	}
+
+	requests.Store(0)
+	client.IdempotencyKeyHeader = "Idempotency-Key"
+	if _, err := client.SendRequestWithOpt(context.Background(), "POST", "/api/objects", `{"id":"1"}`, create); StatusCode(err) != http.StatusBadGateway || requests.Load() != 3 {
+		t.Errorf("A create with an idempotency key should be retried twice, got %v after %d request(s)", err, requests.Load())
+	}
+	requests.Store(0)
+	if _, err := client.SendRequest("POST", "/api/search", `{}`); requests.Load() != 1 {
+		t.Errorf("A POST other than a create should not be retried, got %v after %d request(s)", err, requests.Load())
+	}
+}
+
+func TestAPIClient_retryConditions(t *testing.T) {
+	var requests atomic.Int64
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/invalid":
+			http.Error(w, `{"code":"INVALID"}`, http.StatusBadRequest)
+		case requests.Add(1) <= 2:
+			/* A rate limit reported with a 400 instead of a 429 */
+			http.Error(w, `{"error":{"code":"RATE_LIMITED"}}`, http.StatusBadRequest)
+		default:
+			fmt.Fprint(w, `{"id":"1"}`)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{
+		Uri:        svr.URL,
+		Timeout:    2,
+		RateLimit:  100,
+		MaxRetries: 2,
+		RetryConditions: []RetryCondition{
+			{StatusCode: http.StatusBadRequest, Predicate: JsonPredicate{Path: "error.code", Value: "RATE_LIMITED"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	client.retryBaseWait = time.Millisecond
+
+	res, err := client.SendRequest("GET", "/api/objects/1", "")
+	if err != nil {
+		t.Fatalf("The request should succeed after retrying the rate-limited 400s: %s", err)
+	}
+	if res != `{"id":"1"}` || requests.Load() != 3 {
+		t.Errorf("Got back '%s' after %d request(s); want the object after 3", res, requests.Load())
+	}
+
+	requests.Store(0)
+	if _, err := client.SendRequest("GET", "/invalid", ""); StatusCode(err) != http.StatusBadRequest || requests.Load() != 0 {
+		t.Errorf("A 400 not matching the conditions should be returned without retry, got: %v", err)
+	}
+
+	conditions := []struct {
+		condition RetryCondition
+		err       *APIError
+		match     bool
+	}{
+		{RetryCondition{Predicate: JsonPredicate{Path: "code", Value: "BUSY"}}, &APIError{StatusCode: 409, Body: `{"code":"BUSY"}`}, true},
+		{RetryCondition{StatusCode: 400, Predicate: JsonPredicate{Path: "code", Value: "BUSY"}}, &APIError{StatusCode: 409, Body: `{"code":"BUSY"}`}, false},
+		{RetryCondition{StatusCode: 400, Predicate: JsonPredicate{Path: "code", Value: "BUSY"}}, &APIError{StatusCode: 400, Body: "Bad Request"}, false},
+	}
+	for _, c := range conditions {
+		if c.condition.match(c.err) != c.match {
+			t.Errorf("The condition %+v should match the error %v: %t", c.condition, c.err, c.match)
+		}
+	}
+}
+
+func TestAPIClient_retryMaxElapsedTime(t *testing.T) {
+	var requests atomic.Int64
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer svr.Close()
+
+	client, err := NewAPIClient(&ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 1000, MaxRetries: 1000, RetryJitter: JitterNone, RetryMaxElapsedTime: 1})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	if client.retryMaxElapsedTime != time.Second {
+		t.Fatalf("Unexpected retry budget: %s", client.retryMaxElapsedTime)
+	}
+	/* 10 ms between the attempts, the budget allowing far less than the 1000 retries */
+	client.retryBaseWait = 10 * time.Millisecond
+	client.retryMaxWait = 10 * time.Millisecond
+	client.retryMaxElapsedTime = 200 * time.Millisecond
+
+	start := time.Now()
+	_, err = client.SendRequest("GET", "/api/objects/1", "")
+	elapsed := time.Since(start)
+	if StatusCode(err) != http.StatusServiceUnavailable {
+		t.Errorf("The last 503 should be returned once the budget is spent, got: %v", err)
+	}
+	if elapsed > client.retryMaxElapsedTime+100*time.Millisecond {
+		t.Errorf("The retries took %s over the budget of %s", elapsed, client.retryMaxElapsedTime)
+	}
+	if n := requests.Load(); n < 2 || n > 21 {
+		t.Errorf("Sent %d requests within the budget; want between 2 and 21", n)
+	}
+
+	/* A first wait over the budget returns the first error */
+	requests.Store(0)
+	client.retryMaxWait = time.Second
+	client.retryBaseWait = time.Second
+	if _, err := client.SendRequest("GET", "/api/objects/1", ""); StatusCode(err) != http.StatusServiceUnavailable || requests.Load() != 1 {
+		t.Errorf("Sent %d requests and got %v; want the single 503", requests.Load(), err)
+	}
+
+	client, err = NewAPIClient(&ApiClientOpt{Uri: svr.URL, RetryMaxWait: 5})
+	if err != nil {
+		t.Fatalf("NewAPIClient returned an error: %s", err)
+	}
+	if client.retryMaxWait != 5*time.Second {
+		t.Errorf("Unexpected maximum wait: %s", client.retryMaxWait)
+	}
+}
@@ -0,0 +1,147 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// DefaultMaxLogBodyBytes is the length of the bodies in the debug output
+// suggested to the clients, e.g. the provider default.
+const DefaultMaxLogBodyBytes = 4096
+
+// Headers whose values are masked in the HTTP wire traces.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// Logs the complete wire format of the outgoing request at trace level.
+// The values of the extraHeaders are masked too, and the body with
+// redactBody.
+func traceRequest(ctx context.Context, req *http.Request, redactBody bool, extraHeaders ...string) {
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		tflog.Trace(ctx, "api_client.go: The request can't be dumped: "+err.Error())
+		return
+	}
+	trace := redactWireDump(string(dump), extraHeaders...)
+	if redactBody {
+		trace = redactWireBody(trace)
+	}
+	tflog.Trace(ctx, "api_client.go: HTTP request:\n"+trace)
+}
+
+// Logs the wire format of the response at trace level, with its body as read
+// by the client, i.e. decompressed and limited to the maximum response size,
+// and masked with redactBody. The body is not read again from the response: a
+// dump of the whole body would read it before its size is checked.
+func traceResponse(ctx context.Context, resp *http.Response, body []byte, redactBody bool) {
+	dump, err := httputil.DumpResponse(resp, false)
+	if err != nil {
+		tflog.Trace(ctx, "api_client.go: The response can't be dumped: "+err.Error())
+		return
+	}
+	trace := redactWireDump(string(dump) + string(body))
+	if redactBody {
+		trace = redactWireBody(trace)
+	}
+	tflog.Trace(ctx, "api_client.go: HTTP response:\n"+trace)
+}
+
+// Counts the bytes read from the wrapped body, e.g. a response body as sent on
+// the wire, before its decompression.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// Logs the sizes of the request and response bodies of an exchange at debug
+// level. For a response decompressed by the client, its size on the wire and
+// the compression ratio are logged too. The size on the wire is unknown when
+// Go's transport decompressed the response itself.
+func logTransferSizes(ctx context.Context, method string, path string, status int, requestBytes int, responseBytes int, wireBytes int64, compressed bool) {
+	fields := map[string]interface{}{
+		"method":         method,
+		"path":           path,
+		"status":         status,
+		"request_bytes":  requestBytes,
+		"response_bytes": responseBytes,
+	}
+	if compressed {
+		fields["response_compressed_bytes"] = wireBytes
+		if wireBytes > 0 {
+			fields["compression_ratio"] = float64(responseBytes) / float64(wireBytes)
+		}
+	}
+	tflog.Debug(ctx, "api_client.go: HTTP transfer sizes", fields)
+}
+
+// Returns the body cut to maxBytes, on a character boundary, for the debug
+// output, followed by the number of bytes left out, e.g. `{"id":...[truncated
+// 1024 bytes]`. A maxBytes of 0 keeps the whole body.
+func truncateLogBody(body string, maxBytes int64) string {
+	if maxBytes <= 0 || int64(len(body)) <= maxBytes {
+		return body
+	}
+	cut := int(maxBytes)
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", body[:cut], len(body)-cut)
+}
+
+// Returns the body of the debug output: masked with RedactLogBodies, e.g.
+// `<redacted 1024 bytes>`, cut to MaxLogBodyBytes otherwise.
+func (client *APIClient) logBody(body string) string {
+	if client.RedactLogBodies {
+		return fmt.Sprintf("<redacted %d bytes>", len(body))
+	}
+	return truncateLogBody(body, client.MaxLogBodyBytes)
+}
+
+// Masks the body of an HTTP wire dump, after the first empty line, keeping
+// its size. A dump without body is left untouched.
+func redactWireBody(dump string) string {
+	head, body, found := strings.Cut(dump, "\r\n\r\n")
+	if !found || body == "" {
+		return dump
+	}
+	return fmt.Sprintf("%s\r\n\r\n<redacted %d bytes>", head, len(body))
+}
+
+// Masks the values of the sensitive headers and of the extraHeaders of an HTTP
+// wire dump. The body, after the first empty line, is left untouched.
+func redactWireDump(dump string, extraHeaders ...string) string {
+	redacted := append(append([]string{}, sensitiveHeaders...), extraHeaders...)
+	lines := strings.Split(dump, "\r\n")
+	for i, line := range lines {
+		if line == "" {
+			break
+		}
+		name, _, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		for _, sensitive := range redacted {
+			if strings.EqualFold(strings.TrimSpace(name), sensitive) {
+				lines[i] = name + ": <redacted>"
+			}
+		}
+	}
+	return strings.Join(lines, "\r\n")
+}
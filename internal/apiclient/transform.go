@@ -0,0 +1,102 @@
+package apiclient
+
+import (
+	"fmt"
+)
+
+// ResponseTransform reshapes an API response before its values are read,
+// for APIs returning objects in a shape Terraform doesn't handle well. The
+// rules apply in the order of the fields.
+type ResponseTransform struct {
+	// Dot-separated path of the part of the response to keep, e.g. "data.tenant".
+	SelectSubtree string
+	// Keys of the selected object to rename, old name to new name.
+	RenameKeys map[string]string
+	// Keys of the selected object whose array of {"key": ..., "value": ...}
+	// objects is converted to a map.
+	ArrayToMap []string
+}
+
+// Applies the transform rules to the object of the API response (see
+// JsonDecodeApiResponse).
+func (t *ResponseTransform) Apply(jsonData string) (string, error) {
+	mapData, err := JsonDecodeApiResponse(jsonData)
+	if err != nil {
+		return "", err
+	}
+
+	data, ok := lookupPath(mapData, t.SelectSubtree)
+	if !ok {
+		return "", fmt.Errorf("path %s not found", t.SelectSubtree)
+	}
+
+	if len(t.RenameKeys) > 0 || len(t.ArrayToMap) > 0 {
+		object, ok := data.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("the keys can't be transformed: the selected value is not an object")
+		}
+		for oldKey, newKey := range t.RenameKeys {
+			if value, ok := object[oldKey]; ok {
+				delete(object, oldKey)
+				object[newKey] = value
+			}
+		}
+		for _, key := range t.ArrayToMap {
+			value, ok := object[key]
+			if !ok {
+				continue
+			}
+			converted, err := arrayToMap(value)
+			if err != nil {
+				return "", fmt.Errorf("key %s: %w", key, err)
+			}
+			object[key] = converted
+		}
+	}
+
+	jsonBytes, err := JsonMarshal(data)
+	if err != nil {
+		return "", fmt.Errorf("the transformed data can't be encoded into JSON: %v", data)
+	}
+	return string(jsonBytes), nil
+}
+
+// Converts an array of {"key": ..., "value": ...} objects to a map.
+func arrayToMap(value any) (map[string]any, error) {
+	entries, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("the value is not an array")
+	}
+
+	result := make(map[string]any, len(entries))
+	for i, entry := range entries {
+		pair, ok := entry.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("the entry %d is not an object", i)
+		}
+		key, ok := pair["key"].(string)
+		if !ok {
+			return nil, fmt.Errorf("the entry %d has no string key", i)
+		}
+		result[key] = pair["value"]
+	}
+	return result, nil
+}
+
+// ResponseAtRoot returns the part of the API response at the client
+// ResponseRoot, e.g. the object or the array of {"data": ...} with "data",
+// numbers kept as written. Without ResponseRoot, the response is returned as
+// is.
+func (client *APIClient) ResponseAtRoot(jsonData string) (string, error) {
+	if client.ResponseRoot == "" {
+		return jsonData, nil
+	}
+	value, found, err := GetJsonAtPath(jsonData, client.ResponseRoot)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("the response has no %s root", client.ResponseRoot)
+	}
+	return value, nil
+}
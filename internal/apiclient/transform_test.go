@@ -0,0 +1,98 @@
+package apiclient
+
+import (
+	"testing"
+)
+
+func TestResponseTransform_selectSubtree(t *testing.T) {
+	jsonData := `{"status":"ok","data":{"tenant":{"id":"1","identifier":"tenant_1"}}}`
+
+	transformed, err := (&ResponseTransform{SelectSubtree: "data.tenant"}).Apply(jsonData)
+	if err != nil {
+		t.Fatalf("Apply returned an error: %s", err)
+	}
+	if transformed != `{"id":"1","identifier":"tenant_1"}` {
+		t.Errorf("Apply = %s; want the tenant subtree", transformed)
+	}
+
+	transformed, err = (&ResponseTransform{SelectSubtree: "data.tenant"}).Apply("[" + jsonData + "]")
+	if err != nil {
+		t.Fatalf("Apply returned an error: %s", err)
+	}
+	if transformed != `{"id":"1","identifier":"tenant_1"}` {
+		t.Errorf("Apply on an array = %s; want the tenant subtree of its object", transformed)
+	}
+
+	transformed, err = (&ResponseTransform{}).Apply(jsonData)
+	if err != nil {
+		t.Fatalf("Apply returned an error: %s", err)
+	}
+	if transformed != `{"data":{"tenant":{"id":"1","identifier":"tenant_1"}},"status":"ok"}` {
+		t.Errorf("Apply without rules = %s; want the whole document", transformed)
+	}
+
+	if _, err := (&ResponseTransform{SelectSubtree: "data.missing"}).Apply(jsonData); err == nil {
+		t.Error("Apply should return an error on a missing subtree")
+	}
+}
+
+func TestResponseTransform_renameKeys(t *testing.T) {
+	transform := &ResponseTransform{RenameKeys: map[string]string{"uuid": "id", "missing": "other"}}
+
+	transformed, err := transform.Apply(`{"uuid":"1","identifier":"tenant_1"}`)
+	if err != nil {
+		t.Fatalf("Apply returned an error: %s", err)
+	}
+	if transformed != `{"id":"1","identifier":"tenant_1"}` {
+		t.Errorf("Apply = %s; want uuid renamed to id", transformed)
+	}
+
+	if _, err := (&ResponseTransform{SelectSubtree: "uuid", RenameKeys: transform.RenameKeys}).Apply(`{"uuid":"1"}`); err == nil {
+		t.Error("Apply should return an error when renaming the keys of a non-object")
+	}
+}
+
+func TestResponseTransform_arrayToMap(t *testing.T) {
+	transform := &ResponseTransform{
+		SelectSubtree: "tenant",
+		ArrayToMap:    []string{"labels"},
+	}
+
+	transformed, err := transform.Apply(`{"tenant":{"id":"1","labels":[{"key":"env","value":"prod"},{"key":"team","value":"a"}]}}`)
+	if err != nil {
+		t.Fatalf("Apply returned an error: %s", err)
+	}
+	if transformed != `{"id":"1","labels":{"env":"prod","team":"a"}}` {
+		t.Errorf("Apply = %s; want the labels as a map", transformed)
+	}
+
+	if _, err := transform.Apply(`{"tenant":{"labels":[{"name":"env"}]}}`); err == nil {
+		t.Error("Apply should return an error on an entry without key")
+	}
+	if _, err := transform.Apply(`{"tenant":{"labels":"env"}}`); err == nil {
+		t.Error("Apply should return an error on a non-array value")
+	}
+}
+
+func TestAPIClient_responseAtRoot(t *testing.T) {
+	client := &APIClient{ResponseRoot: "data"}
+	for _, test := range []struct {
+		jsonData string
+		expected string
+	}{
+		{`{"data":{"id":12345678901234567890,"name":"tenant_1"},"meta":{}}`, `{"id":12345678901234567890,"name":"tenant_1"}`},
+		{`{"data":[{"id":1},{"id":2}],"next":null}`, `[{"id":1},{"id":2}]`},
+	} {
+		result, err := client.ResponseAtRoot(test.jsonData)
+		if err != nil || result != test.expected {
+			t.Errorf("ResponseAtRoot(%s) = %s, %v; want %s", test.jsonData, result, err, test.expected)
+		}
+	}
+
+	if _, err := client.ResponseAtRoot(`{"id":1}`); err == nil {
+		t.Error("ResponseAtRoot should return an error on a response without root")
+	}
+	if result, err := (&APIClient{}).ResponseAtRoot(`{"id":1}`); err != nil || result != `{"id":1}` {
+		t.Errorf("ResponseAtRoot without root = %s, %v; want the response as is", result, err)
+	}
+}
@@ -0,0 +1,163 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Timeout of each request to Vault.
+const vaultTimeout = 30 * time.Second
+
+// VaultOpt locates a secret in HashiCorp Vault and authenticates its read,
+// with a token or an AppRole login.
+type VaultOpt struct {
+	// Vault URL, e.g. "https://vault.example.com:8200".
+	Address string
+	Token   string
+	// AppRole credentials, logging in to get a token when Token is empty.
+	RoleId   string
+	SecretId string
+	// Path of the PEM root CA of Vault, when not trusted by the system.
+	CaCertFile string
+	// API path of the secret, without the "/v1/" prefix, e.g.
+	// "secret/data/trustbuilder" in a KV version 2 engine mounted at "secret".
+	Path string
+	Key  string
+}
+
+// ReadVaultSecret reads the string value of the key of a secret in a KV
+// engine, version 1 or 2.
+func ReadVaultSecret(ctx context.Context, opt *VaultOpt) (string, error) {
+	httpClient, err := newVaultHttpClient(opt.CaCertFile)
+	if err != nil {
+		return "", err
+	}
+
+	token := opt.Token
+	if token == "" {
+		if token, err = vaultAppRoleLogin(ctx, httpClient, opt); err != nil {
+			return "", err
+		}
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := vaultRequest(ctx, httpClient, opt.Address, "GET", opt.Path, token, nil, &secret); err != nil {
+		return "", err
+	}
+	data := secret.Data
+	/* KV version 2 nests the secret in data.data, along with data.metadata */
+	if nested, ok := data["data"].(map[string]any); ok && data["metadata"] != nil {
+		data = nested
+	}
+	value, found := data[opt.Key]
+	if !found {
+		return "", fmt.Errorf("the Vault secret %s has no key %s", opt.Path, opt.Key)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("the key %s of the Vault secret %s is not a string", opt.Key, opt.Path)
+	}
+	return text, nil
+}
+
+// Returns the HTTP client of the Vault requests, trusting caCertFile when set.
+func newVaultHttpClient(caCertFile string) (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+	if caCertFile != "" {
+		caCert, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read Vault CA file: %v", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to append Vault CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+	return &http.Client{
+		Timeout: vaultTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+	}, nil
+}
+
+// Logs in with the AppRole credentials and returns the client token.
+func vaultAppRoleLogin(ctx context.Context, httpClient *http.Client, opt *VaultOpt) (string, error) {
+	if opt.RoleId == "" || opt.SecretId == "" {
+		return "", errors.New("a Vault token or AppRole role_id and secret_id are required")
+	}
+	var login struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": opt.RoleId, "secret_id": opt.SecretId}
+	if err := vaultRequest(ctx, httpClient, opt.Address, "POST", "auth/approle/login", "", body, &login); err != nil {
+		return "", fmt.Errorf("the Vault AppRole login failed: %w", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", errors.New("the Vault AppRole login returned no token")
+	}
+	return login.Auth.ClientToken, nil
+}
+
+// Sends a request to the Vault API at the path under /v1/, decoding the JSON
+// response into result. The errors reported by Vault are returned.
+func vaultRequest(ctx context.Context, httpClient *http.Client, address string, method string, path string, token string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	uri := strings.TrimRight(address, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, method, uri, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var vaultErrors struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &vaultErrors) == nil && len(vaultErrors.Errors) > 0 {
+			return fmt.Errorf("the Vault server answered %d on %s: %s", resp.StatusCode, path, strings.Join(vaultErrors.Errors, "; "))
+		}
+		return fmt.Errorf("the Vault server answered %d on %s", resp.StatusCode, path)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("the Vault response on %s can't be decoded: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadVaultSecret(t *testing.T) {
+	/* Serves a KV v2 and a KV v1 secret to the token of an AppRole login or to the root token */
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/v1/auth/approle/login" {
+			var login map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login["role_id"] != "role-1" || login["secret_id"] != "secret-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"errors":["invalid role or secret ID"]}`)
+				return
+			}
+			fmt.Fprint(w, `{"auth":{"client_token":"approle-token"}}`)
+			return
+		}
+		if token := r.Header.Get("X-Vault-Token"); token != "approle-token" && token != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/trustbuilder":
+			fmt.Fprint(w, `{"data":{"data":{"jwt_secret":"s3cr3t","port":8200},"metadata":{"version":3}}}`)
+		case "/v1/kv/trustbuilder":
+			fmt.Fprint(w, `{"data":{"client_secret":"oauth-s3cr3t"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+	defer svr.Close()
+	ctx := context.Background()
+
+	secret, err := ReadVaultSecret(ctx, &VaultOpt{Address: svr.URL + "/", RoleId: "role-1", SecretId: "secret-1", Path: "secret/data/trustbuilder", Key: "jwt_secret"})
+	if err != nil || secret != "s3cr3t" {
+		t.Errorf("ReadVaultSecret on KV v2 returned '%s' and the error: %v", secret, err)
+	}
+	secret, err = ReadVaultSecret(ctx, &VaultOpt{Address: svr.URL, Token: "root-token", Path: "/kv/trustbuilder", Key: "client_secret"})
+	if err != nil || secret != "oauth-s3cr3t" {
+		t.Errorf("ReadVaultSecret on KV v1 returned '%s' and the error: %v", secret, err)
+	}
+
+	tests := []struct {
+		opt   VaultOpt
+		error string
+	}{
+		{VaultOpt{Token: "root-token", Path: "secret/data/trustbuilder", Key: "missing"}, "has no key missing"},
+		{VaultOpt{Token: "root-token", Path: "secret/data/trustbuilder", Key: "port"}, "is not a string"},
+		{VaultOpt{Token: "root-token", Path: "secret/data/other", Key: "jwt_secret"}, "answered 404"},
+		{VaultOpt{Token: "other-token", Path: "secret/data/trustbuilder", Key: "jwt_secret"}, "permission denied"},
+		{VaultOpt{RoleId: "role-1", SecretId: "wrong", Path: "secret/data/trustbuilder", Key: "jwt_secret"}, "AppRole login failed"},
+		{VaultOpt{Path: "secret/data/trustbuilder", Key: "jwt_secret"}, "token or AppRole"},
+	}
+	for _, test := range tests {
+		test.opt.Address = svr.URL
+		if _, err := ReadVaultSecret(ctx, &test.opt); err == nil || !strings.Contains(err.Error(), test.error) {
+			t.Errorf("ReadVaultSecret(%+v) returned the error %v; want one containing '%s'", test.opt, err, test.error)
+		}
+	}
+}
@@ -11,4 +11,6 @@ const (
 	TrustbuilderDestroyMethod = "TRUSTBUILDER_DESTROY_METHOD"
 	TrustbuilderTestPath      = "TRUSTBUILDER_TEST_PATH"
 	TrustbuilderDebug         = "TRUSTBUILDER_DEBUG"
+	VaultAddr                 = "VAULT_ADDR"
+	VaultToken                = "VAULT_TOKEN"
 )
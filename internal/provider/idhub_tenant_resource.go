@@ -3,21 +3,33 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/apiclient"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource = &idhubTenantResource{}
+	_ resource.Resource                   = &idhubTenantResource{}
+	_ resource.ResourceWithValidateConfig = &idhubTenantResource{}
 )
 
 // idhubTenantResource is the resource implementation.
@@ -28,15 +40,76 @@ type idhubTenantResource struct {
 
 // idhubTenantResourceModel maps the resource schema data.
 type idhubTenantResourceModel struct {
-	Headers        types.Map    `tfsdk:"headers"`
-	LastUpdated    types.String `tfsdk:"last_updated"`
-	Id             types.String `tfsdk:"id"`
-	Tenant         types.String `tfsdk:"tenant"`
-	RepoNamePrefix types.String `tfsdk:"repo_name_prefix"`
-	Path           types.String `tfsdk:"path"`
-	Data           types.String `tfsdk:"data"`
+	Headers           types.Map           `tfsdk:"headers"`
+	LastUpdated       types.String        `tfsdk:"last_updated"`
+	Id                types.String        `tfsdk:"id"`
+	Tenant            types.String        `tfsdk:"tenant"`
+	RepoNamePrefix    types.String        `tfsdk:"repo_name_prefix"`
+	Path              types.String        `tfsdk:"path"`
+	Data              types.String        `tfsdk:"data"`
+	NotFoundPredicate *jsonPredicateModel `tfsdk:"not_found_predicate"`
+	ComputedKeys      types.Map           `tfsdk:"computed_keys"`
+	ComputedValues    types.Map           `tfsdk:"computed_values"`
+	Timeouts          timeouts.Value      `tfsdk:"timeouts"`
+	SuppressHeaders   types.List          `tfsdk:"suppress_headers"`
+	ReadBackKey       types.String        `tfsdk:"read_back_key"`
+	TimeFormat        types.String        `tfsdk:"time_format"`
+	SelectSubtree     types.String        `tfsdk:"select_subtree"`
+	ReadData          types.String        `tfsdk:"read_data"`
+	SelectElement     *jsonPredicateModel `tfsdk:"select_element"`
+	CanonicalizeData  types.Bool          `tfsdk:"canonicalize_request_body"`
+	IdFromDataKey     types.String        `tfsdk:"id_from_data_key"`
+	ReadPath          types.String        `tfsdk:"read_path"`
+	UseLocationAsPath types.Bool          `tfsdk:"use_location_as_path"`
+	Location          types.String        `tfsdk:"location"`
+	WaitFor           *waitForModel       `tfsdk:"wait_for"`
+	IdHeader          types.String        `tfsdk:"id_header"`
+	IdentifierKey     types.String        `tfsdk:"identifier_key"`
+	ContentType       types.String        `tfsdk:"content_type"`
+	ResponseFormat    types.String        `tfsdk:"response_format"`
+	Response          types.String        `tfsdk:"response"`
+	Operation         *operationModel     `tfsdk:"operation"`
+	IfNotExists       types.Bool          `tfsdk:"if_not_exists"`
+	TrimResponse      types.Bool          `tfsdk:"trim_response"`
+	SkipReadBack      types.Bool          `tfsdk:"skip_read_after_create"`
 }
 
+// jsonPredicateModel maps a JSON path and the value expected at this path.
+type jsonPredicateModel struct {
+	Path  types.String `tfsdk:"path"`
+	Value types.String `tfsdk:"value"`
+}
+
+// waitForModel maps the readiness condition polled after the creation.
+type waitForModel struct {
+	Path     types.String `tfsdk:"path"`
+	Value    types.String `tfsdk:"value"`
+	Interval types.Int64  `tfsdk:"interval"`
+	Timeout  types.Int64  `tfsdk:"timeout"`
+}
+
+// operationModel maps the asynchronous operation polled after the creation.
+type operationModel struct {
+	IdPath     types.String        `tfsdk:"id_path"`
+	StatusPath types.String        `tfsdk:"status_path"`
+	Success    *jsonPredicateModel `tfsdk:"success"`
+	Failure    *jsonPredicateModel `tfsdk:"failure"`
+	Interval   types.Int64         `tfsdk:"interval"`
+	Timeout    types.Int64         `tfsdk:"timeout"`
+}
+
+// Formats of the API responses of response_format.
+const (
+	responseFormatJson = "json"
+	responseFormatRaw  = "raw"
+)
+
+// Default interval and timeout of wait_for and operation.
+const (
+	defaultWaitForInterval = 5 * time.Second
+	defaultWaitForTimeout  = 5 * time.Minute
+)
+
 // NewtenantResource is a helper function to simplify the provider implementation.
 func NewTenantResource() resource.Resource {
 	return &idhubTenantResource{}
@@ -48,7 +121,7 @@ func (r *idhubTenantResource) Metadata(_ context.Context, req resource.MetadataR
 }
 
 // Schema defines the schema for the resource.
-func (r *idhubTenantResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *idhubTenantResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Resource managing the creation of an idhub tenant.",
 		Attributes: map[string]schema.Attribute{
@@ -57,8 +130,20 @@ func (r *idhubTenantResource) Schema(_ context.Context, _ resource.SchemaRequest
 				ElementType: types.StringType,
 				Optional:    true,
 			},
+			"suppress_headers": schema.ListAttribute{
+				Description: "A list of header names, set by the provider (e.g. in its `headers`), that are not sent on the requests of this resource. Not applied on import.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"time_format": schema.StringAttribute{
+				Description: "Format of `last_updated`: `RFC3339`, `RFC850` or `RFC1123`. Defaults to `RFC3339`.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(timeFormatNames()...),
+				},
+			},
 			"last_updated": schema.StringAttribute{
-				Description: "Resource update date in RFC850 format.",
+				Description: "Resource update date, in the `time_format` format. Null after an import, until the next apply updating the resource.",
 				Computed:    true,
 			},
 			"id": schema.StringAttribute{
@@ -83,18 +168,299 @@ func (r *idhubTenantResource) Schema(_ context.Context, _ resource.SchemaRequest
 				},
 			},
 			"path": schema.StringAttribute{
-				Description: "The API path on top of the base URL set in the provider that represents objects of this type on the API server.",
-				Required:    true,
+				Description: "The API path on top of the base URL set in the provider that represents objects of this type on the API server. " +
+					"It can hold the id of another resource, e.g. `\"/api/objects/${trustbuilder_idhub_tenant.parent.id}/children\"`, known at apply time only. " +
+					"Changing it, e.g. when the parent is replaced, replaces the tenant.",
+				Required: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"data": schema.StringAttribute{
-				Description: "Valid JSON object that this provider will manage with the API server.",
+				Description: "Valid JSON object that this provider will manage with the API server, or any body sent verbatim with `content_type`. It is only sent on creation and, being write-only, never shown in the plan output nor stored in the Terraform state, so secrets in it need no `sensitive` marking. Set the provider `redact_log_bodies` to keep it out of the logs too.",
 				Required:    true,
 				WriteOnly:   true,
 			},
+			"content_type": schema.StringAttribute{
+				Description: "Content type of `data`, e.g. `application/xml` or `text/plain`, overriding the provider `headers`. `data` is then sent verbatim, without being required to be JSON. Defaults to `application/json`.",
+				Optional:    true,
+			},
+			"response_format": schema.StringAttribute{
+				Description: "Format of the API responses: `json`, or `raw` for XML, text or YAML responses, kept as is in `response` instead of being parsed. " +
+					"With `raw`, the id is read from the `id_header` header of the creation response, which is then required, the tenant is read by its id, and `tenant` and `repo_name_prefix` are null. Defaults to `json`.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(responseFormatJson, responseFormatRaw),
+				},
+			},
+			"trim_response": schema.BoolAttribute{
+				Description: "When true, the leading and trailing whitespace of the API responses, e.g. a newline around the body, is removed before they are read or stored in `response`, so that cosmetic whitespace causes no diff. " +
+					"It is removed after the UTF-8 byte order mark and the XSSI prefix. Defaults to true with the `json` `response_format`, false with `raw`.",
+				Optional: true,
+			},
+			"response": schema.StringAttribute{
+				Description: "The body of the last API response, as is, when `response_format` is `raw`. Null otherwise.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"canonicalize_request_body": schema.BoolAttribute{
+				Description: "When true, `data` is re-encoded with sorted keys and without insignificant whitespace before being sent, for APIs computing a content hash over the request body. Defaults to false.",
+				Optional:    true,
+			},
+			"read_data": schema.StringAttribute{
+				Description: "Valid JSON object sent as the body of the read requests, e.g. a search payload for APIs querying with GET requests carrying a body. Not applied on import.",
+				Optional:    true,
+			},
+			"read_path": schema.StringAttribute{
+				Description: "Template of the path, on top of the base URL set in the provider, of the refresh reads, e.g. `/tenants/{tenant}/things/{id}`. " +
+					"Its placeholders are replaced by the values extracted from the last API response: `{id}`, `{tenant}`, `{repo_name_prefix}` and the names of `computed_keys`, which can't override the former. " +
+					"An unknown placeholder fails the read. When not set, the tenant is read on `path` with the provider `identifier_query_param`. Not applied on create and import.",
+				Optional: true,
+			},
+			"use_location_as_path": schema.BoolAttribute{
+				Description: "When true, the tenant is read at the URL of the `Location` header of the creation response, stored as a path relative to the provider `uri` in `location`, instead of on `path` with the provider `identifier_query_param`. " +
+					"A creation response without `Location`, or with one out of the provider `uri`, fails the creation. `read_path` takes precedence. Defaults to false.",
+				Optional: true,
+			},
+			"location": schema.StringAttribute{
+				Description: "The path, relative to the provider `uri`, of the `Location` header of the creation response when `use_location_as_path` is set or the response is a redirect, e.g. a `303 See Other` expected by the provider `create_expected_status` with `follow_redirects` set to false.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"not_found_predicate": schema.SingleNestedAttribute{
+				Description: "When set, a successful read response matching this predicate means that the object doesn't exist anymore: the resource is removed from the state as if the API returned a 404. Useful for APIs answering 200 with a body like `{\"found\": false}`.",
+				Optional:    true,
+				Attributes:  jsonPredicateSchema(),
+			},
+			"wait_for": schema.SingleNestedAttribute{
+				Description: "When set, the creation completes only once the tenant, read as on refresh, matches this condition, e.g. `status` equal to `ready` for a tenant provisioned asynchronously, so that its dependents wait for it. " +
+					"On timeout, the creation fails with the last observed value and the tenant is tainted. Not applied on import.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"path": schema.StringAttribute{
+						Description: "Dot-separated JSON path of the checked value in the tenant, e.g. `status`.",
+						Required:    true,
+					},
+					"value": schema.StringAttribute{
+						Description: "Expected value, compared with the string representation of the JSON value (e.g. `ready`).",
+						Required:    true,
+					},
+					"interval": schema.Int64Attribute{
+						Description: "Time between two reads, in seconds. Defaults to 5.",
+						Optional:    true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"timeout": schema.Int64Attribute{
+						Description: "Time to wait for the condition, in seconds, within the create timeout. Defaults to 300.",
+						Optional:    true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+				},
+			},
+			"operation": schema.SingleNestedAttribute{
+				Description: "When set, the creation response is the handle of an asynchronous operation, e.g. `{\"operation\": {\"id\": \"op-1\"}}`, whose status is polled until it succeeds or fails. " +
+					"The tenant is then read at the `Location` of the creation response, by `id_from_data_key` or by `read_back_key`. On failure or timeout, the creation fails with the last status. Not applied on import.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"id_path": schema.StringAttribute{
+						Description: "Dot-separated JSON path of the operation id in the creation response, e.g. `operation.id`.",
+						Required:    true,
+					},
+					"status_path": schema.StringAttribute{
+						Description: "Template of the path of the operation status, on top of the base URL set in the provider, e.g. `/operations/{op_id}`, `{op_id}` being replaced by the operation id.",
+						Required:    true,
+					},
+					"success": schema.SingleNestedAttribute{
+						Description: "Condition of the status of a succeeded operation, e.g. `status` equal to `done`.",
+						Required:    true,
+						Attributes:  jsonPredicateSchema(),
+					},
+					"failure": schema.SingleNestedAttribute{
+						Description: "Condition of the status of a failed operation, e.g. `status` equal to `failed`, failing the creation at once.",
+						Optional:    true,
+						Attributes:  jsonPredicateSchema(),
+					},
+					"interval": schema.Int64Attribute{
+						Description: "Time between two status reads, in seconds. Defaults to 5.",
+						Optional:    true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"timeout": schema.Int64Attribute{
+						Description: "Time to wait for the operation, in seconds, within the create timeout. Defaults to 300.",
+						Optional:    true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+				},
+			},
+			"read_back_key": schema.StringAttribute{
+				Description: "Key of `data` holding a natural key of the object (e.g. `identifier`). When the creation response has no `id`, the object is read back by the value of this key, passed as the provider `identifier_query_param`. When not set, a missing `id` fails the creation with a warning that the object may exist on the API server.",
+				Optional:    true,
+			},
+			"skip_read_after_create": schema.BoolAttribute{
+				Description: "When true, the state is set from the creation response only, for APIs whose creation response is authoritative and whose objects may not be readable right after their creation. " +
+					"An empty creation response, e.g. a 204 or a redirect, or one without `id` then fails the creation instead of reading the tenant back, like with the provider `create_returns_object`. Can't be combined with `operation` and `wait_for`. Defaults to false.",
+				Optional: true,
+			},
+			"id_from_data_key": schema.StringAttribute{
+				Description: "Key of `data` holding the client-chosen key of the object (e.g. `name`), used as `id` for APIs that never return a synthetic id. The creation response then needs no `id`, and the object is read by this value, passed as the provider `identifier_query_param`, instead of by `tenant`.",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"id_header": schema.StringAttribute{
+				Description: "Name of a header of the creation response holding the id of the tenant (e.g. `X-Resource-Id`), for APIs returning it there only. " +
+					"When the creation response has this header, its value is used as `id` and the responses need no `id`; otherwise the id is read from the response as usual.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("id_from_data_key")),
+				},
+			},
+			"identifier_key": schema.StringAttribute{
+				Description: "Key of the API responses holding the tenant name read into `tenant`, for APIs naming it differently (e.g. `name`). " +
+					"When the creation response is empty, the tenant is read back by the value of this key of `data`. Defaults to `identifier`.",
+				Optional: true,
+			},
+			"if_not_exists": schema.BoolAttribute{
+				Description: "When true, the creation first reads the tenant by the value of the `identifier_key` of `data`, passed as the provider `identifier_query_param`, and adopts an existing tenant into the state instead of creating it. " +
+					"Unlike an upsert, it doesn't rely on the API accepting the creation of an existing object. A 404, an empty array or a response matching `not_found_predicate` means the tenant doesn't exist. Defaults to false.",
+				Optional: true,
+			},
+			"select_element": schema.SingleNestedAttribute{
+				Description: "When set, the API responses are arrays, e.g. from a filtering list endpoint, and the tenant is the single element matching this predicate. Zero or several matching elements are an error. Applied before `select_subtree`. Not applied on import.",
+				Optional:    true,
+				Attributes:  jsonPredicateSchema(),
+			},
+			"select_subtree": schema.StringAttribute{
+				Description: "Dot-separated JSON path (e.g. `data.tenant`) of the part of the API responses holding the tenant, for APIs wrapping it in an envelope. The `id`, `identifier`, `repo_name_prefix` and `computed_keys` values are read from this part. Not applied on import.",
+				Optional:    true,
+			},
+			"computed_keys": schema.MapAttribute{
+				Description: "A map of names to dot-separated JSON paths (e.g. `meta.version`) of values to extract from the API responses into `computed_values`.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"computed_values": schema.MapAttribute{
+				Description: "The values extracted from the API responses for each entry of `computed_keys`, refreshed on every read and on the updates changing `computed_keys`, e.g. server-managed metadata like `created_by` or `version` to reference elsewhere. Non-string values are JSON encoded, the numbers as written in the responses.",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+					computedValuesPlanModifier{},
+				},
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create:            true,
+				Read:              true,
+				CreateDescription: "Timeout of the creation, its requests, `operation` and `wait_for` polls included, e.g. `60s`. Each request is also bound to the provider `timeout`, the earlier deadline applying. Defaults to no limit beyond the provider `timeout` of each request.",
+				ReadDescription:   "Timeout of the read requests, e.g. `5s`, for reads failing faster than the provider `timeout`, which still applies when earlier. Defaults to the provider `timeout`.",
+			}),
+		},
+	}
+}
+
+// computedValuesPlanModifier plans computed_values unknown when computed_keys
+// changes, the values of the new keys being read by the update. The previous
+// values are kept otherwise, see UseStateForUnknown.
+type computedValuesPlanModifier struct{}
+
+func (m computedValuesPlanModifier) Description(_ context.Context) string {
+	return "computed_values is read again when computed_keys changes"
+}
+
+func (m computedValuesPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m computedValuesPlanModifier) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse) {
+	/* Nothing to compare on create and destroy */
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+	var planKeys, stateKeys types.Map
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("computed_keys"), &planKeys)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("computed_keys"), &stateKeys)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !planKeys.Equal(stateKeys) {
+		resp.PlanValue = types.MapUnknown(types.StringType)
+	}
+}
+
+func jsonPredicateSchema() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"path": schema.StringAttribute{
+			Description: "Dot-separated JSON path of the checked value in the response, e.g. `meta.found`.",
+			Required:    true,
+		},
+		"value": schema.StringAttribute{
+			Description: "Expected value, compared with the string representation of the JSON value (e.g. `false`).",
+			Required:    true,
 		},
 	}
 }
 
+// ValidateConfig rejects the settings reading the created tenant with
+// skip_read_after_create, and the settings reading JSON responses when
+// response_format is raw, which requires id_header to identify the tenant.
+func (r *idhubTenantResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var skipReadBack types.Bool
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("skip_read_after_create"), &skipReadBack)...)
+	if skipReadBack.ValueBool() {
+		for _, name := range []string{"operation", "wait_for"} {
+			var value attr.Value
+			resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(name), &value)...)
+			if value != nil && !value.IsNull() {
+				resp.Diagnostics.AddAttributeError(
+					path.Root(name),
+					"Invalid attribute combination",
+					fmt.Sprintf("%s reads the created tenant and can't be set with skip_read_after_create.", name),
+				)
+			}
+		}
+	}
+
+	var responseFormat types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("response_format"), &responseFormat)...)
+	if resp.Diagnostics.HasError() || responseFormat.ValueString() != responseFormatRaw {
+		return
+	}
+
+	for _, name := range []string{"not_found_predicate", "select_element", "select_subtree", "computed_keys", "wait_for", "operation", "id_from_data_key", "read_back_key", "identifier_key", "if_not_exists"} {
+		var value attr.Value
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root(name), &value)...)
+		if value != nil && !value.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(name),
+				"Invalid attribute combination",
+				fmt.Sprintf("%s reads JSON responses and can't be set with response_format \"raw\".", name),
+			)
+		}
+	}
+	var idHeader types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("id_header"), &idHeader)...)
+	if idHeader.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("id_header"),
+			"Missing attribute",
+			"id_header is required with response_format \"raw\": the id can't be read from the responses.",
+		)
+	}
+}
+
 // Create a new resource.
 func (r *idhubTenantResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var planResource idhubTenantResourceModel
@@ -120,17 +486,143 @@ func (r *idhubTenantResource) Create(ctx context.Context, req resource.CreateReq
 		return
 	}
 
-	responseData, err := r.client.SendRequest("POST", planResource.Path.ValueString(), dataAttribute.ValueString())
+	createTimeout, diags := planResource.Timeouts.Create(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withTimeout(ctx, createTimeout)
+	defer cancel()
+
+	requestOpt, diags := planResource.requestOpt(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data := dataAttribute.ValueString()
+	if planResource.CanonicalizeData.ValueBool() {
+		canonicalData, err := apiclient.CanonicalJson(data)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("data"), "Invalid data attribute", fmt.Sprintf("The data can't be canonicalized: %s", err))
+			return
+		}
+		data = canonicalData
+	}
+
+	if !planResource.IdFromDataKey.IsNull() {
+		id, err := apiclient.GetKeyValue(data, planResource.IdFromDataKey.ValueString())
+		if err != nil || id == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("id_from_data_key"), "Missing id in data", fmt.Sprintf("The key %s of the data can't be used as id: %v", planResource.IdFromDataKey.ValueString(), err))
+			return
+		}
+		planResource.Id = types.StringValue(id)
+	}
+
+	var responseData, locationPath string
+	var responseHeader http.Header
+	var err error
+	exists := false
+	if planResource.IfNotExists.ValueBool() {
+		responseData, exists, err = r.findExistingObject(ctx, &planResource, data, requestOpt)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("if_not_exists"), "Existence check error", fmt.Sprintf("The tenant can't be looked up before its creation: %s", r.requestError(ctx, err)))
+			return
+		}
+		if exists {
+			tflog.Info(ctx, "Adopting the existing tenant instead of creating it")
+		}
+	}
+	if !exists {
+		responseData, locationPath, responseHeader, err = r.createObject(ctx, planResource.Path.ValueString(), data, requestOpt, planResource.UseLocationAsPath.ValueBool(), planResource.identifierKey(), !planResource.SkipReadBack.ValueBool())
+		if err != nil {
+			resp.Diagnostics.AddError("Create request error", fmt.Sprintf("Creation request returned the error: %s", r.requestError(ctx, err)))
+			return
+		}
+	}
+	headerId := ""
+	if !planResource.IdHeader.IsNull() {
+		headerId = responseHeader.Get(planResource.IdHeader.ValueString())
+	}
+	if headerId != "" {
+		planResource.Id = types.StringValue(headerId)
+	}
+	planResource.Location = types.StringNull()
+	if locationPath != "" {
+		planResource.Location = types.StringValue(locationPath)
+	}
+	if planResource.Operation != nil && !exists {
+		interval, timeout := planResource.Operation.durations()
+		err = r.waitForOperation(ctx, planResource.Operation, responseData, requestOpt, interval, timeout)
+		if err == nil {
+			/* The creation response is the operation, not the tenant */
+			responseData, err = r.readCreatedObject(ctx, &planResource, data, locationPath, requestOpt)
+		}
+		if err != nil {
+			resp.Diagnostics.AddWarning(
+				"Object may exist on the API server",
+				"The creation request succeeded but the tenant can't be read: the object may have been created. "+
+					"Check the API server before applying again, and import the object if it exists.",
+			)
+			resp.Diagnostics.AddAttributeError(path.Root("operation"), "Creation operation error", fmt.Sprintf("The created tenant can't be read: %s", r.requestError(ctx, err)))
+			return
+		}
+	}
+	if planResource.isRawResponse() {
+		if headerId == "" {
+			resp.Diagnostics.AddWarning(
+				"Object may exist on the API server",
+				"The creation request succeeded but its response has no id: the object may have been created. "+
+					"Check the API server before applying again, and import the object if it exists.",
+			)
+			resp.Diagnostics.AddError("Missing id in create API response", fmt.Sprintf("The creation response has no %s header", planResource.IdHeader.ValueString()))
+			return
+		}
+		planResource.setRawResponse(responseData)
+		planResource.LastUpdated = types.StringValue(planResource.formatLastUpdated(time.Now()))
+		resp.Diagnostics.Append(resp.State.Set(ctx, planResource)...)
+		return
+	}
+	responseData, err = r.tenantResponse(&planResource, responseData)
+	if err == nil && planResource.IdFromDataKey.IsNull() && headerId == "" {
+		_, err = objectId(ctx, responseData, r.client.IdAttribute)
+	}
+	if err != nil && !planResource.SkipReadBack.ValueBool() {
+		responseData, err = r.readCreatedObject(ctx, &planResource, data, locationPath, requestOpt)
+		if err == nil {
+			responseData, err = r.tenantResponse(&planResource, responseData)
+		}
+	}
 	if err != nil {
-		resp.Diagnostics.AddError("Create request error", fmt.Sprintf("Creation request returned the error: %s", err))
+		resp.Diagnostics.AddWarning(
+			"Object may exist on the API server",
+			"The creation request succeeded but its response has no id: the object may have been created. "+
+				"Check the API server before applying again, and import the object if it exists.",
+		)
+		resp.Diagnostics.AddError("Missing id in create API response", fmt.Sprintf("The created object can't be identified: %s", err))
 		return
 	}
-	if err := (&planResource).update_computed_fields(responseData); err != nil {
+	if err := (&planResource).update_computed_fields(ctx, responseData, r.client.IdAttribute); err != nil {
 		resp.Diagnostics.AddError("Missing attribute in create API response", fmt.Sprintf("Missing attribute in the creation response : %s", err))
 		return
 	}
 
-	planResource.LastUpdated = types.StringValue(time.Now().Format(time.RFC3339))
+	if planResource.WaitFor != nil {
+		interval, timeout := planResource.WaitFor.durations()
+		responseData, err = r.waitForCondition(ctx, &planResource, requestOpt, interval, timeout)
+		if err == nil {
+			err = (&planResource).update_computed_fields(ctx, responseData, r.client.IdAttribute)
+		}
+		if err != nil {
+			/* The tenant exists: saving it with an error taints it */
+			planResource.LastUpdated = types.StringValue(planResource.formatLastUpdated(time.Now()))
+			resp.Diagnostics.Append(resp.State.Set(ctx, planResource)...)
+			resp.Diagnostics.AddAttributeError(path.Root("wait_for"), "Wait for condition error", fmt.Sprintf("The created tenant is not ready: %s", r.requestError(ctx, err)))
+			return
+		}
+	}
+
+	planResource.LastUpdated = types.StringValue(planResource.formatLastUpdated(time.Now()))
 
 	// Set state to fully populated data
 	resp.Diagnostics.Append(resp.State.Set(ctx, planResource)...)
@@ -147,16 +639,69 @@ func (r *idhubTenantResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
-	path := strings.TrimRight(stateResource.Path.ValueString(), "/") + "?identifier=" + stateResource.Tenant.ValueString()
-	responseData, err := r.client.SendRequest("GET", path, "")
-	if err != nil {
-		resp.Diagnostics.AddError("Read request error", fmt.Sprintf("Read request returned the error: %s on the path: %s", err, path))
+	found, diags := r.readTenant(ctx, &stateResource)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := (&stateResource).update_computed_fields(responseData); err != nil {
-		resp.Diagnostics.AddError("Missing attribute in read API response", fmt.Sprintf("Missing attribute in the read response : %s", err))
+	if !found {
+		resp.State.RemoveResource(ctx)
 		return
 	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, stateResource)...)
+}
+
+// readTenant reads the tenant of the model and refreshes its computed fields,
+// within the read timeout. found is false when the response matches the
+// not_found_predicate.
+func (r *idhubTenantResource) readTenant(ctx context.Context, m *idhubTenantResourceModel) (bool, diag.Diagnostics) {
+	readTimeout, diags := m.Timeouts.Read(ctx, 0)
+	if diags.HasError() {
+		return false, diags
+	}
+	readCtx, cancel := withTimeout(ctx, readTimeout)
+	defer cancel()
+
+	requestOpt, optDiags := m.requestOpt(ctx)
+	diags.Append(optDiags...)
+	if diags.HasError() {
+		return false, diags
+	}
+
+	readPath, err := r.objectReadPath(m)
+	if err != nil {
+		diags.AddAttributeError(path.Root("read_path"), "Invalid read path", err.Error())
+		return false, diags
+	}
+	responseData, err := r.client.SendJsonRequestWithOpt(readCtx, "GET", readPath, m.ReadData.ValueString(), requestOpt.ForOperation(apiclient.OperationRead))
+	if err != nil {
+		diags.AddError("Read request error", fmt.Sprintf("Read request returned the error: %s on the path: %s", r.requestError(ctx, err), readPath))
+		return false, diags
+	}
+	if m.isRawResponse() {
+		m.setRawResponse(responseData)
+		return true, diags
+	}
+	if m.NotFoundPredicate != nil {
+		notFound, err := m.NotFoundPredicate.toJsonPredicate().Match(responseData)
+		if err != nil {
+			diags.AddError("Read response error", fmt.Sprintf("The not_found_predicate can't be evaluated on the read response: %s", err))
+			return false, diags
+		}
+		if notFound {
+			return false, diags
+		}
+	}
+	responseData, err = r.tenantResponse(m, responseData)
+	if err != nil {
+		diags.AddError("Read response error", fmt.Sprintf("The read response can't be transformed: %s", err))
+		return false, diags
+	}
+	if err := m.update_computed_fields(ctx, responseData, r.client.IdAttribute); err != nil {
+		diags.AddError("Missing attribute in read API response", fmt.Sprintf("Missing attribute in the read response : %s", err))
+		return false, diags
+	}
+	return true, diags
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
@@ -169,14 +714,58 @@ func (r *idhubTenantResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
-	planResource.LastUpdated = types.StringValue(time.Now().Format(time.RFC3339))
+	if planResource.ComputedValues.IsUnknown() {
+		/* computed_keys changed: read the values of the new keys, the read_path placeholders taking the previous ones */
+		var stateResource idhubTenantResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &stateResource)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		planResource.ComputedValues = stateResource.ComputedValues
+		found, diags := r.readTenant(ctx, &planResource)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if !found {
+			resp.Diagnostics.AddError("Read response error", "The tenant matches the not_found_predicate: its computed_values can't be read")
+			return
+		}
+	}
+
+	planResource.LastUpdated = types.StringValue(planResource.formatLastUpdated(time.Now()))
 	state := idhubTenantResourceModel{
-		Headers:        planResource.Headers,
-		LastUpdated:    planResource.LastUpdated,
-		Id:             planResource.Id,
-		Tenant:         planResource.Tenant,
-		RepoNamePrefix: planResource.RepoNamePrefix,
-		Path:           planResource.Path,
+		Headers:           planResource.Headers,
+		LastUpdated:       planResource.LastUpdated,
+		Id:                planResource.Id,
+		Tenant:            planResource.Tenant,
+		RepoNamePrefix:    planResource.RepoNamePrefix,
+		Path:              planResource.Path,
+		NotFoundPredicate: planResource.NotFoundPredicate,
+		ComputedKeys:      planResource.ComputedKeys,
+		ComputedValues:    planResource.ComputedValues,
+		Timeouts:          planResource.Timeouts,
+		SuppressHeaders:   planResource.SuppressHeaders,
+		ReadBackKey:       planResource.ReadBackKey,
+		TimeFormat:        planResource.TimeFormat,
+		SelectSubtree:     planResource.SelectSubtree,
+		ReadData:          planResource.ReadData,
+		SelectElement:     planResource.SelectElement,
+		CanonicalizeData:  planResource.CanonicalizeData,
+		IdFromDataKey:     planResource.IdFromDataKey,
+		ReadPath:          planResource.ReadPath,
+		UseLocationAsPath: planResource.UseLocationAsPath,
+		Location:          planResource.Location,
+		WaitFor:           planResource.WaitFor,
+		Operation:         planResource.Operation,
+		IdHeader:          planResource.IdHeader,
+		IdentifierKey:     planResource.IdentifierKey,
+		ContentType:       planResource.ContentType,
+		ResponseFormat:    planResource.ResponseFormat,
+		Response:          planResource.Response,
+		IfNotExists:       planResource.IfNotExists,
+		TrimResponse:      planResource.TrimResponse,
+		SkipReadBack:      planResource.SkipReadBack,
 		//omit Data
 	}
 
@@ -188,33 +777,32 @@ func (r *idhubTenantResource) Update(ctx context.Context, req resource.UpdateReq
 	}
 }
 
-// Delete deletes the resource and removes the Terraform state on success.
+// Delete removes the tenant from the Terraform state only: no request is sent,
+// the tenant is kept on the API server.
 func (r *idhubTenantResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 }
 
 func (r *idhubTenantResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	idParts := strings.Split(req.ID, ",")
-
-	if len(idParts) != 2 || idParts[0] == "" || idParts[1] == "" {
-		resp.Diagnostics.AddError(
-			"Unexpected Import Identifier",
-			fmt.Sprintf("Expected import identifier with format: path,tenant. Got: %q", req.ID),
-		)
+	tenantPath, tenantName, err := parseImportId(req.ID, r.client.DefaultPath)
+	if err != nil {
+		resp.Diagnostics.AddError("Unexpected Import Identifier", fmt.Sprintf("%s. Got: %q", err, req.ID))
 		return
 	}
 
-	tenantPath := idParts[0]
-	tenantName := idParts[1]
-
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), tenantPath)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("tenant"), tenantName)...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("last_updated"), time.Now().Format(time.RFC3339))...)
+	/* time_format is not known on import: last_updated is set by the next apply */
 
-	requestPath := strings.TrimRight(tenantPath, "/") + "?identifier=" + tenantName
+	requestPath := r.tenantReadPath(tenantPath, tenantName)
 	//Get data from API
-	responseData, err := r.client.SendRequest("GET", requestPath, "")
+	responseData, err := r.client.SendJsonRequestWithOpt(ctx, "GET", requestPath, "", &apiclient.RequestOpt{Operation: apiclient.OperationRead})
+	if err != nil {
+		resp.Diagnostics.AddError("Import request error", fmt.Sprintf("Import request returned the error: %s on the path: %s", r.requestError(ctx, err), requestPath))
+		return
+	}
+	responseData, err = r.client.ResponseAtRoot(responseData)
 	if err != nil {
-		resp.Diagnostics.AddError("Import request error", fmt.Sprintf("Import request returned the error: %s on the path: %s", err, requestPath))
+		resp.Diagnostics.AddError("Import request error", fmt.Sprintf("The tenant can't be read from the API response: %s", err))
 		return
 	}
 	//Delete the array, to have only the object
@@ -224,9 +812,9 @@ func (r *idhubTenantResource) ImportState(ctx context.Context, req resource.Impo
 		return
 	}
 
-	id, ok := mapData["id"].(string)
-	if !ok {
-		resp.Diagnostics.AddError("Missing attribute in import API response", "Missing id attribute or it is not a string")
+	id, err := objectId(ctx, responseData, r.client.IdAttribute)
+	if err != nil {
+		resp.Diagnostics.AddError("Missing attribute in import API response", fmt.Sprintf("Missing id attribute: %s", err))
 		return
 	}
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
@@ -238,6 +826,23 @@ func (r *idhubTenantResource) ImportState(ctx context.Context, req resource.Impo
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("repo_name_prefix"), repoNamePrefix)...)
 }
 
+// parseImportId splits the import identifier, path,tenant or only the tenant
+// when a default path is set.
+func parseImportId(id string, defaultPath string) (string, string, error) {
+	idParts := strings.Split(id, ",")
+
+	switch {
+	case len(idParts) == 1 && idParts[0] != "" && defaultPath != "":
+		return defaultPath, idParts[0], nil
+	case len(idParts) == 2 && idParts[0] != "" && idParts[1] != "":
+		return idParts[0], idParts[1], nil
+	case len(idParts) == 1 && defaultPath == "":
+		return "", "", fmt.Errorf("expected import identifier with format: path,tenant (or tenant when the provider default_path is set)")
+	default:
+		return "", "", fmt.Errorf("expected import identifier with format: path,tenant or tenant")
+	}
+}
+
 // Configure adds the provider configured client to the resource.
 func (r *idhubTenantResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 
@@ -261,21 +866,403 @@ func (r *idhubTenantResource) Configure(_ context.Context, req resource.Configur
 	r.url = client.Uri
 }
 
-func (m *idhubTenantResourceModel) update_computed_fields(jsonData string) error {
+// timeFormats maps the supported time_format values to their layout.
+var timeFormats = map[string]string{
+	"RFC3339": time.RFC3339,
+	"RFC850":  time.RFC850,
+	"RFC1123": time.RFC1123,
+}
+
+// timeFormatNames returns the supported time_format values, sorted.
+func timeFormatNames() []string {
+	names := make([]string, 0, len(timeFormats))
+	for name := range timeFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatLastUpdated formats t for last_updated, in RFC3339 unless time_format is set.
+func (m *idhubTenantResourceModel) formatLastUpdated(t time.Time) string {
+	layout, ok := timeFormats[m.TimeFormat.ValueString()]
+	if !ok {
+		layout = time.RFC3339
+	}
+	return t.Format(layout)
+}
+
+// tenantResponse returns the part of the API response holding the tenant: the
+// response at the provider response_root, transformed by the resource.
+func (r *idhubTenantResource) tenantResponse(m *idhubTenantResourceModel, jsonData string) (string, error) {
+	jsonData, err := r.client.ResponseAtRoot(jsonData)
+	if err != nil {
+		return "", err
+	}
+	return m.transformResponse(jsonData)
+}
+
+// transformResponse applies select_element and select_subtree to the API
+// response.
+func (m *idhubTenantResourceModel) transformResponse(jsonData string) (string, error) {
+	if m.SelectElement != nil {
+		var err error
+		if jsonData, err = m.SelectElement.toJsonPredicate().SelectElement(jsonData); err != nil {
+			return "", err
+		}
+	}
+	if m.SelectSubtree.IsNull() {
+		return jsonData, nil
+	}
+	transform := &apiclient.ResponseTransform{SelectSubtree: m.SelectSubtree.ValueString()}
+	return transform.Apply(jsonData)
+}
+
+// requestOpt returns the per-request settings of the resource.
+func (m *idhubTenantResourceModel) requestOpt(ctx context.Context) (*apiclient.RequestOpt, diag.Diagnostics) {
+	var suppressHeaders []string
+	diags := m.SuppressHeaders.ElementsAs(ctx, &suppressHeaders, false)
+
+	return &apiclient.RequestOpt{
+		SuppressHeaders: suppressHeaders,
+		ContentType:     m.ContentType.ValueString(),
+		RawResponse:     m.isRawResponse(),
+		TrimResponse:    m.trimResponse(),
+	}, diags
+}
+
+func (p *jsonPredicateModel) toJsonPredicate() *apiclient.JsonPredicate {
+	return &apiclient.JsonPredicate{
+		Path:  p.Path.ValueString(),
+		Value: p.Value.ValueString(),
+	}
+}
+
+// durations returns the interval and timeout of wait_for, or their defaults.
+func (w *waitForModel) durations() (time.Duration, time.Duration) {
+	return pollDurations(w.Interval, w.Timeout)
+}
+
+// durations returns the interval and timeout of operation, or their defaults.
+func (o *operationModel) durations() (time.Duration, time.Duration) {
+	return pollDurations(o.Interval, o.Timeout)
+}
+
+// pollDurations returns the polling interval and timeout set in seconds, or
+// their defaults when null.
+func pollDurations(intervalSeconds types.Int64, timeoutSeconds types.Int64) (time.Duration, time.Duration) {
+	interval, timeout := defaultWaitForInterval, defaultWaitForTimeout
+	if !intervalSeconds.IsNull() {
+		interval = time.Duration(intervalSeconds.ValueInt64()) * time.Second
+	}
+	if !timeoutSeconds.IsNull() {
+		timeout = time.Duration(timeoutSeconds.ValueInt64()) * time.Second
+	}
+	return interval, timeout
+}
+
+// waitForOperation polls the status of the operation of the creation response
+// every interval until it matches the success condition. The failure
+// condition, a read error or the timeout fail with the last status.
+func (r *idhubTenantResource) waitForOperation(ctx context.Context, operation *operationModel, createResponse string, requestOpt *apiclient.RequestOpt, interval time.Duration, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	operationId, err := apiclient.GetNestedKeyValue(createResponse, operation.IdPath.ValueString())
+	if err != nil || operationId == "" {
+		return fmt.Errorf("the creation response has no operation id at %s: %v", operation.IdPath.ValueString(), err)
+	}
+	statusPath, err := apiclient.ExpandPathTemplate(operation.StatusPath.ValueString(), map[string]string{"op_id": operationId})
+	if err != nil {
+		return err
+	}
+	status := "none"
+	for {
+		responseData, err := r.client.SendJsonRequestWithOpt(ctx, "GET", statusPath, "", requestOpt.ForOperation(apiclient.OperationRead))
+		if err != nil && ctx.Err() != nil {
+			return fmt.Errorf("the operation %s is not complete after %s, its last status: %s", operationId, timeout, status)
+		}
+		if err != nil {
+			return err
+		}
+		status = responseData
+		if operation.Failure != nil {
+			failed, err := operation.Failure.toJsonPredicate().Match(responseData)
+			if err != nil {
+				return err
+			}
+			if failed {
+				return fmt.Errorf("the operation %s failed, its status: %s", operationId, status)
+			}
+		}
+		succeeded, err := operation.Success.toJsonPredicate().Match(responseData)
+		if err != nil {
+			return err
+		}
+		if succeeded {
+			return nil
+		}
+		tflog.Debug(ctx, "Waiting for the creation operation", map[string]interface{}{"operation_id": operationId, "status": status})
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("the operation %s is not complete after %s, its last status: %s", operationId, timeout, status)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// waitForCondition reads the tenant of the model every interval until it
+// matches the wait_for condition, and returns the matching tenant. A read
+// error fails immediately; on timeout the error has the last observed value.
+func (r *idhubTenantResource) waitForCondition(ctx context.Context, m *idhubTenantResourceModel, requestOpt *apiclient.RequestOpt, interval time.Duration, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	readPath, err := r.objectReadPath(m)
+	if err != nil {
+		return "", err
+	}
+	condition := &apiclient.JsonPredicate{Path: m.WaitFor.Path.ValueString(), Value: m.WaitFor.Value.ValueString()}
+	observed := "no value"
+	for {
+		responseData, err := r.client.SendJsonRequestWithOpt(ctx, "GET", readPath, m.ReadData.ValueString(), requestOpt.ForOperation(apiclient.OperationRead))
+		if err != nil && ctx.Err() != nil {
+			return "", fmt.Errorf("the value at %s is still %s after %s", condition.Path, observed, timeout)
+		}
+		if err == nil {
+			responseData, err = r.tenantResponse(m, responseData)
+		}
+		if err != nil {
+			return "", err
+		}
+		matched, err := condition.Match(responseData)
+		if err != nil {
+			return "", err
+		}
+		if matched {
+			return responseData, nil
+		}
+		if value, found, _ := apiclient.GetJsonAtPath(responseData, condition.Path); found {
+			observed = value
+		} else {
+			observed = "missing"
+		}
+		tflog.Debug(ctx, "Waiting for the tenant condition", map[string]interface{}{"path": condition.Path, "value": observed})
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("the value at %s is still %s after %s", condition.Path, observed, timeout)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// createObject sends the creation request and returns the JSON of the created object
+// and, with useLocation or on a redirect (e.g. a 303 See Other expected with
+// follow_redirects unset), its path given by the Location header of the response.
+// When the API answers without content (e.g. 204 No Content) and create_returns_object
+// is not set, or on a redirect, the object is read back at this path, or using the
+// identifier sent in the data at identifierKey, unless readBack is false. The headers
+// of the creation response are returned too.
+func (r *idhubTenantResource) createObject(ctx context.Context, tenantPath string, data string, requestOpt *apiclient.RequestOpt, useLocation bool, identifierKey string, readBack bool) (string, string, http.Header, error) {
+	createOpt := requestOpt.ForOperation(apiclient.OperationCreate)
+	createOpt.ResponseHeader = http.Header{}
+	responseData, err := r.client.SendRequestWithOpt(ctx, "POST", tenantPath, data, createOpt)
+	if err != nil {
+		return "", "", nil, err
+	}
+	var locationPath string
+	/* A POST-redirect-GET API answers with the Location of the created object */
+	redirected := apiclient.IsRedirect(createOpt.ResponseStatus) && createOpt.ResponseHeader.Get("Location") != ""
+	if useLocation || redirected {
+		locationPath, err = r.client.LocationPath(tenantPath, createOpt.ResponseHeader.Get("Location"))
+		if err != nil {
+			return "", "", nil, fmt.Errorf("the path of the created object can't be read: %w", err)
+		}
+	}
+	if !apiclient.IsEmptyResponse(responseData) || createOpt.RawResponse {
+		return responseData, locationPath, createOpt.ResponseHeader, nil
+	}
+	if r.client.CreateReturnsObject && !redirected {
+		return "", "", nil, fmt.Errorf("the creation response is empty while create_returns_object is set")
+	}
+	if !readBack {
+		return "", "", nil, fmt.Errorf("the creation response is empty while skip_read_after_create is set")
+	}
+
+	if locationPath != "" {
+		responseData, err = r.client.SendJsonRequestWithOpt(ctx, "GET", locationPath, "", requestOpt.ForOperation(apiclient.OperationRead))
+	} else {
+		responseData, err = r.readBackObject(ctx, tenantPath, data, identifierKey, requestOpt)
+	}
+	if err != nil {
+		return "", "", nil, fmt.Errorf("the creation response is empty: %w", err)
+	}
+	return responseData, locationPath, createOpt.ResponseHeader, nil
+}
+
+// readCreatedObject reads the tenant created with data when the creation
+// response doesn't identify it: at locationPath when set, by its id taken
+// from the data with id_from_data_key, or by the value of read_back_key.
+func (r *idhubTenantResource) readCreatedObject(ctx context.Context, m *idhubTenantResourceModel, data string, locationPath string, requestOpt *apiclient.RequestOpt) (string, error) {
+	readOpt := requestOpt.ForOperation(apiclient.OperationRead)
+	switch {
+	case locationPath != "":
+		return r.client.SendJsonRequestWithOpt(ctx, "GET", locationPath, "", readOpt)
+	case !m.IdFromDataKey.IsNull():
+		return r.client.SendJsonRequestWithOpt(ctx, "GET", r.tenantReadPath(m.Path.ValueString(), m.Id.ValueString()), "", readOpt)
+	default:
+		return r.readBackObject(ctx, m.Path.ValueString(), data, m.ReadBackKey.ValueString(), requestOpt)
+	}
+}
+
+// findExistingObject reads the tenant of data by the value of its identifier
+// key and reports whether it exists: a 404, an empty array or a response
+// matching not_found_predicate means it doesn't.
+func (r *idhubTenantResource) findExistingObject(ctx context.Context, m *idhubTenantResourceModel, data string, requestOpt *apiclient.RequestOpt) (string, bool, error) {
+	responseData, err := r.readBackObject(ctx, m.Path.ValueString(), data, m.identifierKey(), requestOpt)
+	if apiclient.StatusCode(err) == http.StatusNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if empty, err := apiclient.JsonEqual(responseData, "[]"); err != nil || empty || apiclient.IsEmptyResponse(responseData) {
+		return "", false, err
+	}
+	if m.NotFoundPredicate != nil {
+		notFound, err := m.NotFoundPredicate.toJsonPredicate().Match(responseData)
+		if err != nil || notFound {
+			return "", false, err
+		}
+	}
+	return responseData, true, nil
+}
+
+// readBackObject reads the object created with data back by the value of its
+// key naturalKey.
+func (r *idhubTenantResource) readBackObject(ctx context.Context, tenantPath string, data string, naturalKey string, requestOpt *apiclient.RequestOpt) (string, error) {
+	if naturalKey == "" {
+		return "", fmt.Errorf("no key is set to read the object back")
+	}
+	tenant, err := apiclient.GetKeyValue(data, naturalKey)
+	if err != nil {
+		return "", fmt.Errorf("the key %s can't be read from the data: %w", naturalKey, err)
+	}
+	return r.client.SendJsonRequestWithOpt(ctx, "GET", r.tenantReadPath(tenantPath, tenant), "", requestOpt.ForOperation(apiclient.OperationRead))
+}
+
+// requestError returns the detail of a request error: the provider
+// status_messages entry of its status code, the raw error being logged at
+// debug level, or the error itself.
+func (r *idhubTenantResource) requestError(ctx context.Context, err error) string {
+	message, ok := r.client.StatusMessage(err)
+	if !ok {
+		return err.Error()
+	}
+	tflog.Debug(ctx, "API error: "+err.Error())
+	return fmt.Sprintf("%s (status %d)", message, apiclient.StatusCode(err))
+}
+
+// withTimeout derives a context from ctx bound to the operation timeout. The
+// client's global timeout still bounds each request, the earlier deadline
+// applying.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// objectId returns the id of the object of the API response, at idAttribute
+// or, when empty, at the first of the apiclient.DefaultIdAttributes present,
+// logging the detected attribute.
+func objectId(ctx context.Context, jsonData string, idAttribute string) (string, error) {
+	id, attribute, err := apiclient.ObjectId(jsonData, idAttribute)
+	if err != nil {
+		return "", err
+	}
+	if idAttribute == "" {
+		tflog.Debug(ctx, "Detected the id attribute of the object", map[string]interface{}{"id_attribute": attribute})
+	}
+	return id, nil
+}
+
+// tenantReadPath returns the path used to read a tenant by its identifier,
+// passed as the configured query parameter.
+func (r *idhubTenantResource) tenantReadPath(tenantPath string, tenant string) string {
+	query := url.Values{r.client.IdentifierQueryParam: []string{tenant}}
+	return strings.TrimRight(tenantPath, "/") + "?" + query.Encode()
+}
+
+// objectReadPath returns the path used to read the tenant of the model: its
+// read_path template or its location when set, or by its id when taken from
+// the data, by its tenant name otherwise.
+func (r *idhubTenantResource) objectReadPath(m *idhubTenantResourceModel) (string, error) {
+	if !m.ReadPath.IsNull() {
+		return apiclient.ExpandPathTemplate(m.ReadPath.ValueString(), m.pathTemplateValues())
+	}
+	if m.UseLocationAsPath.ValueBool() && m.Location.ValueString() != "" {
+		return m.Location.ValueString(), nil
+	}
+	if !m.IdFromDataKey.IsNull() || m.isRawResponse() {
+		return r.tenantReadPath(m.Path.ValueString(), m.Id.ValueString()), nil
+	}
+	return r.tenantReadPath(m.Path.ValueString(), m.Tenant.ValueString()), nil
+}
+
+// pathTemplateValues returns the values of the read_path placeholders: the
+// computed values, overridden by the id, tenant and repo_name_prefix.
+func (m *idhubTenantResourceModel) pathTemplateValues() map[string]string {
+	values := map[string]string{}
+	for name, element := range m.ComputedValues.Elements() {
+		if value, ok := element.(types.String); ok {
+			values[name] = value.ValueString()
+		}
+	}
+	values["id"] = m.Id.ValueString()
+	values["tenant"] = m.Tenant.ValueString()
+	values["repo_name_prefix"] = m.RepoNamePrefix.ValueString()
+	return values
+}
+
+// identifierKey returns the key of the API responses holding the tenant name:
+// identifier_key, or identifier when not set.
+func (m *idhubTenantResourceModel) identifierKey() string {
+	if m.IdentifierKey.ValueString() == "" {
+		return "identifier"
+	}
+	return m.IdentifierKey.ValueString()
+}
+
+// update_computed_fields reads the computed attributes from the API response,
+// the id at idAttribute or detected when empty (see objectId), the tenant at
+// identifier_key. The id taken from the data with id_from_data_key, or from
+// the id_header header, is kept.
+func (m *idhubTenantResourceModel) update_computed_fields(ctx context.Context, jsonData string, idAttribute string) error {
 	var id string
 	var tenant string
 	var repoNamePrefix string
 	var err error
 
-	id, err = apiclient.GetKeyValue(jsonData, "id")
+	id = m.Id.ValueString()
+	if m.IdFromDataKey.IsNull() && (m.IdHeader.IsNull() || id == "") {
+		id, err = objectId(ctx, jsonData, idAttribute)
+		if err != nil {
+			return err
+		}
+	}
+	tenant, err = apiclient.GetKeyValue(jsonData, m.identifierKey())
 	if err != nil {
 		return err
 	}
-	tenant, err = apiclient.GetKeyValue(jsonData, "identifier")
+	repoNamePrefix, err = apiclient.GetKeyValue(jsonData, "repo_name_prefix")
 	if err != nil {
 		return err
 	}
-	repoNamePrefix, err = apiclient.GetKeyValue(jsonData, "repo_name_prefix")
+
+	computedValues, err := extractComputedValues(jsonData, m.ComputedKeys)
 	if err != nil {
 		return err
 	}
@@ -283,5 +1270,57 @@ func (m *idhubTenantResourceModel) update_computed_fields(jsonData string) error
 	m.Id = types.StringValue(id)
 	m.Tenant = types.StringValue(tenant)
 	m.RepoNamePrefix = types.StringValue(repoNamePrefix)
+	m.ComputedValues = computedValues
+	m.Response = types.StringNull()
 	return nil
 }
+
+// isRawResponse returns whether the API responses are kept as is, not parsed
+// as JSON.
+func (m *idhubTenantResourceModel) isRawResponse() bool {
+	return m.ResponseFormat.ValueString() == responseFormatRaw
+}
+
+// trimResponse returns whether the whitespace around the API responses is
+// removed: trim_response when set, else for the JSON responses only.
+func (m *idhubTenantResourceModel) trimResponse() bool {
+	if m.TrimResponse.IsNull() {
+		return !m.isRawResponse()
+	}
+	return m.TrimResponse.ValueBool()
+}
+
+// setRawResponse keeps the API response of response_format raw. The values
+// read from JSON responses are null.
+func (m *idhubTenantResourceModel) setRawResponse(body string) {
+	m.Response = types.StringValue(body)
+	m.Tenant = types.StringNull()
+	m.RepoNamePrefix = types.StringNull()
+	m.ComputedValues = types.MapNull(types.StringType)
+}
+
+// extractComputedValues returns the values found in the JSON data at the paths of computedKeys.
+func extractComputedValues(jsonData string, computedKeys types.Map) (types.Map, error) {
+	if computedKeys.IsNull() || computedKeys.IsUnknown() {
+		return types.MapNull(types.StringType), nil
+	}
+
+	values := make(map[string]attr.Value)
+	for name, element := range computedKeys.Elements() {
+		path, ok := element.(types.String)
+		if !ok {
+			return types.MapNull(types.StringType), fmt.Errorf("the path of the computed key %s is not a string", name)
+		}
+		value, err := apiclient.GetNestedKeyValue(jsonData, path.ValueString())
+		if err != nil {
+			return types.MapNull(types.StringType), fmt.Errorf("computed key %s: %w", name, err)
+		}
+		values[name] = types.StringValue(value)
+	}
+
+	result, diags := types.MapValue(types.StringType, values)
+	if diags.HasError() {
+		return types.MapNull(types.StringType), fmt.Errorf("the computed values can't be converted into a map: %v", diags)
+	}
+	return result, nil
+}
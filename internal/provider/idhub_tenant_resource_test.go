@@ -1,11 +1,26 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
 	"github.com/hashicorp/terraform-plugin-testing/plancheck"
@@ -251,3 +266,1656 @@ func TestAccIdhubTenantResource_import(t *testing.T) {
 		},
 	})
 }
+
+func TestAccIdhubTenantResource_unknownData(t *testing.T) {
+	resourceFulleName := idhubTenantResourceName + ".api_data"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccIdhubTenantPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.RequireAbove(tfversion.Version1_11_0),
+		},
+		Steps: []resource.TestStep{
+			// The data is unknown at plan time: the output of terraform_data is computed.
+			{
+				Config: providerConfig + `
+resource "terraform_data" "tenant" {
+  input = "tenant_9"
+}
+
+resource "` + idhubTenantResourceName + `" "api_data" {
+  path = "/api/objects"
+  data = jsonencode({
+    identifier       = terraform_data.tenant.output
+    id               = "9"
+    repo_name_prefix = "tenant_9-qmxkd"
+  })
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(resourceFulleName, tfjsonpath.New("id"), knownvalue.StringExact("9")),
+					statecheck.ExpectKnownValue(resourceFulleName, tfjsonpath.New("tenant"), knownvalue.StringExact("tenant_9")),
+				},
+			},
+		},
+	})
+}
+
+func TestAccIdhubTenantResource_numericPrecision(t *testing.T) {
+	resourceFulleName := idhubTenantResourceName + ".numbers"
+	/* Numbers float64 can't hold exactly: a lossy decoding changes them on every read */
+	config := providerConfig + generateIdhubTenantResource("numbers",
+		`{"identifier":"tenant_48","id":"48","repo_name_prefix":"tenant_48-hxnro","big":12345678901234567890,"ratio":0.1000000000000000055,"count":1000000}`,
+		map[string]any{"computed_keys": `{ big = "big", ratio = "ratio", count = "count" }`})
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccIdhubTenantPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.RequireAbove(tfversion.Version1_11_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(resourceFulleName, tfjsonpath.New("computed_values"), knownvalue.MapExact(map[string]knownvalue.Check{
+						"big":   knownvalue.StringExact("12345678901234567890"),
+						"ratio": knownvalue.StringExact("0.1000000000000000055"),
+						"count": knownvalue.StringExact("1000000"),
+					})),
+				},
+			},
+			// A second plan, after another refresh, has no diff.
+			{
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccIdhubTenantResource_dependentPath(t *testing.T) {
+	childFullName := idhubTenantResourceName + ".child"
+	parentsConfig := `
+resource "` + idhubTenantResourceName + `" "parent_1" {
+  path = "/api/objects"
+  data = jsonencode({
+    identifier       = "tenant_12"
+    id               = "12"
+    repo_name_prefix = "tenant_12-bxkqa"
+  })
+}
+
+resource "` + idhubTenantResourceName + `" "parent_2" {
+  path = "/api/objects"
+  data = jsonencode({
+    identifier       = "tenant_13"
+    id               = "13"
+    repo_name_prefix = "tenant_13-jfuzw"
+  })
+}
+`
+	childConfig := func(parent string) string {
+		return `
+resource "` + idhubTenantResourceName + `" "child" {
+  path = "/api/objects/${` + idhubTenantResourceName + `.` + parent + `.id}/children"
+  data = jsonencode({
+    identifier       = "child_121"
+    id               = "121"
+    repo_name_prefix = "child_121-wnzte"
+  })
+}
+`
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccIdhubTenantPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.RequireAbove(tfversion.Version1_11_0),
+		},
+		Steps: []resource.TestStep{
+			// The child path holds the parent id, unknown until the parent is created.
+			{
+				Config: providerConfig + parentsConfig + childConfig("parent_1"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownValue(childFullName, tfjsonpath.New("path")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(childFullName, tfjsonpath.New("path"), knownvalue.StringExact("/api/objects/12/children")),
+					statecheck.ExpectKnownValue(childFullName, tfjsonpath.New("id"), knownvalue.StringExact("121")),
+				},
+			},
+			// Another parent replaces the child, created under the new parent.
+			{
+				Config: providerConfig + parentsConfig + childConfig("parent_2"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction(childFullName, plancheck.ResourceActionReplace),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(childFullName, tfjsonpath.New("path"), knownvalue.StringExact("/api/objects/13/children")),
+					statecheck.ExpectKnownValue(childFullName, tfjsonpath.New("id"), knownvalue.StringExact("121")),
+				},
+			},
+		},
+	})
+}
+
+func TestIdhubTenantResource_validateUnknownData(t *testing.T) {
+	ctx := context.Background()
+	providerServer, err := createProviderServer(New("test")())
+	if err != nil {
+		t.Fatalf("Failed to create provider server: %s", err)
+	}
+	schemaResp, err := providerServer.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("GetProviderSchema returned an error: %s", err)
+	}
+	objectType, ok := schemaResp.ResourceSchemas[idhubTenantResourceName].ValueType().(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected resource schema type")
+	}
+
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+	}
+	values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+	values["data"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	config, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, values))
+	if err != nil {
+		t.Fatalf("The configuration can't be encoded: %s", err)
+	}
+
+	validateResp, err := providerServer.ValidateResourceConfig(ctx, &tfprotov6.ValidateResourceConfigRequest{
+		TypeName: idhubTenantResourceName,
+		Config:   &config,
+		ClientCapabilities: &tfprotov6.ValidateResourceConfigClientCapabilities{
+			WriteOnlyAttributesAllowed: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("ValidateResourceConfig returned an error: %s", err)
+	}
+	if hasError(validateResp.Diagnostics) {
+		t.Errorf("An unknown data should pass the validation: %v", validateResp.Diagnostics)
+	}
+}
+
+func TestIdhubTenantResource_createNoContent(t *testing.T) {
+	createdTenant := `{"identifier":"tenant_8","id":"8","repo_name_prefix":"tenant_8-kqwpe"}`
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && r.URL.Path == "/api/objects" && r.URL.Query().Get("identifier") == "tenant_8":
+			if _, err := w.Write([]byte("[" + createdTenant + "]")); err != nil {
+				t.Errorf("Error on sending read response: %s", err)
+			}
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+
+	responseData, _, _, err := r.createObject(context.Background(), "/api/objects", createdTenant, nil, false, "identifier", true)
+	if err != nil {
+		t.Fatalf("createObject returned an error on a 204 creation response: %s", err)
+	}
+	var model idhubTenantResourceModel
+	if err := model.update_computed_fields(context.Background(), responseData, ""); err != nil {
+		t.Fatalf("The computed fields can't be read from the read back object: %s", err)
+	}
+	if model.Id.ValueString() != "8" || model.Tenant.ValueString() != "tenant_8" {
+		t.Errorf("Unexpected computed fields after the read back: id=%s tenant=%s", model.Id, model.Tenant)
+	}
+
+	client.CreateReturnsObject = true
+	if _, _, _, err := r.createObject(context.Background(), "/api/objects", createdTenant, nil, false, "identifier", true); err == nil {
+		t.Error("createObject should fail on an empty creation response when create_returns_object is set")
+	}
+}
+
+func TestIdhubTenantResource_readBackObject(t *testing.T) {
+	createdTenant := `{"identifier":"tenant_9","id":"9","repo_name_prefix":"tenant_9-ozxle"}`
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == "/api/objects" && r.URL.Query().Get("identifier") == "tenant_9" {
+			if _, err := w.Write([]byte("[" + createdTenant + "]")); err != nil {
+				t.Errorf("Error on sending read response: %s", err)
+			}
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	data := `{"name":"tenant_9"}`
+
+	responseData, err := r.readBackObject(context.Background(), "/api/objects", data, "name", nil)
+	if err != nil {
+		t.Fatalf("readBackObject returned an error: %s", err)
+	}
+	if id, err := apiclient.GetKeyValue(responseData, "id"); err != nil || id != "9" {
+		t.Errorf("Unexpected id of the read back object: %s (%v)", id, err)
+	}
+
+	if _, err := r.readBackObject(context.Background(), "/api/objects", data, "", nil); err == nil {
+		t.Error("readBackObject should fail when no natural key is set")
+	}
+	if _, err := r.readBackObject(context.Background(), "/api/objects", data, "identifier", nil); err == nil {
+		t.Error("readBackObject should fail when the natural key is missing from the data")
+	}
+}
+
+func TestIdhubTenantResource_formatLastUpdated(t *testing.T) {
+	updated := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		timeFormat types.String
+		expected   string
+	}{
+		{types.StringNull(), "2024-06-01T12:00:00Z"},
+		{types.StringValue("RFC3339"), "2024-06-01T12:00:00Z"},
+		{types.StringValue("RFC850"), "Saturday, 01-Jun-24 12:00:00 UTC"},
+		{types.StringValue("RFC1123"), "Sat, 01 Jun 2024 12:00:00 UTC"},
+	}
+
+	for _, test := range tests {
+		model := idhubTenantResourceModel{TimeFormat: test.timeFormat}
+		if formatted := model.formatLastUpdated(updated); formatted != test.expected {
+			t.Errorf("formatLastUpdated with time_format %s = %s; want %s", test.timeFormat, formatted, test.expected)
+		}
+	}
+}
+
+func TestIdhubTenantResource_selectSubtree(t *testing.T) {
+	responseData := `[{"status":"ok","data":{"identifier":"tenant_1","id":"1","repo_name_prefix":"tenant_1-sxxlh"}}]`
+
+	model := idhubTenantResourceModel{SelectSubtree: types.StringValue("data")}
+	tenantData, err := model.transformResponse(responseData)
+	if err != nil {
+		t.Fatalf("transformResponse returned an error: %s", err)
+	}
+	if err := model.update_computed_fields(context.Background(), tenantData, ""); err != nil {
+		t.Fatalf("update_computed_fields returned an error on the selected subtree: %s", err)
+	}
+	if model.Id.ValueString() != "1" || model.Tenant.ValueString() != "tenant_1" {
+		t.Errorf("Unexpected computed fields: id=%s tenant=%s", model.Id, model.Tenant)
+	}
+
+	listData := `[{"identifier":"tenant_1","id":"1","repo_name_prefix":"tenant_1-sxxlh"},{"identifier":"tenant_10","id":"10","repo_name_prefix":"tenant_10-ghyxa"}]`
+	model = idhubTenantResourceModel{
+		SelectElement: &jsonPredicateModel{Path: types.StringValue("identifier"), Value: types.StringValue("tenant_10")},
+		SelectSubtree: types.StringNull(),
+	}
+	tenantData, err = model.transformResponse(listData)
+	if err != nil {
+		t.Fatalf("transformResponse returned an error with select_element: %s", err)
+	}
+	if err := model.update_computed_fields(context.Background(), tenantData, ""); err != nil || model.Id.ValueString() != "10" {
+		t.Errorf("The selected element has the id %s (%v); want 10", model.Id, err)
+	}
+
+	model = idhubTenantResourceModel{SelectSubtree: types.StringNull()}
+	if tenantData, err := model.transformResponse(responseData); err != nil || tenantData != responseData {
+		t.Errorf("transformResponse without select_subtree should return the response as is, got: %s (%v)", tenantData, err)
+	}
+}
+
+func TestParseImportId(t *testing.T) {
+	tests := []struct {
+		id           string
+		defaultPath  string
+		expectedPath string
+		expectedName string
+		fails        bool
+	}{
+		{"/api/objects,tenant_1", "", "/api/objects", "tenant_1", false},
+		{"/api/objects,tenant_1", "/tenants", "/api/objects", "tenant_1", false},
+		{"tenant_1", "/tenants", "/tenants", "tenant_1", false},
+		{"tenant_1", "", "", "", true},
+		{"/api/objects,", "/tenants", "", "", true},
+		{"a,b,c", "/tenants", "", "", true},
+		{"", "/tenants", "", "", true},
+	}
+
+	for _, test := range tests {
+		tenantPath, tenantName, err := parseImportId(test.id, test.defaultPath)
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseImportId(%q, %q) should return an error, got: %s,%s", test.id, test.defaultPath, tenantPath, tenantName)
+			}
+			continue
+		}
+		if err != nil || tenantPath != test.expectedPath || tenantName != test.expectedName {
+			t.Errorf("parseImportId(%q, %q) = %s, %s, %v; want %s, %s", test.id, test.defaultPath, tenantPath, tenantName, err, test.expectedPath, test.expectedName)
+		}
+	}
+}
+
+func TestIdhubTenantResource_computedValues(t *testing.T) {
+	responseData := `[{"identifier":"tenant_1","id":"1","repo_name_prefix":"tenant_1-sxxlh","meta":{"version":3,"owner":"team_a"}}]`
+	computedKeys := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"version": types.StringValue("meta.version"),
+		"owner":   types.StringValue("meta.owner"),
+	})
+
+	model := idhubTenantResourceModel{ComputedKeys: computedKeys}
+	if err := model.update_computed_fields(context.Background(), responseData, ""); err != nil {
+		t.Fatalf("update_computed_fields returned an error: %s", err)
+	}
+	expected := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"version": types.StringValue("3"),
+		"owner":   types.StringValue("team_a"),
+	})
+	if !model.ComputedValues.Equal(expected) {
+		t.Errorf("computed_values = %s; want %s", model.ComputedValues, expected)
+	}
+
+	model = idhubTenantResourceModel{ComputedKeys: types.MapNull(types.StringType)}
+	if err := model.update_computed_fields(context.Background(), responseData, ""); err != nil {
+		t.Fatalf("update_computed_fields returned an error: %s", err)
+	}
+	if !model.ComputedValues.IsNull() {
+		t.Errorf("computed_values should be null without computed_keys, got: %s", model.ComputedValues)
+	}
+
+	model = idhubTenantResourceModel{ComputedKeys: types.MapValueMust(types.StringType, map[string]attr.Value{
+		"missing": types.StringValue("meta.missing"),
+	})}
+	if err := model.update_computed_fields(context.Background(), responseData, ""); err == nil {
+		t.Error("update_computed_fields should fail when a computed key path is missing in the response")
+	}
+}
+
+func TestIdhubTenantResource_computedKeysUpdate(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/api/objects" || r.URL.Query().Get("identifier") != "tenant_46" {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"id":"46","identifier":"tenant_46","repo_name_prefix":"tenant_46-kqzrd","meta":{"version":2,"owner":"team_b"}}`)
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, IdentifierQueryParam: "identifier"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	mapType := tftypes.Map{ElementType: tftypes.String}
+	/* Returns the resource value with the computed_keys and computed_values maps, the other attributes null but the tenant ones */
+	resourceValue := func(computedKeys map[string]string, computedValues any) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+		}
+		values["id"] = tftypes.NewValue(tftypes.String, "46")
+		values["tenant"] = tftypes.NewValue(tftypes.String, "tenant_46")
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+		keys := map[string]tftypes.Value{}
+		for name, path := range computedKeys {
+			keys[name] = tftypes.NewValue(tftypes.String, path)
+		}
+		values["computed_keys"] = tftypes.NewValue(mapType, keys)
+		if stringValues, ok := computedValues.(map[string]string); ok {
+			elements := map[string]tftypes.Value{}
+			for name, value := range stringValues {
+				elements[name] = tftypes.NewValue(tftypes.String, value)
+			}
+			values["computed_values"] = tftypes.NewValue(mapType, elements)
+		} else {
+			values["computed_values"] = tftypes.NewValue(mapType, computedValues)
+		}
+		return tftypes.NewValue(objectType, values)
+	}
+
+	/* First step: computed_keys has the version only */
+	firstKeys := map[string]string{"version": "meta.version"}
+	firstValues := map[string]string{"version": "2"}
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(firstKeys, firstValues)}
+	stateValues := types.MapValueMust(types.StringType, map[string]attr.Value{"version": types.StringValue("2")})
+
+	/* Returns the computed_values planned from the state values, as kept by UseStateForUnknown */
+	planComputedValues := func(computedKeys map[string]string) types.Map {
+		plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(computedKeys, firstValues)}
+		modifierResp := &planmodifier.MapResponse{PlanValue: stateValues}
+		computedValuesPlanModifier{}.PlanModifyMap(ctx, planmodifier.MapRequest{
+			Path:       path.Root("computed_values"),
+			State:      state,
+			Plan:       plan,
+			StateValue: stateValues,
+			PlanValue:  stateValues,
+		}, modifierResp)
+		if modifierResp.Diagnostics.HasError() {
+			t.Fatalf("The computed_values plan modifier returned errors: %v", modifierResp.Diagnostics)
+		}
+		return modifierResp.PlanValue
+	}
+	if planned := planComputedValues(firstKeys); !planned.Equal(stateValues) {
+		t.Errorf("With unchanged computed_keys, computed_values should keep the state values, got: %s", planned)
+	}
+
+	/* Second step: computed_keys adds the owner */
+	secondKeys := map[string]string{"version": "meta.version", "owner": "meta.owner"}
+	if planned := planComputedValues(secondKeys); !planned.IsUnknown() {
+		t.Fatalf("With changed computed_keys, computed_values should be planned unknown, got: %s", planned)
+	}
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(secondKeys, tftypes.UnknownValue)}
+	updateResp := &fwresource.UpdateResponse{State: state}
+	r.Update(ctx, fwresource.UpdateRequest{Plan: plan, State: state}, updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("Update returned errors: %v", updateResp.Diagnostics)
+	}
+	var computedValues types.Map
+	updateResp.Diagnostics.Append(updateResp.State.GetAttribute(ctx, path.Root("computed_values"), &computedValues)...)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("Reading computed_values from the updated state returned errors: %v", updateResp.Diagnostics)
+	}
+	expected := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"version": types.StringValue("2"),
+		"owner":   types.StringValue("team_b"),
+	})
+	if !computedValues.Equal(expected) {
+		t.Errorf("computed_values = %s; want %s", computedValues, expected)
+	}
+}
+
+func TestIdhubTenantResource_idAttribute(t *testing.T) {
+	tests := []struct {
+		responseData string
+		idAttribute  string
+		expected     string
+	}{
+		{`{"id":"1","uuid":"u-1","identifier":"tenant_1","repo_name_prefix":"tenant_1-sxxlh"}`, "", "1"},
+		{`{"uuid":"u-1","name":"tenant_1","identifier":"tenant_1","repo_name_prefix":"tenant_1-sxxlh"}`, "", "u-1"},
+		{`{"_id":"m-1","identifier":"tenant_1","repo_name_prefix":"tenant_1-sxxlh"}`, "", "m-1"},
+		{`{"id":"1","uuid":"u-1","identifier":"tenant_1","repo_name_prefix":"tenant_1-sxxlh"}`, "uuid", "u-1"},
+	}
+	for _, test := range tests {
+		var model idhubTenantResourceModel
+		if err := model.update_computed_fields(context.Background(), test.responseData, test.idAttribute); err != nil {
+			t.Errorf("update_computed_fields(%s, %s) returned an error: %s", test.responseData, test.idAttribute, err)
+			continue
+		}
+		if model.Id.ValueString() != test.expected {
+			t.Errorf("update_computed_fields(%s, %s) set the id %s; want %s", test.responseData, test.idAttribute, model.Id, test.expected)
+		}
+	}
+
+	/* The configured id attribute is authoritative, even when a default one is present */
+	var model idhubTenantResourceModel
+	if err := model.update_computed_fields(context.Background(), `{"id":"1","identifier":"tenant_1","repo_name_prefix":"tenant_1-sxxlh"}`, "uuid"); err == nil {
+		t.Error("update_computed_fields should fail when the configured id attribute is missing")
+	}
+}
+
+func TestIdhubTenantResource_readPath(t *testing.T) {
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: "http://127.0.0.1:19092", Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	model := idhubTenantResourceModel{
+		Id:             types.StringValue("42"),
+		Tenant:         types.StringValue("tenant_1"),
+		RepoNamePrefix: types.StringValue("tenant_1-sxxlh"),
+		Path:           types.StringValue("/api/objects"),
+		ComputedValues: types.MapValueMust(types.StringType, map[string]attr.Value{
+			"region": types.StringValue("eu west"),
+			"id":     types.StringValue("ignored"),
+		}),
+	}
+
+	tests := []struct {
+		readPath string
+		expected string
+	}{
+		{"/tenants/{tenant}/things/{id}", "/tenants/tenant_1/things/42"},
+		{"/regions/{region}/repos/{repo_name_prefix}", "/regions/eu%20west/repos/tenant_1-sxxlh"},
+	}
+	for _, test := range tests {
+		model.ReadPath = types.StringValue(test.readPath)
+		readPath, err := r.objectReadPath(&model)
+		if err != nil {
+			t.Errorf("objectReadPath(%s) returned an error: %s", test.readPath, err)
+			continue
+		}
+		if readPath != test.expected {
+			t.Errorf("objectReadPath(%s) = %s; want %s", test.readPath, readPath, test.expected)
+		}
+	}
+
+	model.ReadPath = types.StringValue("/tenants/{tenant}/things/{thing}")
+	if _, err := r.objectReadPath(&model); err == nil || !strings.Contains(err.Error(), "the available ones are: id, region, repo_name_prefix, tenant") {
+		t.Errorf("objectReadPath should fail on an unknown placeholder, listing the available ones, got: %v", err)
+	}
+
+	model.ReadPath = types.StringNull()
+	if readPath, err := r.objectReadPath(&model); err != nil || readPath != "/api/objects?identifier=tenant_1" {
+		t.Errorf("Without read_path, objectReadPath() = %s, %v; want /api/objects?identifier=tenant_1", readPath, err)
+	}
+}
+
+func TestIdhubTenantResource_identifierQueryParam(t *testing.T) {
+	objects := map[string]map[string]any{
+		"9": {
+			"name":             "tenant_9",
+			"identifier":       "tenant_9",
+			"id":               "9",
+			"repo_name_prefix": "tenant_9-ymhta",
+		},
+	}
+	svr := fakeserver.NewFakeServer(19092, objects, true, false, "")
+	defer svr.Shutdown()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{
+		Uri:                  "http://127.0.0.1:19092",
+		Timeout:              2,
+		RateLimit:            10,
+		IdentifierQueryParam: "name",
+	})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+
+	readPath := r.tenantReadPath("/api/objects/", "tenant_9")
+	if readPath != "/api/objects?name=tenant_9" {
+		t.Errorf("tenantReadPath() = %s; want /api/objects?name=tenant_9", readPath)
+	}
+	responseData, err := client.SendJsonRequestWithOpt(context.Background(), "GET", readPath, "", nil)
+	if err != nil {
+		t.Fatalf("The read request returned an error: %s", err)
+	}
+	var model idhubTenantResourceModel
+	if err := model.update_computed_fields(context.Background(), responseData, ""); err != nil {
+		t.Fatalf("update_computed_fields returned an error: %s", err)
+	}
+	if model.Id.ValueString() != "9" {
+		t.Errorf("The tenant read with the name parameter has the id %s; want 9", model.Id)
+	}
+}
+
+func TestIdhubTenantResource_readData(t *testing.T) {
+	svr := fakeserver.NewFakeServer(19093, make(map[string]map[string]any), false, false, "")
+	svr.HandleMethods("/api/search", map[string]http.HandlerFunc{
+		"GET": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Content-Type") != "application/json" {
+				http.Error(w, "unexpected content type", http.StatusUnsupportedMediaType)
+				return
+			}
+			// Echo the search payload as the found tenant.
+			if _, err := io.Copy(w, r.Body); err != nil {
+				t.Errorf("Error on echoing the read body: %s", err)
+			}
+		},
+	})
+	svr.StartInBackground()
+	defer svr.Shutdown()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: "http://127.0.0.1:19093", Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	model := idhubTenantResourceModel{
+		ReadData: types.StringValue(`{"identifier":"tenant_1","id":"1","repo_name_prefix":"tenant_1-sxxlh"}`),
+	}
+
+	responseData, err := client.SendJsonRequestWithOpt(context.Background(), "GET", r.tenantReadPath("/api/search", "tenant_1"), model.ReadData.ValueString(), nil)
+	if err != nil {
+		t.Fatalf("The read request with a body returned an error: %s", err)
+	}
+	if err := model.update_computed_fields(context.Background(), responseData, ""); err != nil {
+		t.Fatalf("update_computed_fields returned an error on the echoed body: %s", err)
+	}
+	if model.Id.ValueString() != "1" {
+		t.Errorf("The echoed tenant has the id %s; want 1", model.Id)
+	}
+}
+
+func TestIdhubTenantResource_requestError(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/unauthorized":
+			http.Error(w, "token expired", http.StatusUnauthorized)
+		default:
+			http.Error(w, "no permission", http.StatusForbidden)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{
+		Uri:            svr.URL,
+		Timeout:        2,
+		RateLimit:      10,
+		StatusMessages: map[int]string{http.StatusUnauthorized: "Check the credentials"},
+	})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+
+	_, err = client.SendRequest("GET", "/api/unauthorized", "")
+	if message := r.requestError(context.Background(), err); message != "Check the credentials (status 401)" {
+		t.Errorf("requestError(401) = %q; want the status_messages entry", message)
+	}
+	_, err = client.SendRequest("GET", "/api/forbidden", "")
+	if message := r.requestError(context.Background(), err); message != err.Error() {
+		t.Errorf("requestError(403) = %q; want the generic error %q", message, err.Error())
+	}
+}
+
+func TestIdhubTenantResource_idFromDataKey(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	objects := map[string]string{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			name, err := apiclient.GetKeyValue(string(body), "name")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			/* The object is stored and returned as sent, without id */
+			objects[name] = string(body)
+			fmt.Fprint(w, string(body))
+		case r.Method == "GET" && r.URL.Path == "/api/objects":
+			object, ok := objects[r.URL.Query().Get("name")]
+			if !ok {
+				fmt.Fprint(w, "[]")
+				return
+			}
+			fmt.Fprint(w, "["+object+"]")
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, IdentifierQueryParam: "name"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	/* Returns the resource value with the given attributes, the others null or, when computed, unknown */
+	resourceValue := func(computed any, attributes map[string]string) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		for name, value := range attributes {
+			values[name] = tftypes.NewValue(tftypes.String, value)
+		}
+		return tftypes.NewValue(objectType, values)
+	}
+	attributes := map[string]string{
+		"path":             "/api/objects",
+		"data":             `{"name":"tenant_11","identifier":"tenant_11","repo_name_prefix":"tenant_11-pqmzt"}`,
+		"id_from_data_key": "name",
+	}
+
+	createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil, nil)}}
+	r.Create(ctx, fwresource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue, attributes)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil, attributes)},
+	}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+	}
+	var state idhubTenantResourceModel
+	createResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "tenant_11" || state.Tenant.ValueString() != "tenant_11" {
+		t.Errorf("Unexpected state after the creation: id=%s tenant=%s", state.Id, state.Tenant)
+	}
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", readResp.Diagnostics)
+	}
+	readResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "tenant_11" || state.RepoNamePrefix.ValueString() != "tenant_11-pqmzt" {
+		t.Errorf("Unexpected state after the read: id=%s repo_name_prefix=%s", state.Id, state.RepoNamePrefix)
+	}
+
+	deleteResp := &fwresource.DeleteResponse{State: readResp.State}
+	r.Delete(ctx, fwresource.DeleteRequest{State: readResp.State}, deleteResp)
+	if deleteResp.Diagnostics.HasError() {
+		t.Fatalf("Delete returned errors: %v", deleteResp.Diagnostics)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"POST /api/objects", "GET /api/objects?name=tenant_11"}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected requests %v; want %v, the tenant being kept on delete", requests, expected)
+	}
+
+	/* A data without the key can't be created */
+	attributes["id_from_data_key"] = "code"
+	createResp = &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil, nil)}}
+	r.Create(ctx, fwresource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue, attributes)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil, attributes)},
+	}, createResp)
+	if !createResp.Diagnostics.HasError() {
+		t.Error("Create should fail when the data has no id_from_data_key key")
+	}
+}
+
+func TestIdhubTenantResource_useLocationAsPath(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	const tenant = `{"id":"12","identifier":"tenant_12","repo_name_prefix":"tenant_12-bqkxe"}`
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			/* The created object is only given by its URL */
+			w.Header().Set("Location", "http://"+r.Host+"/api/objects/12")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "GET" && r.URL.Path == "/api/objects/12":
+			fmt.Fprint(w, tenant)
+		case r.Method == "POST" && r.URL.Path == "/api/unlocated":
+			fmt.Fprint(w, tenant)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	/* Returns the resource value with path, data and use_location_as_path, the others null or, when computed, unknown */
+	resourceValue := func(computed any) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+		values["data"] = tftypes.NewValue(tftypes.String, `{"identifier":"tenant_12"}`)
+		values["use_location_as_path"] = tftypes.NewValue(tftypes.Bool, true)
+		return tftypes.NewValue(objectType, values)
+	}
+
+	createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil)}}
+	r.Create(ctx, fwresource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil)},
+	}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+	}
+	var state idhubTenantResourceModel
+	createResp.State.Get(ctx, &state)
+	if state.Location.ValueString() != "/api/objects/12" || state.Id.ValueString() != "12" {
+		t.Errorf("Unexpected state after the creation: location=%s id=%s", state.Location, state.Id)
+	}
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", readResp.Diagnostics)
+	}
+
+	mu.Lock()
+	expected := []string{"POST /api/objects", "GET /api/objects/12", "GET /api/objects/12"}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected requests %v; want %v", requests, expected)
+	}
+	mu.Unlock()
+
+	/* A creation response without Location fails the creation */
+	if _, _, _, err := r.createObject(ctx, "/api/unlocated", `{"identifier":"tenant_12"}`, nil, true, "identifier", true); err == nil || !strings.Contains(err.Error(), "no Location header") {
+		t.Errorf("createObject should fail on a creation response without Location, got: %v", err)
+	}
+}
+
+func TestIdhubTenantResource_createRedirect(t *testing.T) {
+	var requests []string
+	const tenant = `{"id":"12","identifier":"tenant_12","repo_name_prefix":"tenant_12-bqkxe"}`
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			/* POST-redirect-GET: the created object is at the Location of the 303 */
+			http.Redirect(w, r, "/api/objects/12", http.StatusSeeOther)
+		case r.Method == "GET" && r.URL.Path == "/api/objects/12":
+			fmt.Fprint(w, tenant)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{
+		Uri:               svr.URL,
+		Timeout:           2,
+		RateLimit:         100,
+		NoFollowRedirects: true,
+		ExpectedStatus:    map[apiclient.Operation][]int{apiclient.OperationCreate: {http.StatusSeeOther}},
+	})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+
+	responseData, locationPath, _, err := r.createObject(context.Background(), "/api/objects", `{"identifier":"tenant_12"}`, nil, false, "identifier", true)
+	if err != nil {
+		t.Fatalf("createObject returned an error: %s", err)
+	}
+	if responseData != tenant || locationPath != "/api/objects/12" {
+		t.Errorf("Unexpected created object %s at %s", responseData, locationPath)
+	}
+	expected := []string{"POST /api/objects", "GET /api/objects/12"}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected requests %v; want %v", requests, expected)
+	}
+}
+
+func TestIdhubTenantResource_waitForCondition(t *testing.T) {
+	reads := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("identifier") {
+		case "tenant_12":
+			/* Provisioned on the third read */
+			reads++
+			status := "pending"
+			if reads >= 3 {
+				status = "ready"
+			}
+			fmt.Fprintf(w, `{"id":"12","identifier":"tenant_12","repo_name_prefix":"tenant_12-bqkxe","status":"%s"}`, status)
+		case "tenant_13":
+			fmt.Fprint(w, `{"id":"13","identifier":"tenant_13","repo_name_prefix":"tenant_13-bqkxe","status":"failed"}`)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, IdentifierQueryParam: "identifier"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+	model := func(tenant string) *idhubTenantResourceModel {
+		return &idhubTenantResourceModel{
+			Path:    types.StringValue("/api/objects"),
+			Tenant:  types.StringValue(tenant),
+			WaitFor: &waitForModel{Path: types.StringValue("status"), Value: types.StringValue("ready")},
+		}
+	}
+
+	responseData, err := r.waitForCondition(ctx, model("tenant_12"), nil, 10*time.Millisecond, time.Second)
+	if err != nil || reads != 3 || !strings.Contains(responseData, `"ready"`) {
+		t.Errorf("waitForCondition returned %s after %d reads and the error: %v", responseData, reads, err)
+	}
+
+	_, err = r.waitForCondition(ctx, model("tenant_13"), nil, 10*time.Millisecond, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), `the value at status is still "failed"`) {
+		t.Errorf("waitForCondition should time out with the last observed value, got: %v", err)
+	}
+
+	if _, err = r.waitForCondition(ctx, model("tenant_14"), nil, 10*time.Millisecond, time.Second); apiclient.StatusCode(err) != http.StatusNotFound {
+		t.Errorf("waitForCondition should fail on a read error, got: %v", err)
+	}
+}
+
+func TestIdhubTenantResource_idHeader(t *testing.T) {
+	var requests []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			/* The id is only given by a header, with an empty body */
+			w.Header().Set("X-Resource-Id", "42")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "GET" && r.URL.Path == "/api/objects" && r.URL.Query().Get("identifier") == "tenant_42":
+			fmt.Fprint(w, `{"identifier":"tenant_42","repo_name_prefix":"tenant_42-bqkxe"}`)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, IdentifierQueryParam: "identifier"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	/* Returns the resource value with path, data and id_header, the others null or, when computed, unknown */
+	resourceValue := func(computed any) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+		values["data"] = tftypes.NewValue(tftypes.String, `{"identifier":"tenant_42"}`)
+		values["id_header"] = tftypes.NewValue(tftypes.String, "X-Resource-Id")
+		return tftypes.NewValue(objectType, values)
+	}
+
+	createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil)}}
+	r.Create(ctx, fwresource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil)},
+	}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+	}
+	var state idhubTenantResourceModel
+	createResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "42" || state.Tenant.ValueString() != "tenant_42" {
+		t.Errorf("Unexpected state after the creation: id=%s tenant=%s", state.Id, state.Tenant)
+	}
+
+	/* The read responses have no id either: the id of the header is kept */
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", readResp.Diagnostics)
+	}
+	readResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "42" {
+		t.Errorf("Unexpected id after the read: %s", state.Id)
+	}
+
+	expected := []string{"POST /api/objects", "GET /api/objects?identifier=tenant_42", "GET /api/objects?identifier=tenant_42"}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected requests %v; want %v", requests, expected)
+	}
+}
+
+func TestIdhubTenantResource_identifierKey(t *testing.T) {
+	var requests []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && r.URL.Path == "/api/objects" && r.URL.Query().Get("name") == "tenant_43":
+			/* The tenant name is under name, the API having no identifier */
+			fmt.Fprint(w, `{"id":"43","name":"tenant_43","repo_name_prefix":"tenant_43-mvhzq"}`)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, IdentifierQueryParam: "name"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	/* Returns the resource value with path, data and identifier_key, the others null or, when computed, unknown */
+	resourceValue := func(computed any) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+		values["data"] = tftypes.NewValue(tftypes.String, `{"name":"tenant_43"}`)
+		values["identifier_key"] = tftypes.NewValue(tftypes.String, "name")
+		return tftypes.NewValue(objectType, values)
+	}
+
+	createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil)}}
+	r.Create(ctx, fwresource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil)},
+	}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+	}
+	var state idhubTenantResourceModel
+	createResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "43" || state.Tenant.ValueString() != "tenant_43" {
+		t.Errorf("Unexpected state after the creation: id=%s tenant=%s", state.Id, state.Tenant)
+	}
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", readResp.Diagnostics)
+	}
+	readResp.State.Get(ctx, &state)
+	if state.Tenant.ValueString() != "tenant_43" {
+		t.Errorf("Unexpected tenant after the read: %s", state.Tenant)
+	}
+
+	expected := []string{"POST /api/objects", "GET /api/objects?name=tenant_43", "GET /api/objects?name=tenant_43"}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected requests %v; want %v", requests, expected)
+	}
+}
+
+func TestIdhubTenantResource_notFoundPredicate(t *testing.T) {
+	found := false
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/api/objects" || r.URL.Query().Get("identifier") != "tenant_45" {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		/* The API answers a missing tenant with a 200 envelope */
+		fmt.Fprintf(w, `{"found":%t,"id":"45","identifier":"tenant_45","repo_name_prefix":"tenant_45-mvhzq"}`, found)
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	predicateType := objectType.AttributeTypes["not_found_predicate"].(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+	}
+	values["id"] = tftypes.NewValue(tftypes.String, "45")
+	values["tenant"] = tftypes.NewValue(tftypes.String, "tenant_45")
+	values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+	values["data"] = tftypes.NewValue(tftypes.String, `{"identifier":"tenant_45"}`)
+	values["not_found_predicate"] = tftypes.NewValue(predicateType, map[string]tftypes.Value{
+		"path":  tftypes.NewValue(tftypes.String, "found"),
+		"value": tftypes.NewValue(tftypes.String, "false"),
+	})
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)}
+
+	for _, found = range []bool{false, true} {
+		readResp := &fwresource.ReadResponse{State: state}
+		r.Read(ctx, fwresource.ReadRequest{State: state}, readResp)
+		if readResp.Diagnostics.HasError() {
+			t.Fatalf("Read with found=%t returned errors: %v", found, readResp.Diagnostics)
+		}
+		if readResp.State.Raw.IsNull() == found {
+			t.Errorf("With found=%t, Read should remove the resource from the state: %t", found, !found)
+		}
+	}
+}
+
+func TestIdhubTenantResource_rawResponse(t *testing.T) {
+	const xmlData = `<tenant><name>tenant_44</name></tenant>`
+	const xmlTenant = `<tenant id="44"><name>tenant_44</name></tenant>`
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			if body, _ := io.ReadAll(r.Body); string(body) != xmlData || r.Header.Get("Content-Type") != "application/xml" {
+				t.Errorf("Unexpected creation request with the content type %s: %s", r.Header.Get("Content-Type"), body)
+			}
+			w.Header().Set("X-Resource-Id", "44")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, xmlTenant)
+		case r.Method == "GET" && r.URL.Path == "/api/objects" && r.URL.Query().Get("identifier") == "44":
+			fmt.Fprint(w, xmlTenant)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, IdentifierQueryParam: "identifier"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	/* Returns the resource value of an XML tenant with the attributes, the others null or, when computed, unknown */
+	resourceValue := func(computed any, attributes map[string]string) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+		values["data"] = tftypes.NewValue(tftypes.String, xmlData)
+		values["content_type"] = tftypes.NewValue(tftypes.String, "application/xml")
+		values["response_format"] = tftypes.NewValue(tftypes.String, "raw")
+		for name, value := range attributes {
+			values[name] = tftypes.NewValue(tftypes.String, value)
+		}
+		return tftypes.NewValue(objectType, values)
+	}
+	idHeader := map[string]string{"id_header": "X-Resource-Id"}
+
+	/* Without id_header or with a setting reading JSON, the configuration is invalid */
+	for _, attributes := range []map[string]string{{}, {"id_header": "X-Resource-Id", "select_subtree": "data"}} {
+		validateResp := &fwresource.ValidateConfigResponse{}
+		r.ValidateConfig(ctx, fwresource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil, attributes)}}, validateResp)
+		if !validateResp.Diagnostics.HasError() {
+			t.Errorf("ValidateConfig should fail with response_format raw and %v", attributes)
+		}
+	}
+	validateResp := &fwresource.ValidateConfigResponse{}
+	r.ValidateConfig(ctx, fwresource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil, idHeader)}}, validateResp)
+	if validateResp.Diagnostics.HasError() {
+		t.Errorf("ValidateConfig returned errors: %v", validateResp.Diagnostics)
+	}
+
+	createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil, idHeader)}}
+	r.Create(ctx, fwresource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue, idHeader)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil, idHeader)},
+	}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+	}
+	var state idhubTenantResourceModel
+	createResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "44" || state.Response.ValueString() != xmlTenant || !state.Tenant.IsNull() {
+		t.Errorf("Unexpected state after the creation: id=%s response=%s tenant=%s", state.Id, state.Response, state.Tenant)
+	}
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", readResp.Diagnostics)
+	}
+	readResp.State.Get(ctx, &state)
+	if state.Response.ValueString() != xmlTenant {
+		t.Errorf("Unexpected response after the read: %s", state.Response)
+	}
+}
+
+func TestIdhubTenantResource_operation(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	statusReads := map[string]int{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			/* The creation is asynchronous: the response is an operation handle */
+			var data map[string]string
+			json.NewDecoder(r.Body).Decode(&data)
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintf(w, `{"operation":{"id":"op-%s"}}`, strings.TrimPrefix(data["identifier"], "tenant_"))
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/operations/"):
+			operationId := strings.TrimPrefix(r.URL.Path, "/operations/")
+			statusReads[operationId]++
+			switch {
+			case statusReads[operationId] == 1:
+				fmt.Fprint(w, `{"status":"running"}`)
+			case operationId == "op-45":
+				fmt.Fprint(w, `{"status":"done"}`)
+			default:
+				fmt.Fprint(w, `{"status":"failed","error":"quota exceeded"}`)
+			}
+		case r.Method == "GET" && r.URL.Path == "/api/objects" && r.URL.Query().Get("identifier") == "tenant_45":
+			fmt.Fprint(w, `{"id":"45","identifier":"tenant_45","repo_name_prefix":"tenant_45-wqpzr"}`)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, IdentifierQueryParam: "identifier"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	operationType := objectType.AttributeTypes["operation"].(tftypes.Object)
+	predicateType := operationType.AttributeTypes["success"].(tftypes.Object)
+	predicate := func(path string, value string) tftypes.Value {
+		return tftypes.NewValue(predicateType, map[string]tftypes.Value{
+			"path":  tftypes.NewValue(tftypes.String, path),
+			"value": tftypes.NewValue(tftypes.String, value),
+		})
+	}
+	/* Returns the resource value of the tenant polling its creation operation, the others null or, when computed, unknown */
+	resourceValue := func(computed any, tenant string) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+		values["data"] = tftypes.NewValue(tftypes.String, fmt.Sprintf(`{"identifier":"%s"}`, tenant))
+		values["read_back_key"] = tftypes.NewValue(tftypes.String, "identifier")
+		values["operation"] = tftypes.NewValue(operationType, map[string]tftypes.Value{
+			"id_path":     tftypes.NewValue(tftypes.String, "operation.id"),
+			"status_path": tftypes.NewValue(tftypes.String, "/operations/{op_id}"),
+			"success":     predicate("status", "done"),
+			"failure":     predicate("status", "failed"),
+			"interval":    tftypes.NewValue(tftypes.Number, 1),
+			"timeout":     tftypes.NewValue(tftypes.Number, 10),
+		})
+		return tftypes.NewValue(objectType, values)
+	}
+	create := func(tenant string) *fwresource.CreateResponse {
+		createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil, tenant)}}
+		r.Create(ctx, fwresource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue, tenant)},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil, tenant)},
+		}, createResp)
+		return createResp
+	}
+
+	createResp := create("tenant_45")
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+	}
+	var state idhubTenantResourceModel
+	createResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "45" || state.Tenant.ValueString() != "tenant_45" {
+		t.Errorf("Unexpected state after the creation: id=%s tenant=%s", state.Id, state.Tenant)
+	}
+	expected := []string{"POST /api/objects", "GET /operations/op-45", "GET /operations/op-45", "GET /api/objects?identifier=tenant_45"}
+	mu.Lock()
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected requests %v; want %v", requests, expected)
+	}
+	mu.Unlock()
+
+	/* A failed operation fails the creation with its status */
+	createResp = create("tenant_46")
+	if !createResp.Diagnostics.HasError() || !strings.Contains(fmt.Sprint(createResp.Diagnostics), "quota exceeded") {
+		t.Errorf("Create should fail with the status of the failed operation: %v", createResp.Diagnostics)
+	}
+}
+
+func TestIdhubTenantResource_responseRoot(t *testing.T) {
+	const tenant = `{"id":"47","identifier":"tenant_47","repo_name_prefix":"tenant_47-zvtra"}`
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/objects":
+			/* The object reads wrap an object, the list reads an array */
+			fmt.Fprintf(w, `{"data":%s}`, tenant)
+		case r.Method == "GET" && r.URL.Path == "/api/objects" && r.URL.Query().Get("identifier") == "tenant_47":
+			fmt.Fprintf(w, `{"data":[%s],"next":null}`, tenant)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100, IdentifierQueryParam: "identifier", ResponseRoot: "data"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	/* Returns the resource value with path and data, the others null or, when computed, unknown */
+	resourceValue := func(computed any) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+		values["data"] = tftypes.NewValue(tftypes.String, `{"identifier":"tenant_47"}`)
+		return tftypes.NewValue(objectType, values)
+	}
+
+	createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil)}}
+	r.Create(ctx, fwresource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil)},
+	}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+	}
+	var state idhubTenantResourceModel
+	createResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "47" || state.Tenant.ValueString() != "tenant_47" {
+		t.Errorf("Unexpected state after the creation: id=%s tenant=%s", state.Id, state.Tenant)
+	}
+
+	readResp := &fwresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, fwresource.ReadRequest{State: createResp.State}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", readResp.Diagnostics)
+	}
+	readResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "47" || state.RepoNamePrefix.ValueString() != "tenant_47-zvtra" {
+		t.Errorf("Unexpected state after the read: id=%s repo_name_prefix=%s", state.Id, state.RepoNamePrefix)
+	}
+
+	importResp := &fwresource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+	r.ImportState(ctx, fwresource.ImportStateRequest{ID: "/api/objects,tenant_47"}, importResp)
+	if importResp.Diagnostics.HasError() {
+		t.Fatalf("ImportState returned errors: %v", importResp.Diagnostics)
+	}
+	importResp.State.Get(ctx, &state)
+	if state.Id.ValueString() != "47" || state.RepoNamePrefix.ValueString() != "tenant_47-zvtra" {
+		t.Errorf("Unexpected state after the import: id=%s repo_name_prefix=%s", state.Id, state.RepoNamePrefix)
+	}
+	if !state.LastUpdated.IsNull() {
+		t.Errorf("last_updated should be null after the import, without time_format: %s", state.LastUpdated)
+	}
+}
+
+func TestIdhubTenantResource_ifNotExists(t *testing.T) {
+	objects := map[string]map[string]any{
+		"7": {
+			"id":               "7",
+			"identifier":       "tenant_7",
+			"repo_name_prefix": "tenant_7-qzpwk",
+		},
+	}
+	svr := fakeserver.NewFakeServer(19095, objects, true, false, "")
+	defer svr.Shutdown()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: "http://127.0.0.1:19095", Timeout: 2, RateLimit: 100, IdentifierQueryParam: "identifier"})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	/* Returns the resource value with path, data and if_not_exists, the others null or, when computed, unknown */
+	resourceValue := func(data string, computed any) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/objects")
+		values["data"] = tftypes.NewValue(tftypes.String, data)
+		values["if_not_exists"] = tftypes.NewValue(tftypes.Bool, true)
+		return tftypes.NewValue(objectType, values)
+	}
+
+	testCases := []struct {
+		name           string
+		data           string
+		id             string
+		repoNamePrefix string
+	}{
+		/* The fake server rejects the POST of an existing id: the existing tenant must be adopted */
+		{"exists", `{"id":"7","identifier":"tenant_7","repo_name_prefix":"tenant_7-other"}`, "7", "tenant_7-qzpwk"},
+		/* The lookup answers an empty array: the tenant is created */
+		{"not exists", `{"id":"8","identifier":"tenant_8","repo_name_prefix":"tenant_8-hbvxs"}`, "8", "tenant_8-hbvxs"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(tc.data, nil)}}
+			r.Create(ctx, fwresource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tc.data, tftypes.UnknownValue)},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(tc.data, nil)},
+			}, createResp)
+			if createResp.Diagnostics.HasError() {
+				t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+			}
+			var state idhubTenantResourceModel
+			createResp.State.Get(ctx, &state)
+			if state.Id.ValueString() != tc.id || state.RepoNamePrefix.ValueString() != tc.repoNamePrefix {
+				t.Errorf("Unexpected state after the creation: id=%s repo_name_prefix=%s; want %s and %s", state.Id, state.RepoNamePrefix, tc.id, tc.repoNamePrefix)
+			}
+
+			responseData, err := client.SendJsonRequestWithOpt(ctx, "GET", "/api/objects/"+tc.id, "", nil)
+			if err != nil {
+				t.Fatalf("The tenant %s can't be read on the fake server: %s", tc.id, err)
+			}
+			repoNamePrefix, err := apiclient.GetKeyValue(responseData, "repo_name_prefix")
+			if err != nil || repoNamePrefix != tc.repoNamePrefix {
+				t.Errorf("The tenant on the fake server has the repo_name_prefix %s (%v); want %s", repoNamePrefix, err, tc.repoNamePrefix)
+			}
+		})
+	}
+}
+
+func TestIdhubTenantResource_trimResponse(t *testing.T) {
+	testCases := []struct {
+		responseFormat types.String
+		trimResponse   types.Bool
+		expected       bool
+	}{
+		{types.StringNull(), types.BoolNull(), true},
+		{types.StringValue(responseFormatJson), types.BoolNull(), true},
+		{types.StringValue(responseFormatRaw), types.BoolNull(), false},
+		{types.StringValue(responseFormatJson), types.BoolValue(false), false},
+		{types.StringValue(responseFormatRaw), types.BoolValue(true), true},
+	}
+	for _, tc := range testCases {
+		model := idhubTenantResourceModel{ResponseFormat: tc.responseFormat, TrimResponse: tc.trimResponse, SuppressHeaders: types.ListNull(types.StringType)}
+		requestOpt, diags := model.requestOpt(context.Background())
+		if diags.HasError() {
+			t.Fatalf("requestOpt returned errors: %v", diags)
+		}
+		if requestOpt.TrimResponse != tc.expected {
+			t.Errorf("With response_format %s and trim_response %s, TrimResponse = %t; want %t", tc.responseFormat, tc.trimResponse, requestOpt.TrimResponse, tc.expected)
+		}
+	}
+}
+
+func TestIdhubTenantResource_skipReadAfterCreate(t *testing.T) {
+	const tenant = `{"id":"45","identifier":"tenant_45","repo_name_prefix":"tenant_45-kdwye"}`
+	var requests []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/full":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, tenant)
+		case r.Method == "POST" && r.URL.Path == "/api/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "POST" && r.URL.Path == "/api/unidentified":
+			fmt.Fprint(w, `{"identifier":"tenant_45"}`)
+		case r.Method == "GET":
+			fmt.Fprint(w, tenant)
+		default:
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &idhubTenantResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(ctx, fwresource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	/* Returns the resource value with the attributes, the others null or, when computed, unknown */
+	resourceValue := func(computed any, attributes map[string]tftypes.Value) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+			if attribute := schemaResp.Schema.Attributes[name]; attribute.IsComputed() && !attribute.IsOptional() {
+				values[name] = tftypes.NewValue(attributeType, computed)
+			}
+		}
+		values["data"] = tftypes.NewValue(tftypes.String, `{"identifier":"tenant_45"}`)
+		values["read_back_key"] = tftypes.NewValue(tftypes.String, "identifier")
+		for name, value := range attributes {
+			values[name] = value
+		}
+		return tftypes.NewValue(objectType, values)
+	}
+	create := func(tenantPath string, skip bool) (*fwresource.CreateResponse, idhubTenantResourceModel) {
+		requests = nil
+		attributes := map[string]tftypes.Value{
+			"path":                   tftypes.NewValue(tftypes.String, tenantPath),
+			"skip_read_after_create": tftypes.NewValue(tftypes.Bool, skip),
+		}
+		createResp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: resourceValue(nil, attributes)}}
+		r.Create(ctx, fwresource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: resourceValue(tftypes.UnknownValue, attributes)},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil, attributes)},
+		}, createResp)
+		var state idhubTenantResourceModel
+		createResp.State.Get(ctx, &state)
+		return createResp, state
+	}
+
+	createResp, state := create("/api/full", true)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create returned errors: %v", createResp.Diagnostics)
+	}
+	if state.Id.ValueString() != "45" || state.RepoNamePrefix.ValueString() != "tenant_45-kdwye" {
+		t.Errorf("Unexpected state after the creation: id=%s repo_name_prefix=%s", state.Id, state.RepoNamePrefix)
+	}
+	if fmt.Sprint(requests) != "[POST /api/full]" {
+		t.Errorf("Unexpected requests %v; want the creation only", requests)
+	}
+
+	/* The tenant is read back without skip_read_after_create only */
+	for _, tenantPath := range []string{"/api/empty", "/api/unidentified"} {
+		if createResp, _ := create(tenantPath, false); createResp.Diagnostics.HasError() || len(requests) != 2 {
+			t.Errorf("Create on %s should read the tenant back: %v %v", tenantPath, requests, createResp.Diagnostics)
+		}
+		if createResp, _ := create(tenantPath, true); !createResp.Diagnostics.HasError() || len(requests) != 1 {
+			t.Errorf("Create on %s should fail without reading the tenant back: %v %v", tenantPath, requests, createResp.Diagnostics)
+		}
+	}
+
+	waitForType := objectType.AttributeTypes["wait_for"].(tftypes.Object)
+	waitFor := map[string]tftypes.Value{}
+	for name, attributeType := range waitForType.AttributeTypes {
+		waitFor[name] = tftypes.NewValue(attributeType, nil)
+	}
+	waitFor["path"] = tftypes.NewValue(tftypes.String, "status")
+	waitFor["value"] = tftypes.NewValue(tftypes.String, "ready")
+	for _, skip := range []bool{false, true} {
+		validateResp := &fwresource.ValidateConfigResponse{}
+		r.ValidateConfig(ctx, fwresource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: resourceValue(nil, map[string]tftypes.Value{
+			"path":                   tftypes.NewValue(tftypes.String, "/api/full"),
+			"skip_read_after_create": tftypes.NewValue(tftypes.Bool, skip),
+			"wait_for":               tftypes.NewValue(waitForType, waitFor),
+		})}}, validateResp)
+		if validateResp.Diagnostics.HasError() != skip {
+			t.Errorf("With skip_read_after_create %t and wait_for, ValidateConfig returned %v", skip, validateResp.Diagnostics)
+		}
+	}
+}
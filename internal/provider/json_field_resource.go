@@ -0,0 +1,428 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/apiclient"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource = &jsonFieldResource{}
+)
+
+// Number of read-modify-write attempts when the object changes between the
+// read and the write, failing the precondition of the write.
+const jsonFieldWriteAttempts = 3
+
+// Settings of the requests reading the object.
+var jsonFieldReadOpt = &apiclient.RequestOpt{Operation: apiclient.OperationRead}
+
+// Private state key set when an update failed with a recreate_on_status
+// code, removing the resource from the state on the next refresh.
+const jsonFieldRecreateKey = "recreate"
+
+// jsonFieldResource manages a single field of an object owned elsewhere.
+type jsonFieldResource struct {
+	client *apiclient.APIClient
+}
+
+// jsonFieldResourceModel maps the resource schema data.
+type jsonFieldResourceModel struct {
+	Id                types.String `tfsdk:"id"`
+	Path              types.String `tfsdk:"path"`
+	Field             types.String `tfsdk:"field"`
+	Value             types.String `tfsdk:"value"`
+	PreviousValue     types.String `tfsdk:"previous_value"`
+	LastUpdated       types.String `tfsdk:"last_updated"`
+	IgnoreAddedKeys   types.Bool   `tfsdk:"ignore_added_keys"`
+	IfUnmodifiedSince types.Bool   `tfsdk:"if_unmodified_since"`
+	MergePatch        types.Bool   `tfsdk:"merge_patch"`
+	// Treat a 405 Method Not Allowed answering the write as a success
+	IgnoreUpdateMethodNotAllowed  types.Bool `tfsdk:"ignore_update_method_not_allowed"`
+	IgnoreDestroyMethodNotAllowed types.Bool `tfsdk:"ignore_destroy_method_not_allowed"`
+	// Status codes of an update write re-creating the resource instead of failing
+	RecreateOnStatus types.List `tfsdk:"recreate_on_status"`
+}
+
+// NewJsonFieldResource is a helper function to simplify the provider implementation.
+func NewJsonFieldResource() resource.Resource {
+	return &jsonFieldResource{}
+}
+
+// Metadata returns the resource type name.
+func (r *jsonFieldResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_json_field"
+}
+
+// Schema defines the schema for the resource.
+func (r *jsonFieldResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Resource managing a single field of an existing object, e.g. a feature flag inside a large configuration document managed elsewhere. " +
+			"The object is read, the field set, and the object written back with the provider update method (PUT by default). " +
+			"The write carries `If-Match` with the `ETag` of the read, or `If-Unmodified-Since` with its `Last-Modified`, and is retried from a new read when the object changed in between. " +
+			"On destroy, the field is restored to its previous value, or removed when it didn't exist.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The path and the field, separated by `#`.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description: "The API path, on top of the base URL set in the provider, of the object holding the field.",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"field": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the managed field in the object, e.g. `features.new_ui`. The missing objects along the path are created.",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"value": schema.StringAttribute{
+				Description: "JSON encoded value of the field, e.g. `jsonencode(true)`.",
+				Required:    true,
+				Validators: []validator.String{
+					jsonValidator{},
+				},
+			},
+			"ignore_added_keys": schema.BoolAttribute{
+				Description: "When set, the keys the API server adds to an object `value`, e.g. defaults, are ignored: the refreshed field keeps the configured `value` while it holds all its keys with equal values, recursively. " +
+					"A key whose value differs, or a removed key, still shows a diff. Defaults to `false`, the field differing on any added key.",
+				Optional: true,
+			},
+			"ignore_update_method_not_allowed": schema.BoolAttribute{
+				Description: "When set, a 405 Method Not Allowed answering the write of an update is ignored, with a warning, e.g. for read-only objects. The field keeps its value on the API server. Defaults to `false`.",
+				Optional:    true,
+			},
+			"ignore_destroy_method_not_allowed": schema.BoolAttribute{
+				Description: "When set, a 405 Method Not Allowed answering the write restoring the field on destroy is ignored, with a warning: the resource is only removed from the Terraform state. Defaults to `false`.",
+				Optional:    true,
+			},
+			"recreate_on_status": schema.ListAttribute{
+				Description: "A list of the HTTP error status codes of an update write, e.g. `[409]` when the object was recreated out-of-band, re-creating the resource instead of failing. " +
+					"The update succeeds with a warning, without setting the field, and the next refresh removes the resource from the state so that the next apply creates it again. " +
+					"The other errors, and these codes on create and destroy, still fail. Defaults to none.",
+				ElementType: types.Int64Type,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.ValueInt64sAre(int64validator.Between(400, 599)),
+				},
+			},
+			"if_unmodified_since": schema.BoolAttribute{
+				Description: "When set, the write of an update sends `If-Unmodified-Since` with `last_updated`, for APIs detecting the concurrent changes with timestamps: a 412 Precondition Failed, the object having changed since the last refresh, fails the update with a conflict, to refresh and plan again. " +
+					"The API server must send the `Last-Modified` of the object as an HTTP-date, e.g. `Tue, 14 Nov 2023 22:13:20 GMT` (the IMF-fixdate of RFC 9110), sent back verbatim. " +
+					"Without `last_updated`, the write is guarded by the read preceding it only. Defaults to `false`.",
+				Optional: true,
+			},
+			"last_updated": schema.StringAttribute{
+				Description: "The `Last-Modified` header of the object, an HTTP-date, as of the last refresh or write. Null when the API server doesn't send it.",
+				Computed:    true,
+			},
+			"merge_patch": schema.BoolAttribute{
+				Description: "When set, the field is written with a PATCH of the RFC 7386 JSON merge patch turning the object read into the modified one, with the `application/merge-patch+json` content type, instead of writing the whole object back with the provider update method: only the changed field is sent. " +
+					"As with any merge patch, a `null` `value` removes the field. Defaults to `false`.",
+				Optional: true,
+			},
+			"previous_value": schema.StringAttribute{
+				Description: "JSON encoded value of the field before this resource set it, restored on destroy. Null when the field didn't exist.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+// Create sets the field, remembering its previous value.
+func (r *jsonFieldResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan jsonFieldResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	object, lastModified, err := r.writeField(ctx, &plan, plan.Value.ValueString(), false, "")
+	if err != nil {
+		resp.Diagnostics.AddError("Update request error", fmt.Sprintf("The field can't be set: %s", err))
+		return
+	}
+	plan.LastUpdated = lastUpdatedValue(lastModified)
+	previousValue, found, err := apiclient.GetJsonAtPath(object, plan.Field.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read response error", fmt.Sprintf("The object can't be decoded: %s", err))
+		return
+	}
+	plan.PreviousValue = types.StringNull()
+	if found {
+		plan.PreviousValue = types.StringValue(previousValue)
+	}
+
+	plan.Id = types.StringValue(plan.Path.ValueString() + "#" + plan.Field.ValueString())
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Read refreshes the value of the field. The resource is removed from the
+// state when the object or the field doesn't exist anymore.
+func (r *jsonFieldResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state jsonFieldResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	recreate, diags := req.Private.GetKey(ctx, jsonFieldRecreateKey)
+	resp.Diagnostics.Append(diags...)
+	if len(recreate) > 0 {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	readOpt := *jsonFieldReadOpt
+	readOpt.ResponseHeader = http.Header{}
+	object, err := r.client.SendJsonRequestWithOpt(ctx, "GET", state.Path.ValueString(), "", &readOpt)
+	if apiclient.StatusCode(err) == http.StatusNotFound {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read request error", fmt.Sprintf("Read request returned the error: %s on the path: %s", err, state.Path.ValueString()))
+		return
+	}
+	value, found, err := apiclient.GetJsonAtPath(object, state.Field.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read response error", fmt.Sprintf("The object can't be decoded: %s", err))
+		return
+	}
+	if !found {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	/* Keep the configured formatting of an unchanged value */
+	equal, err := apiclient.JsonEqual(value, state.Value.ValueString())
+	if err == nil && !equal && state.IgnoreAddedKeys.ValueBool() {
+		equal, err = apiclient.JsonIncludes(state.Value.ValueString(), value)
+	}
+	if err != nil || !equal {
+		state.Value = types.StringValue(value)
+	}
+	state.LastUpdated = lastUpdatedValue(readOpt.ResponseHeader.Get("Last-Modified"))
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+// Update sets the field to its new value. With
+// ignore_update_method_not_allowed, a 405 keeps the planned value in the
+// state, the next refresh reporting the value of the API server. A
+// recreate_on_status code keeps it too, flagging the resource in its private
+// state for its removal on the next refresh: Terraform can't replace a
+// resource during its update. With if_unmodified_since, a 412 answering the
+// write conditional on last_updated fails with a conflict.
+func (r *jsonFieldResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state jsonFieldResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var recreateOnStatus []int64
+	resp.Diagnostics.Append(plan.RecreateOnStatus.ElementsAs(ctx, &recreateOnStatus, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var ifUnmodifiedSince string
+	if plan.IfUnmodifiedSince.ValueBool() {
+		ifUnmodifiedSince = state.LastUpdated.ValueString()
+	}
+	_, lastModified, err := r.writeField(ctx, &plan, plan.Value.ValueString(), false, ifUnmodifiedSince)
+	plan.LastUpdated = state.LastUpdated
+	if err == nil {
+		plan.LastUpdated = lastUpdatedValue(lastModified)
+	}
+	if plan.IgnoreUpdateMethodNotAllowed.ValueBool() && apiclient.IsMethodNotAllowed(err, r.writeMethod(&plan)) {
+		resp.Diagnostics.AddWarning("Update not allowed", fmt.Sprintf("The API server doesn't allow the update of the object %s, the field %s was not set: %s", plan.Path.ValueString(), plan.Field.ValueString(), err))
+	} else if status := apiclient.StatusCode(err); status != 0 && slices.Contains(recreateOnStatus, int64(status)) {
+		resp.Diagnostics.AddWarning(
+			"Resource to re-create",
+			fmt.Sprintf("The update of the object %s failed with the status %d of recreate_on_status, the field %s was not set. "+
+				"The resource will be removed from the state on the next refresh and created again by the next apply: %s", plan.Path.ValueString(), status, plan.Field.ValueString(), err),
+		)
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, jsonFieldRecreateKey, []byte("true"))...)
+	} else if ifUnmodifiedSince != "" && apiclient.StatusCode(err) == http.StatusPreconditionFailed {
+		resp.Diagnostics.AddError(
+			"Update conflict",
+			fmt.Sprintf("The object %s was modified on the API server since %s, its last refresh, the field %s was not set. "+
+				"Refresh the state, e.g. with terraform apply -refresh-only, and plan the change again: %s", plan.Path.ValueString(), ifUnmodifiedSince, plan.Field.ValueString(), err),
+		)
+		return
+	} else if err != nil {
+		resp.Diagnostics.AddError("Update request error", fmt.Sprintf("The field can't be set: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Delete restores the previous value of the field, or removes it when it
+// didn't exist. Nothing is sent when the object doesn't exist anymore.
+// With ignore_destroy_method_not_allowed, a 405 only removes the resource from
+// the state.
+func (r *jsonFieldResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state jsonFieldResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, _, err := r.writeField(ctx, &state, state.PreviousValue.ValueString(), state.PreviousValue.IsNull(), "")
+	if state.IgnoreDestroyMethodNotAllowed.ValueBool() && apiclient.IsMethodNotAllowed(err, r.writeMethod(&state)) {
+		resp.Diagnostics.AddWarning("Destroy not allowed", fmt.Sprintf("The API server doesn't allow the update of the object %s, the field %s was not restored: %s", state.Path.ValueString(), state.Field.ValueString(), err))
+		return
+	}
+	if err != nil && apiclient.StatusCode(err) != http.StatusNotFound {
+		resp.Diagnostics.AddError("Update request error", fmt.Sprintf("The field can't be restored: %s", err))
+	}
+}
+
+// writeField reads the object of the resource, sets its field to value, or
+// removes it, and writes the object back, or its merge patch with merge_patch.
+// The write is conditional on the read, see jsonFieldPrecondition: when the
+// object changed in between, e.g. by another client, the API server answers
+// 412 Precondition Failed and the read-modify-write starts over from the new
+// content rather than clobbering the change. The write is unconditional when
+// the API server sends neither an ETag nor a Last-Modified. A non-empty
+// ifUnmodifiedSince replaces the precondition of the read, its 412 being
+// returned without retry. Returns the object as it was before the write, and
+// the Last-Modified of the write response, empty when not sent.
+func (r *jsonFieldResource) writeField(ctx context.Context, m *jsonFieldResourceModel, value string, remove bool, ifUnmodifiedSince string) (string, string, error) {
+	objectPath := m.Path.ValueString()
+	field := m.Field.ValueString()
+
+	for attempt := 1; ; attempt++ {
+		readOpt := *jsonFieldReadOpt
+		readOpt.ResponseHeader = http.Header{}
+		object, err := r.client.SendJsonRequestWithOpt(ctx, "GET", objectPath, "", &readOpt)
+		if err != nil {
+			return "", "", err
+		}
+
+		var modified string
+		if remove {
+			modified, err = apiclient.RemoveJsonAtPath(object, field)
+		} else {
+			modified, err = apiclient.SetJsonAtPath(object, field, value)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		writeOpt := &apiclient.RequestOpt{Operation: apiclient.OperationUpdate, ResponseHeader: http.Header{}}
+		if m.MergePatch.ValueBool() {
+			if modified, err = apiclient.MergePatch(object, modified); err != nil {
+				return "", "", err
+			}
+			writeOpt.ContentType = apiclient.MergePatchContentType
+		}
+
+		precondition := jsonFieldPrecondition(readOpt.ResponseHeader)
+		if ifUnmodifiedSince != "" {
+			precondition = map[string]string{"If-Unmodified-Since": ifUnmodifiedSince}
+		}
+		writeOpt.Headers = precondition
+		_, err = r.client.SendRequestWithOpt(ctx, r.writeMethod(m), objectPath, modified, writeOpt)
+		if precondition == nil || ifUnmodifiedSince != "" || apiclient.StatusCode(err) != http.StatusPreconditionFailed {
+			return object, writeOpt.ResponseHeader.Get("Last-Modified"), err
+		}
+		if attempt >= jsonFieldWriteAttempts {
+			return "", "", fmt.Errorf("the object %s kept changing during %d read-modify-write attempts: %w", objectPath, attempt, err)
+		}
+	}
+}
+
+// Returns the method writing the object of the resource: PATCH with
+// merge_patch, the provider update method otherwise.
+func (r *jsonFieldResource) writeMethod(m *jsonFieldResourceModel) string {
+	if m.MergePatch.ValueBool() {
+		return "PATCH"
+	}
+	return r.client.UpdateMethod
+}
+
+// Returns the precondition headers of the write of an object read with the
+// response header: If-Match with its ETag, or If-Unmodified-Since with its
+// Last-Modified. A weak ETag is skipped, never matching If-Match. nil when
+// the read response has neither.
+func jsonFieldPrecondition(header http.Header) map[string]string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return map[string]string{"If-Match": etag}
+	}
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		return map[string]string{"If-Unmodified-Since": lastModified}
+	}
+	return nil
+}
+
+// Returns the last_updated of a Last-Modified header, null when empty.
+func lastUpdatedValue(lastModified string) types.String {
+	if lastModified == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(lastModified)
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *jsonFieldResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*apiclient.APIClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *apiclient.APIClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+// jsonValidator checks that a string attribute holds a JSON document. Unknown
+// values are checked at apply time.
+type jsonValidator struct{}
+
+func (v jsonValidator) Description(_ context.Context) string {
+	return "value must be valid JSON"
+}
+
+func (v jsonValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v jsonValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if !json.Valid([]byte(req.ConfigValue.ValueString())) {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid JSON", fmt.Sprintf("The value is not valid JSON: %s", req.ConfigValue.ValueString()))
+	}
+}
@@ -0,0 +1,511 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/apiclient"
+)
+
+// Serves a single JSON object on /configs/main, read with GET and replaced
+// with PUT. The merge patches sent with PATCH are only recorded. beforeWrite can change the object before a given write, like
+// another client.
+type jsonObjectServer struct {
+	mu          sync.Mutex
+	object      string
+	version     int
+	gets        int
+	puts        int
+	beforeWrite func(put int, object string) string
+	patch       string
+	// Validator sent with the object and checked on the writes: "etag" or
+	// "last-modified", none when empty
+	validator string
+	// When set, the status code answering the writes, e.g. 405 Method Not Allowed
+	writeStatus int
+}
+
+// Returns the Last-Modified of the current version of the object.
+func (s *jsonObjectServer) lastModified() string {
+	return time.Unix(1700000000+int64(s.version), 0).UTC().Format(http.TimeFormat)
+}
+
+// Returns whether the precondition of the write holds.
+func (s *jsonObjectServer) preconditionHolds(r *http.Request) bool {
+	switch s.validator {
+	case "etag":
+		return r.Header.Get("If-Match") == fmt.Sprintf(`"v%d"`, s.version)
+	case "last-modified":
+		return r.Header.Get("If-Unmodified-Since") == s.lastModified()
+	}
+	return true
+}
+
+func (s *jsonObjectServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path != "/configs/main" {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case "GET":
+		s.gets++
+		switch s.validator {
+		case "etag":
+			w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, s.version))
+		case "last-modified":
+			w.Header().Set("Last-Modified", s.lastModified())
+		}
+		if _, err := io.WriteString(w, s.object); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case "PUT":
+		if s.writeStatus != 0 {
+			http.Error(w, http.StatusText(s.writeStatus), s.writeStatus)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.puts++
+		if s.beforeWrite != nil {
+			if object := s.beforeWrite(s.puts, s.object); object != s.object {
+				s.object = object
+				s.version++
+			}
+		}
+		if !s.preconditionHolds(r) {
+			http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+			return
+		}
+		s.object = string(body)
+		s.version++
+		if s.validator == "last-modified" {
+			w.Header().Set("Last-Modified", s.lastModified())
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "PATCH":
+		if r.Header.Get("Content-Type") != apiclient.MergePatchContentType {
+			http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+			return
+		}
+		if !s.preconditionHolds(r) {
+			http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.patch = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// Returns the model of the resource managing the field of the object.
+func jsonFieldModel(objectPath string, field string) *jsonFieldResourceModel {
+	return &jsonFieldResourceModel{Path: types.StringValue(objectPath), Field: types.StringValue(field)}
+}
+
+func TestJsonFieldResource_writeField(t *testing.T) {
+	server := &jsonObjectServer{object: `{"features":{"new_ui":false},"owner":"platform"}`, validator: "etag"}
+	svr := httptest.NewServer(server)
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &jsonFieldResource{client: client}
+	ctx := context.Background()
+
+	before, _, err := r.writeField(ctx, jsonFieldModel("/configs/main", "features.new_ui"), "true", false, "")
+	if err != nil {
+		t.Fatalf("writeField returned an error: %s", err)
+	}
+	if before != `{"features":{"new_ui":false},"owner":"platform"}` {
+		t.Errorf("writeField returned the object %s; want the object before the write", before)
+	}
+	if server.object != `{"features":{"new_ui":true},"owner":"platform"}` || server.gets != 1 {
+		t.Errorf("Unexpected object after setting the field with %d read(s): %s", server.gets, server.object)
+	}
+
+	if _, _, err := r.writeField(ctx, jsonFieldModel("/configs/main", "features.new_ui"), "", true, ""); err != nil {
+		t.Fatalf("writeField returned an error on removal: %s", err)
+	}
+	if server.object != `{"features":{},"owner":"platform"}` {
+		t.Errorf("Unexpected object after removing the field: %s", server.object)
+	}
+
+	/* Another client changes the owner between the read and the write */
+	for _, validator := range []string{"etag", "last-modified"} {
+		server.validator = validator
+		server.object = `{"features":{},"owner":"platform"}`
+		changedPut := server.puts + 1
+		server.beforeWrite = func(put int, object string) string {
+			if put == changedPut {
+				return `{"features":{},"owner":"security"}`
+			}
+			return object
+		}
+		if _, _, err := r.writeField(ctx, jsonFieldModel("/configs/main", "features.beta"), `"on"`, false, ""); err != nil {
+			t.Fatalf("writeField returned an error on a concurrent change with the %s: %s", validator, err)
+		}
+		if server.object != `{"features":{"beta":"on"},"owner":"security"}` {
+			t.Errorf("The concurrent change was clobbered with the %s: %s", validator, server.object)
+		}
+	}
+
+	/* The object changes before every write */
+	server.beforeWrite = func(put int, object string) string {
+		value, _ := apiclient.SetJsonAtPath(object, "revision", string(rune('0'+put%10)))
+		return value
+	}
+	gets := server.gets
+	if _, _, err := r.writeField(ctx, jsonFieldModel("/configs/main", "features.beta"), `"off"`, false, ""); apiclient.StatusCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("writeField should fail with the 412 when the object keeps changing, got: %v", err)
+	}
+	if server.gets-gets != jsonFieldWriteAttempts {
+		t.Errorf("writeField read the object %d time(s); want %d", server.gets-gets, jsonFieldWriteAttempts)
+	}
+
+	/* Without validator, the write is unconditional */
+	server.validator = ""
+	if _, _, err := r.writeField(ctx, jsonFieldModel("/configs/main", "features.beta"), `"off"`, false, ""); err != nil {
+		t.Errorf("writeField returned an error without validator: %s", err)
+	}
+
+	if _, _, err := r.writeField(ctx, jsonFieldModel("/configs/missing", "features.beta"), `"off"`, false, ""); apiclient.StatusCode(err) != http.StatusNotFound {
+		t.Errorf("writeField on a missing object should return the 404 error, got: %v", err)
+	}
+}
+
+func TestJsonFieldResource_mergePatch(t *testing.T) {
+	server := &jsonObjectServer{object: `{"features":{"new_ui":false,"beta":"on"},"owner":"platform"}`, validator: "etag"}
+	svr := httptest.NewServer(server)
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &jsonFieldResource{client: client}
+	ctx := context.Background()
+	m := jsonFieldModel("/configs/main", "features.new_ui")
+	m.MergePatch = types.BoolValue(true)
+
+	if _, _, err := r.writeField(ctx, m, "true", false, ""); err != nil {
+		t.Fatalf("writeField returned an error: %s", err)
+	}
+	if server.patch != `{"features":{"new_ui":true}}` || server.puts != 0 {
+		t.Errorf("Unexpected merge patch setting the field: %s", server.patch)
+	}
+	if _, _, err := r.writeField(ctx, m, "", true, ""); err != nil {
+		t.Fatalf("writeField returned an error on removal: %s", err)
+	}
+	if server.patch != `{"features":{"new_ui":null}}` {
+		t.Errorf("Unexpected merge patch removing the field: %s", server.patch)
+	}
+}
+
+func TestJsonFieldResource_ifUnmodifiedSince(t *testing.T) {
+	server := &jsonObjectServer{object: `{"features":{"new_ui":false}}`, validator: "last-modified"}
+	svr := httptest.NewServer(server)
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &jsonFieldResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	/* Refreshes the field set to false, then updates it to value */
+	update := func(value string, ifUnmodifiedSince bool, concurrentChange bool) (*resource.UpdateResponse, jsonFieldResourceModel) {
+		state := tfsdk.State{Schema: schemaResp.Schema}
+		model := jsonFieldResourceModel{
+			Id:                types.StringValue("/configs/main#features.new_ui"),
+			Path:              types.StringValue("/configs/main"),
+			Field:             types.StringValue("features.new_ui"),
+			Value:             types.StringValue("false"),
+			PreviousValue:     types.StringNull(),
+			RecreateOnStatus:  types.ListNull(types.Int64Type),
+			IfUnmodifiedSince: types.BoolValue(ifUnmodifiedSince),
+		}
+		if diags := state.Set(ctx, model); diags.HasError() {
+			t.Fatalf("Setting the state failed: %v", diags)
+		}
+		readResp := &resource.ReadResponse{State: state}
+		r.Read(ctx, resource.ReadRequest{State: state}, readResp)
+		if readResp.Diagnostics.HasError() {
+			t.Fatalf("Read returned errors: %v", readResp.Diagnostics)
+		}
+		if concurrentChange {
+			server.object = `{"features":{"new_ui":false},"owner":"security"}`
+			server.version++
+		}
+
+		plan := tfsdk.Plan{Schema: schemaResp.Schema}
+		model.Value = types.StringValue(value)
+		model.LastUpdated = types.StringUnknown()
+		if diags := plan.Set(ctx, model); diags.HasError() {
+			t.Fatalf("Setting the plan failed: %v", diags)
+		}
+		resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: plan.Raw}}
+		r.Update(ctx, resource.UpdateRequest{Plan: plan, State: readResp.State}, resp)
+		var updated jsonFieldResourceModel
+		resp.State.Get(ctx, &updated)
+		return resp, updated
+	}
+
+	resp, updated := update("true", true, false)
+	if resp.Diagnostics.HasError() || server.object != `{"features":{"new_ui":true}}` {
+		t.Fatalf("Update returned the diagnostics %v, the object being %s", resp.Diagnostics, server.object)
+	}
+	if updated.LastUpdated.ValueString() != server.lastModified() {
+		t.Errorf("last_updated is %s; want the Last-Modified of the write %s", updated.LastUpdated, server.lastModified())
+	}
+
+	/* The change since the refresh fails the update without retry */
+	server.object = `{"features":{"new_ui":false}}`
+	puts := server.puts
+	resp, _ = update("true", true, true)
+	if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Update conflict" || server.puts != puts+1 {
+		t.Errorf("Update should fail with a conflict after a single write, got the diagnostics %v after %d write(s)", resp.Diagnostics, server.puts-puts)
+	}
+	if server.object != `{"features":{"new_ui":false},"owner":"security"}` {
+		t.Errorf("The concurrent change was clobbered: %s", server.object)
+	}
+
+	/* Without if_unmodified_since, only the read preceding the write is checked */
+	resp, _ = update("true", false, true)
+	if resp.Diagnostics.HasError() || server.object != `{"features":{"new_ui":true},"owner":"security"}` {
+		t.Errorf("Update returned the diagnostics %v, the object being %s", resp.Diagnostics, server.object)
+	}
+}
+
+func TestJsonFieldPrecondition(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		want   map[string]string
+	}{
+		{http.Header{"Etag": {`"v1"`}, "Last-Modified": {"Tue, 14 Nov 2023 22:13:20 GMT"}}, map[string]string{"If-Match": `"v1"`}},
+		{http.Header{"Etag": {`W/"v1"`}, "Last-Modified": {"Tue, 14 Nov 2023 22:13:20 GMT"}}, map[string]string{"If-Unmodified-Since": "Tue, 14 Nov 2023 22:13:20 GMT"}},
+		{http.Header{"Etag": {`W/"v1"`}}, nil},
+		{http.Header{}, nil},
+	}
+
+	for _, test := range tests {
+		if got := jsonFieldPrecondition(test.header); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("jsonFieldPrecondition(%v) = %v; want %v", test.header, got, test.want)
+		}
+	}
+}
+
+func TestJsonFieldResource_methodNotAllowed(t *testing.T) {
+	server := &jsonObjectServer{object: `{"features":{"new_ui":false}}`, writeStatus: http.StatusMethodNotAllowed}
+	svr := httptest.NewServer(server)
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &jsonFieldResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	/* Returns the state of the field set to true, ignoring the 405 on both operations or not */
+	resourceValue := func(ignore bool) tfsdk.State {
+		state := tfsdk.State{Schema: schemaResp.Schema}
+		diags := state.Set(ctx, jsonFieldResourceModel{
+			Id:                            types.StringValue("/configs/main#features.new_ui"),
+			Path:                          types.StringValue("/configs/main"),
+			Field:                         types.StringValue("features.new_ui"),
+			Value:                         types.StringValue("true"),
+			PreviousValue:                 types.StringValue("false"),
+			IgnoreUpdateMethodNotAllowed:  types.BoolValue(ignore),
+			IgnoreDestroyMethodNotAllowed: types.BoolValue(ignore),
+			RecreateOnStatus:              types.ListNull(types.Int64Type),
+		})
+		if diags.HasError() {
+			t.Fatalf("Setting the state failed: %v", diags)
+		}
+		return state
+	}
+
+	for _, ignore := range []bool{false, true} {
+		warnings := 0
+		if ignore {
+			warnings = 1
+		}
+		value := resourceValue(ignore)
+		updateResp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: value.Raw}}
+		r.Update(ctx, resource.UpdateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: value.Raw}, State: value}, updateResp)
+		if updateResp.Diagnostics.HasError() == ignore || updateResp.Diagnostics.WarningsCount() != warnings {
+			t.Errorf("Update with ignore=%t returned the diagnostics: %v", ignore, updateResp.Diagnostics)
+		}
+
+		deleteResp := &resource.DeleteResponse{State: value}
+		r.Delete(ctx, resource.DeleteRequest{State: value}, deleteResp)
+		if deleteResp.Diagnostics.HasError() == ignore || deleteResp.Diagnostics.WarningsCount() != warnings {
+			t.Errorf("Delete with ignore=%t returned the diagnostics: %v", ignore, deleteResp.Diagnostics)
+		}
+	}
+}
+
+func TestJsonFieldResource_recreateOnStatus(t *testing.T) {
+	server := &jsonObjectServer{object: `{"features":{"new_ui":false}}`, writeStatus: http.StatusConflict}
+	svr := httptest.NewServer(server)
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &jsonFieldResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	state := tfsdk.State{Schema: schemaResp.Schema}
+	diags := state.Set(ctx, jsonFieldResourceModel{
+		Id:                            types.StringValue("/configs/main#features.new_ui"),
+		Path:                          types.StringValue("/configs/main"),
+		Field:                         types.StringValue("features.new_ui"),
+		Value:                         types.StringValue("true"),
+		PreviousValue:                 types.StringValue("false"),
+		IgnoreUpdateMethodNotAllowed:  types.BoolNull(),
+		IgnoreDestroyMethodNotAllowed: types.BoolNull(),
+		RecreateOnStatus:              types.ListValueMust(types.Int64Type, []attr.Value{types.Int64Value(http.StatusConflict)}),
+	})
+	if diags.HasError() {
+		t.Fatalf("Setting the state failed: %v", diags)
+	}
+	update := func() *resource.UpdateResponse {
+		resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state.Raw}}
+		/* The private state type is internal to the framework, which initializes it */
+		private := reflect.ValueOf(resp).Elem().FieldByName("Private")
+		private.Set(reflect.New(private.Type().Elem()))
+		r.Update(ctx, resource.UpdateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: state.Raw}, State: state}, resp)
+		return resp
+	}
+
+	/* The 409 succeeds with a warning, the next refresh removing the resource */
+	updateResp := update()
+	if updateResp.Diagnostics.HasError() || updateResp.Diagnostics.WarningsCount() != 1 {
+		t.Fatalf("Update returned the diagnostics: %v", updateResp.Diagnostics)
+	}
+	readResp := &resource.ReadResponse{State: updateResp.State}
+	r.Read(ctx, resource.ReadRequest{State: updateResp.State, Private: updateResp.Private}, readResp)
+	if readResp.Diagnostics.HasError() || !readResp.State.Raw.IsNull() {
+		t.Errorf("Read should remove the resource to re-create, got the diagnostics %v", readResp.Diagnostics)
+	}
+
+	/* Another conflict status still fails */
+	server.writeStatus = http.StatusPreconditionFailed
+	if updateResp := update(); !updateResp.Diagnostics.HasError() {
+		t.Error("Update should fail on a status out of recreate_on_status")
+	}
+}
+
+func TestJsonValidator(t *testing.T) {
+	tests := []struct {
+		value types.String
+		fails bool
+	}{
+		{types.StringValue(`{"a":[1,true,null]}`), false},
+		{types.StringValue(`"text"`), false},
+		{types.StringValue(`{"a":`), true},
+		{types.StringNull(), false},
+		{types.StringUnknown(), false},
+	}
+
+	for _, test := range tests {
+		resp := &validator.StringResponse{}
+		jsonValidator{}.ValidateString(context.Background(), validator.StringRequest{Path: path.Root("value"), ConfigValue: test.value}, resp)
+		if resp.Diagnostics.HasError() != test.fails {
+			t.Errorf("jsonValidator(%s) returned errors: %t; want %t", test.value, resp.Diagnostics.HasError(), test.fails)
+		}
+	}
+}
+
+func TestJsonFieldResource_ignoreAddedKeys(t *testing.T) {
+	server := &jsonObjectServer{}
+	svr := httptest.NewServer(server)
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	r := &jsonFieldResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	configured := `{"enabled": true}`
+	read := func(object string, ignoreAddedKeys bool) string {
+		server.object = object
+		state := tfsdk.State{Schema: schemaResp.Schema}
+		diags := state.Set(ctx, jsonFieldResourceModel{
+			Id:               types.StringValue("/configs/main#features.new_ui"),
+			Path:             types.StringValue("/configs/main"),
+			Field:            types.StringValue("features.new_ui"),
+			Value:            types.StringValue(configured),
+			PreviousValue:    types.StringNull(),
+			IgnoreAddedKeys:  types.BoolValue(ignoreAddedKeys),
+			RecreateOnStatus: types.ListNull(types.Int64Type),
+		})
+		if diags.HasError() {
+			t.Fatalf("Setting the state failed: %v", diags)
+		}
+		resp := &resource.ReadResponse{State: state}
+		r.Read(ctx, resource.ReadRequest{State: state}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("Read returned errors: %v", resp.Diagnostics)
+		}
+		var refreshed jsonFieldResourceModel
+		resp.State.Get(ctx, &refreshed)
+		return refreshed.Value.ValueString()
+	}
+
+	/* A default added by the API server shows no diff in this mode only */
+	addedDefault := `{"features":{"new_ui":{"enabled":true,"rollout":100}}}`
+	if value := read(addedDefault, true); value != configured {
+		t.Errorf("An added default should keep the configured value, got %s", value)
+	}
+	if value := read(addedDefault, false); value != `{"enabled":true,"rollout":100}` {
+		t.Errorf("An added default should be refreshed without ignore_added_keys, got %s", value)
+	}
+
+	/* A changed value always shows a diff */
+	if value := read(`{"features":{"new_ui":{"enabled":false,"rollout":100}}}`, true); value != `{"enabled":false,"rollout":100}` {
+		t.Errorf("A changed value should be refreshed, got %s", value)
+	}
+	if value := read(`{"features":{"new_ui":{}}}`, true); value != `{}` {
+		t.Errorf("A removed key should be refreshed, got %s", value)
+	}
+}
@@ -0,0 +1,235 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/apiclient"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &listFileDataSource{}
+	_ datasource.DataSourceWithConfigure = &listFileDataSource{}
+)
+
+// listFileDataSource reads all the pages of a list endpoint into a local
+// file, keeping large collections out of the Terraform state.
+type listFileDataSource struct {
+	client *apiclient.APIClient
+}
+
+// listFileDataSourceModel maps the data source schema data.
+type listFileDataSourceModel struct {
+	Path        types.String `tfsdk:"path"`
+	OutputPath  types.String `tfsdk:"output_path"`
+	QueryString types.String `tfsdk:"query_string"`
+	ResultsKey  types.String `tfsdk:"results_key"`
+	NextKey     types.String `tfsdk:"next_key"`
+	TotalKey    types.String `tfsdk:"total_key"`
+	MaxPages    types.Int64  `tfsdk:"max_pages"`
+	Method      types.String `tfsdk:"method"`
+	Body        types.String `tfsdk:"body"`
+	Count       types.Int64  `tfsdk:"count"`
+	Sha256      types.String `tfsdk:"sha256"`
+}
+
+// NewListFileDataSource is a helper function to simplify the provider implementation.
+func NewListFileDataSource() datasource.DataSource {
+	return &listFileDataSource{}
+}
+
+// Metadata returns the data source type name.
+func (d *listFileDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_list_file"
+}
+
+// Schema defines the schema for the data source.
+func (d *listFileDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Data source writing all the objects of a list endpoint, following its pages, to a local file as a JSON array, for collections too large to keep in the Terraform state. " +
+			"The pages are written as they are read, and only the number of objects and the checksum of the file are kept in the state.",
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Description: "The API path, on top of the base URL set in the provider, of the list or search endpoint.",
+				Required:    true,
+			},
+			"output_path": schema.StringAttribute{
+				Description: "Path of the local file receiving the JSON array of the objects, e.g. `${path.module}/users.json`, written with the `0600` permissions. " +
+					"The file is replaced on each read once the whole list is read, and left as is when the read fails.",
+				Required: true,
+			},
+			"query_string": schema.StringAttribute{
+				Description: "Query string appended to the path of the first page, e.g. `type=user&limit=500`.",
+				Optional:    true,
+			},
+			"results_key": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the objects array in the pages, e.g. `data.items`, relative to the provider `response_root`. Defaults to the page itself, or its `response_root`.",
+				Optional:    true,
+			},
+			"next_key": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the link to the next page in the pages, e.g. `links.next`, an absolute URL under the provider `uri` or a path. The page without this link is the last one. Defaults to a single page.",
+				Optional:    true,
+			},
+			"total_key": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the total number of objects reported in the first page, e.g. `meta.total`. A total differing from `count` raises a warning.",
+				Optional:    true,
+			},
+			"max_pages": schema.Int64Attribute{
+				Description: fmt.Sprintf("Maximum number of pages read, stopping the pagination when the next page link never clears. Reaching it raises a warning. Defaults to %d.", apiclient.DefaultMaxPages),
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"method": schema.StringAttribute{
+				Description: "HTTP method of the page requests, `GET` or `POST` for the search endpoints taking a query in their body. Defaults to `GET`.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("GET", "POST"),
+				},
+			},
+			"body": schema.StringAttribute{
+				Description: "JSON body of the page requests, e.g. the query of a POST search endpoint, sent with the `application/json` content type. Defaults to no body.",
+				Optional:    true,
+				Validators: []validator.String{
+					jsonValidator{},
+				},
+			},
+			"count": schema.Int64Attribute{
+				Description: "The number of objects written to the file.",
+				Computed:    true,
+			},
+			"sha256": schema.StringAttribute{
+				Description: "The hex-encoded SHA-256 checksum of the file, changing with the listed objects.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// Read writes the list to the output file.
+func (d *listFileDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config listFileDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	requestPath, err := apiclient.WithQuery(config.Path.ValueString(), config.QueryString.ValueString(), nil)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("query_string"), "Invalid query string", err.Error())
+		return
+	}
+
+	/* results_key is relative to the provider response_root */
+	itemsKey := strings.Trim(d.client.ResponseRoot+"."+config.ResultsKey.ValueString(), ".")
+	list, checksum, err := d.writeList(ctx, requestPath, &apiclient.ListOpt{
+		ItemsKey: itemsKey,
+		NextKey:  config.NextKey.ValueString(),
+		TotalKey: config.TotalKey.ValueString(),
+		MaxPages: int(config.MaxPages.ValueInt64()),
+		Method:   config.Method.ValueString(),
+		Body:     config.Body.ValueString(),
+	}, config.OutputPath.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("List read error", fmt.Sprintf("The list of the path %s can't be written to %s: %s", requestPath, config.OutputPath.ValueString(), err))
+		return
+	}
+	if list.Truncated {
+		resp.Diagnostics.AddAttributeWarning(path.Root("max_pages"), "Truncated list", fmt.Sprintf("The pagination stopped after %d pages while a next page remained: the file holds the first %d objects only.", list.Pages, list.Count))
+	}
+	if list.TotalMismatch() {
+		resp.Diagnostics.AddAttributeWarning(path.Root("total_key"), "Incomplete list", fmt.Sprintf("The API reported %d objects but %d were read.", list.Total, list.Count))
+	}
+
+	config.Count = types.Int64Value(list.Count)
+	config.Sha256 = types.StringValue(checksum)
+	resp.Diagnostics.Append(resp.State.Set(ctx, config)...)
+}
+
+// writeList streams the objects of the pages to outputPath as a JSON array
+// and returns the listing result and the hex-encoded SHA-256 of the file. The
+// array is written to a temporary file of the same directory, renamed to
+// outputPath once complete.
+func (d *listFileDataSource) writeList(ctx context.Context, requestPath string, opt *apiclient.ListOpt, outputPath string) (*apiclient.ListResult, string, error) {
+	file, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*")
+	if err != nil {
+		return nil, "", err
+	}
+	/* Without effect once the file is renamed */
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	writer := bufio.NewWriter(io.MultiWriter(file, hash))
+	separator := ""
+	if _, err := writer.WriteString("["); err != nil {
+		return nil, "", err
+	}
+	list, err := d.client.WalkPages(ctx, requestPath, opt, func(items []any) error {
+		for _, item := range items {
+			itemJson, err := apiclient.JsonMarshal(item)
+			if err != nil {
+				return err
+			}
+			if _, err := writer.WriteString(separator); err != nil {
+				return err
+			}
+			if _, err := writer.Write(itemJson); err != nil {
+				return err
+			}
+			separator = ","
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := writer.WriteString("]\n"); err != nil {
+		return nil, "", err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, "", err
+	}
+	if err := file.Close(); err != nil {
+		return nil, "", err
+	}
+	if err := os.Rename(file.Name(), outputPath); err != nil {
+		return nil, "", err
+	}
+	return list, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *listFileDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*apiclient.APIClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *apiclient.APIClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
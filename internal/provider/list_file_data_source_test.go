@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/apiclient"
+)
+
+func TestListFileDataSource_Read(t *testing.T) {
+	/* Three pages of users linked by next, the numbers being kept as written */
+	pages := map[string]string{
+		"1": `{"data":{"items":[{"id":1,"name":"alice"},{"id":2,"name":"bob"}]},"links":{"next":"/api/users?page=2"},"meta":{"total":5}}`,
+		"2": `{"data":{"items":[{"id":3,"name":"carol","quota":12345678901234567890}]},"links":{"next":"%s/api/users?page=3"}}`,
+		"3": `{"data":{"items":[{"id":4,"name":"dave"},{"id":5,"name":"erin"}]},"links":{"next":null}}`,
+	}
+	var svrURL string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/empty":
+			fmt.Fprint(w, `{"data":{"items":[]}}`)
+		case r.URL.Path == "/api/users" && r.URL.Query().Get("page") == "":
+			fmt.Fprint(w, pages["1"])
+		case r.URL.Path == "/api/users" && r.URL.Query().Get("page") == "2":
+			fmt.Fprintf(w, pages["2"], svrURL)
+		case r.URL.Path == "/api/users" && r.URL.Query().Get("page") == "3":
+			fmt.Fprint(w, pages["3"])
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}))
+	defer svr.Close()
+	svrURL = svr.URL
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	d := &listFileDataSource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	outputPath := filepath.Join(t.TempDir(), "users.json")
+	read := func(attributes map[string]tftypes.Value) (*datasource.ReadResponse, listFileDataSourceModel) {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/users")
+		values["output_path"] = tftypes.NewValue(tftypes.String, outputPath)
+		values["results_key"] = tftypes.NewValue(tftypes.String, "data.items")
+		values["next_key"] = tftypes.NewValue(tftypes.String, "links.next")
+		for name, value := range attributes {
+			values[name] = value
+		}
+		raw := tftypes.NewValue(objectType, values)
+
+		resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+		d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+		var state listFileDataSourceModel
+		resp.State.Get(ctx, &state)
+		return resp, state
+	}
+	checkFile := func(expected string, state listFileDataSourceModel) {
+		t.Helper()
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("The output file can't be read: %s", err)
+		}
+		if equal, err := apiclient.JsonEqual(string(content), expected); err != nil || !equal {
+			t.Errorf("Unexpected output file: %s (%v)", content, err)
+		}
+		checksum := sha256.Sum256(content)
+		if state.Sha256.ValueString() != hex.EncodeToString(checksum[:]) {
+			t.Errorf("The sha256 %s is not the checksum of the output file", state.Sha256)
+		}
+	}
+
+	resp, state := read(map[string]tftypes.Value{"total_key": tftypes.NewValue(tftypes.String, "meta.total")})
+	if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() > 0 {
+		t.Fatalf("Read returned diagnostics: %v", resp.Diagnostics)
+	}
+	if state.Count.ValueInt64() != 5 {
+		t.Errorf("Unexpected count: %s; want 5", state.Count)
+	}
+	checkFile(`[{"id":1,"name":"alice"},{"id":2,"name":"bob"},{"id":3,"name":"carol","quota":12345678901234567890},{"id":4,"name":"dave"},{"id":5,"name":"erin"}]`, state)
+	content, _ := os.ReadFile(outputPath)
+	if !strings.Contains(string(content), "12345678901234567890") {
+		t.Errorf("The numbers should be written as listed: %s", content)
+	}
+	fullChecksum := state.Sha256.ValueString()
+
+	/* Stopped after 2 pages, the file holds the first 3 users with a warning */
+	resp, state = read(map[string]tftypes.Value{"max_pages": tftypes.NewValue(tftypes.Number, 2)})
+	if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+		t.Fatalf("Read should return a single truncation warning: %v", resp.Diagnostics)
+	}
+	if state.Count.ValueInt64() != 3 || state.Sha256.ValueString() == fullChecksum {
+		t.Errorf("Unexpected truncated list: count=%s sha256=%s", state.Count, state.Sha256)
+	}
+	checkFile(`[{"id":1,"name":"alice"},{"id":2,"name":"bob"},{"id":3,"name":"carol","quota":12345678901234567890}]`, state)
+
+	resp, state = read(map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "/api/empty")})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", resp.Diagnostics)
+	}
+	if state.Count.ValueInt64() != 0 {
+		t.Errorf("Unexpected count of the empty list: %s", state.Count)
+	}
+	checkFile(`[]`, state)
+
+	/* A failed read leaves the previous file as is, without temporary file */
+	resp, _ = read(map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "/api/failing")})
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("Read should fail on a 500")
+	}
+	if content, _ := os.ReadFile(outputPath); string(content) != "[]\n" {
+		t.Errorf("The output file changed on a failed read: %s", content)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(outputPath)); len(entries) != 1 {
+		t.Errorf("Unexpected files left in the output directory: %v", entries)
+	}
+}
@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/apiclient"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &objectDataSource{}
+	_ datasource.DataSourceWithConfigure = &objectDataSource{}
+)
+
+// objectDataSource looks an existing object up by a natural key, e.g. its
+// name, to reference its id from other resources.
+type objectDataSource struct {
+	client *apiclient.APIClient
+}
+
+// objectDataSourceModel maps the data source schema data.
+type objectDataSourceModel struct {
+	Path        types.String `tfsdk:"path"`
+	SearchKey   types.String `tfsdk:"search_key"`
+	SearchValue types.String `tfsdk:"search_value"`
+	FilterParam types.String `tfsdk:"filter_param"`
+	QueryString types.String `tfsdk:"query_string"`
+	ResultsKey  types.String `tfsdk:"results_key"`
+	IdAttribute types.String `tfsdk:"id_attribute"`
+	Method      types.String `tfsdk:"method"`
+	Body        types.String `tfsdk:"body"`
+	Id          types.String `tfsdk:"id"`
+	Data        types.String `tfsdk:"data"`
+}
+
+// NewObjectDataSource is a helper function to simplify the provider implementation.
+func NewObjectDataSource() datasource.DataSource {
+	return &objectDataSource{}
+}
+
+// Metadata returns the data source type name.
+func (d *objectDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_object"
+}
+
+// Schema defines the schema for the data source.
+func (d *objectDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Data source resolving an existing object by a natural key, e.g. its name, to its id and data. " +
+			"The list endpoint is read with the filter in its query, and the single object having the searched value is picked out of the response. " +
+			"Zero or several matching objects are an error.",
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Description: "The API path, on top of the base URL set in the provider, of the list or search endpoint.",
+				Required:    true,
+			},
+			"search_key": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the natural key in the listed objects, e.g. `name` or `meta.name`.",
+				Required:    true,
+			},
+			"search_value": schema.StringAttribute{
+				Description: "Value of the natural key of the object.",
+				Required:    true,
+			},
+			"filter_param": schema.StringAttribute{
+				Description: "Name of the query parameter, set to `search_value`, filtering the list on the API server. Defaults to `search_key`. " +
+					"An empty string sends no filter, the objects being matched by the provider only.",
+				Optional: true,
+			},
+			"query_string": schema.StringAttribute{
+				Description: "Query string appended to the path, e.g. `type=group&limit=500`.",
+				Optional:    true,
+			},
+			"results_key": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the objects array in the response, e.g. `data.items`, relative to the provider `response_root`. Defaults to the response itself, or its `response_root`.",
+				Optional:    true,
+			},
+			"id_attribute": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the id in the matching object. Defaults to the provider `id_attribute`.",
+				Optional:    true,
+			},
+			"method": schema.StringAttribute{
+				Description: "HTTP method of the request, `GET` or `POST` for the search endpoints taking a query in their body. Defaults to `GET`.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("GET", "POST"),
+				},
+			},
+			"body": schema.StringAttribute{
+				Description: "JSON body of the request, e.g. the query of a POST search endpoint, sent with the `application/json` content type. Defaults to no body.",
+				Optional:    true,
+				Validators: []validator.String{
+					jsonValidator{},
+				},
+			},
+			"id": schema.StringAttribute{
+				Description: "The id of the matching object.",
+				Computed:    true,
+			},
+			"data": schema.StringAttribute{
+				Description: "JSON encoded data of the matching object.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// Read looks the object up.
+func (d *objectDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config objectDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filterParam := config.SearchKey.ValueString()
+	if !config.FilterParam.IsNull() {
+		filterParam = config.FilterParam.ValueString()
+	}
+	params := map[string]string{}
+	if filterParam != "" {
+		params[filterParam] = config.SearchValue.ValueString()
+	}
+	requestPath, err := apiclient.WithQuery(config.Path.ValueString(), config.QueryString.ValueString(), params)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("query_string"), "Invalid query string", err.Error())
+		return
+	}
+
+	/* results_key is relative to the provider response_root */
+	itemsKey := strings.Trim(d.client.ResponseRoot+"."+config.ResultsKey.ValueString(), ".")
+	list, err := d.client.ListObjects(ctx, requestPath, &apiclient.ListOpt{
+		ItemsKey: itemsKey,
+		MaxPages: 1,
+		Method:   config.Method.ValueString(),
+		Body:     config.Body.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Read request error", fmt.Sprintf("Read request returned the error: %s on the path: %s", err, requestPath))
+		return
+	}
+	items, err := json.Marshal(list.Items)
+	if err != nil {
+		resp.Diagnostics.AddError("Read response error", fmt.Sprintf("The objects can't be encoded into JSON: %s", err))
+		return
+	}
+
+	predicate := apiclient.JsonPredicate{Path: config.SearchKey.ValueString(), Value: config.SearchValue.ValueString()}
+	object, err := predicate.SelectElement(string(items))
+	if err != nil {
+		resp.Diagnostics.AddError("Object lookup error", fmt.Sprintf("The object can't be resolved on the path %s: %s", requestPath, err))
+		return
+	}
+
+	idAttribute := d.client.IdAttribute
+	if !config.IdAttribute.IsNull() {
+		idAttribute = config.IdAttribute.ValueString()
+	}
+	id, err := objectId(ctx, object, idAttribute)
+	if err != nil {
+		resp.Diagnostics.AddError("Object lookup error", fmt.Sprintf("The id of the object can't be read: %s", err))
+		return
+	}
+
+	config.Id = types.StringValue(id)
+	config.Data = types.StringValue(object)
+	resp.Diagnostics.Append(resp.State.Set(ctx, config)...)
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *objectDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*apiclient.APIClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *apiclient.APIClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/apiclient"
+)
+
+func TestObjectDataSource_Read(t *testing.T) {
+	/* Lists the groups, filtered by name prefix like a search endpoint, from
+	   the query string or from the JSON body of a POST search */
+	groups := []string{
+		`{"id":7,"name":"admins","meta":{"region":"eu"}}`,
+		`{"id":"g-8","name":"admins-eu","meta":{"region":"eu"}}`,
+		`{"id":"g-9","name":"auditors","meta":{"region":"us"}}`,
+	}
+	var queries []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if r.URL.Path == "/api/groups/search" && r.Method == "POST" && r.Header.Get("Content-Type") == "application/json" {
+			var query struct {
+				Name string `json:"name"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			name = query.Name
+		} else if r.URL.Path != "/api/groups" || r.Method != "GET" {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		queries = append(queries, r.URL.RawQuery)
+		var matches []string
+		for _, group := range groups {
+			if strings.Contains(group, `"name":"`+name) {
+				matches = append(matches, group)
+			}
+		}
+		fmt.Fprintf(w, `{"data":{"items":[%s]}}`, strings.Join(matches, ","))
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 100})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+	d := &objectDataSource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	read := func(attributes map[string]string) (*datasource.ReadResponse, objectDataSourceModel) {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+		}
+		values["path"] = tftypes.NewValue(tftypes.String, "/api/groups")
+		values["results_key"] = tftypes.NewValue(tftypes.String, "data.items")
+		for name, value := range attributes {
+			values[name] = tftypes.NewValue(tftypes.String, value)
+		}
+		raw := tftypes.NewValue(objectType, values)
+
+		resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+		d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+		var state objectDataSourceModel
+		resp.State.Get(ctx, &state)
+		return resp, state
+	}
+
+	/* The server returns admins and admins-eu, the exact match is picked */
+	resp, state := read(map[string]string{"search_key": "name", "search_value": "admins"})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", resp.Diagnostics)
+	}
+	if equal, _ := apiclient.JsonEqual(state.Data.ValueString(), groups[0]); state.Id.ValueString() != "7" || !equal {
+		t.Errorf("Unexpected lookup result: id=%s data=%s", state.Id, state.Data)
+	}
+	if queries[len(queries)-1] != "name=admins" {
+		t.Errorf("Unexpected query: %s", queries[len(queries)-1])
+	}
+
+	resp, state = read(map[string]string{"search_key": "name", "search_value": "auditors", "query_string": "limit=500", "id_attribute": "id"})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", resp.Diagnostics)
+	}
+	if state.Id.ValueString() != "g-9" {
+		t.Errorf("Unexpected id: %s", state.Id)
+	}
+	if queries[len(queries)-1] != "limit=500&name=auditors" {
+		t.Errorf("Unexpected query: %s", queries[len(queries)-1])
+	}
+
+	/* Without server filter, the objects are matched by the provider only */
+	resp, state = read(map[string]string{"search_key": "meta.region", "search_value": "us", "filter_param": ""})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", resp.Diagnostics)
+	}
+	if state.Id.ValueString() != "g-9" || queries[len(queries)-1] != "" {
+		t.Errorf("Unexpected lookup result: id=%s query=%s", state.Id, queries[len(queries)-1])
+	}
+
+	resp, state = read(map[string]string{
+		"path":         "/api/groups/search",
+		"method":       "POST",
+		"body":         `{"name":"admins-"}`,
+		"search_key":   "meta.region",
+		"search_value": "eu",
+		"filter_param": "",
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors: %v", resp.Diagnostics)
+	}
+	if state.Id.ValueString() != "g-8" {
+		t.Errorf("Unexpected id of the POST search: %s", state.Id)
+	}
+
+	for _, attributes := range []map[string]string{
+		{"search_key": "name", "search_value": "readers"},
+		{"search_key": "meta.region", "search_value": "eu", "filter_param": ""},
+		{"search_key": "name", "search_value": "admins", "id_attribute": "meta"},
+	} {
+		if resp, _ := read(attributes); !resp.Diagnostics.HasError() {
+			t.Errorf("Read should fail with the attributes %v", attributes)
+		}
+	}
+	/* With the provider response_root, results_key is relative to it */
+	client.ResponseRoot = "data"
+	resp, state = read(map[string]string{"search_key": "name", "search_value": "auditors", "results_key": "items"})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Read returned errors with response_root: %v", resp.Diagnostics)
+	}
+	if state.Id.ValueString() != "g-9" {
+		t.Errorf("Unexpected id with response_root: %s", state.Id)
+	}
+}
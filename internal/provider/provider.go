@@ -3,17 +3,29 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
@@ -21,7 +33,12 @@ import (
 	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/envvar"
 )
 
-var _ provider.Provider = &TrustbuilderProvider{}
+var (
+	_ provider.Provider                   = &TrustbuilderProvider{}
+	_ provider.ProviderWithValidateConfig = &TrustbuilderProvider{}
+)
+
+const defaultVersionHeaderName = "X-Terraform-Provider-Version"
 
 // Defines the provider implementation.
 type TrustbuilderProvider struct {
@@ -29,6 +46,10 @@ type TrustbuilderProvider struct {
 	// provider is built and ran locally, and "test" when running acceptance
 	// testing.
 	version string
+	// vaultSecrets caches the secrets read from Vault by address, path and
+	// key, so that they are read once per provider instance.
+	vaultSecrets   map[string]string
+	vaultSecretsMu sync.Mutex
 }
 
 func New(version string) func() provider.Provider {
@@ -41,12 +62,62 @@ func New(version string) func() provider.Provider {
 
 // Describes the provider data model.
 type TrustbuilderProviderModel struct {
-	URI            types.String `tfsdk:"uri"`
-	Headers        types.Map    `tfsdk:"headers"`
-	JwtHashedToken types.Object `tfsdk:"jwt_hashed_token"`
-	Timeout        types.Int64  `tfsdk:"timeout"`
-	TestPath       types.String `tfsdk:"test_path"`
-	Debug          types.Bool   `tfsdk:"debug"`
+	URI                         types.String `tfsdk:"uri"`
+	Headers                     types.Map    `tfsdk:"headers"`
+	AcceptLanguage              types.String `tfsdk:"accept_language"`
+	IdempotencyKeyHeader        types.String `tfsdk:"idempotency_key_header"`
+	StatusMessages              types.Map    `tfsdk:"status_messages"`
+	CreateExpectedStatus        types.List   `tfsdk:"create_expected_status"`
+	ReadExpectedStatus          types.List   `tfsdk:"read_expected_status"`
+	UpdateExpectedStatus        types.List   `tfsdk:"update_expected_status"`
+	DestroyExpectedStatus       types.List   `tfsdk:"destroy_expected_status"`
+	JwtHashedToken              types.Object `tfsdk:"jwt_hashed_token"`
+	OauthRefreshToken           types.Object `tfsdk:"oauth_refresh_token"`
+	Vault                       types.Object `tfsdk:"vault"`
+	Login                       types.Object `tfsdk:"login"`
+	ReauthOn401                 types.Bool   `tfsdk:"reauth_on_401"`
+	AuthHeaderName              types.String `tfsdk:"auth_header_name"`
+	AuthHeaderPrefix            types.String `tfsdk:"auth_header_prefix"`
+	UseNetrc                    types.Bool   `tfsdk:"use_netrc"`
+	NetrcFile                   types.String `tfsdk:"netrc_file"`
+	Pkcs12File                  types.String `tfsdk:"pkcs12_file"`
+	Pkcs12Password              types.String `tfsdk:"pkcs12_password"`
+	CertReload                  types.Bool   `tfsdk:"cert_reload"`
+	Http2PriorKnowledge         types.Bool   `tfsdk:"http2_prior_knowledge"`
+	PinnedCertSha256            types.String `tfsdk:"pinned_cert_sha256"`
+	PinnedCertOnly              types.Bool   `tfsdk:"pinned_cert_only"`
+	Timeout                     types.Int64  `tfsdk:"timeout"`
+	TestPath                    types.String `tfsdk:"test_path"`
+	TestPaths                   types.List   `tfsdk:"test_paths"`
+	CreateReturnsObject         types.Bool   `tfsdk:"create_returns_object"`
+	StripHeadersOnRedirect      types.List   `tfsdk:"strip_headers_on_redirect"`
+	RestrictRedirectsToSameHost types.Bool   `tfsdk:"restrict_redirects_to_same_host"`
+	FollowRedirects             types.Bool   `tfsdk:"follow_redirects"`
+	MaxConcurrentRequests       types.Int64  `tfsdk:"max_concurrent_requests"`
+	JsonDecodeRetries           types.Int64  `tfsdk:"json_decode_retries"`
+	JsonEscapeHTML              types.Bool   `tfsdk:"json_escape_html"`
+	MaxResponseSize             types.Int64  `tfsdk:"max_response_size"`
+	CompressRequestMinBytes     types.Int64  `tfsdk:"compress_request_min_bytes"`
+	ResponseErrorsPath          types.String `tfsdk:"response_errors_path"`
+	ResponseFormat              types.String `tfsdk:"response_format"`
+	ResponseRoot                types.String `tfsdk:"response_root"`
+	AppendTrailingSlash         types.Bool   `tfsdk:"append_trailing_slash"`
+	TraceHttp                   types.Bool   `tfsdk:"trace_http"`
+	IdentifierQueryParam        types.String `tfsdk:"identifier_query_param"`
+	IdAttribute                 types.String `tfsdk:"id_attribute"`
+	DefaultPath                 types.String `tfsdk:"default_path"`
+	MaxRetries                  types.Int64  `tfsdk:"max_retries"`
+	RetryOn                     types.List   `tfsdk:"retry_on"`
+	RetryJitter                 types.String `tfsdk:"retry_jitter"`
+	RetryMaxWait                types.Int64  `tfsdk:"retry_max_wait"`
+	RetryMaxElapsedTime         types.Int64  `tfsdk:"retry_max_elapsed_time"`
+	FailFast                    types.Bool   `tfsdk:"fail_fast"`
+	VersionHeaderName           types.String `tfsdk:"version_header_name"`
+	DisableVersionHeaders       types.Bool   `tfsdk:"disable_version_headers"`
+	Debug                       types.Bool   `tfsdk:"debug"`
+	DebugLogFile                types.String `tfsdk:"debug_log_file"`
+	MaxLogBodyBytes             types.Int64  `tfsdk:"max_log_body_bytes"`
+	RedactLogBodies             types.Bool   `tfsdk:"redact_log_bodies"`
 }
 
 type JwtHashedTokenModel struct {
@@ -54,6 +125,47 @@ type JwtHashedTokenModel struct {
 	Secret                 types.String `tfsdk:"secret"`
 	Algorithm              types.String `tfsdk:"algorithm"`
 	ValidityDurationMinute types.Int64  `tfsdk:"validity_duration_minute"`
+	PrivateKey             types.String `tfsdk:"private_key"`
+	Certificate            types.String `tfsdk:"certificate"`
+}
+
+type OauthRefreshTokenModel struct {
+	TokenURL       types.String `tfsdk:"token_url"`
+	ClientID       types.String `tfsdk:"client_id"`
+	ClientSecret   types.String `tfsdk:"client_secret"`
+	RefreshToken   types.String `tfsdk:"refresh_token"`
+	Scopes         types.List   `tfsdk:"scopes"`
+	TokenFile      types.String `tfsdk:"token_file"`
+	RootCaFile     types.String `tfsdk:"root_ca_file"`
+	CertFile       types.String `tfsdk:"cert_file"`
+	KeyFile        types.String `tfsdk:"key_file"`
+	Audience       types.String `tfsdk:"audience"`
+	EndpointParams types.Map    `tfsdk:"endpoint_params"`
+	AuthMethod     types.String `tfsdk:"auth_method"`
+}
+
+type LoginModel struct {
+	Path       types.String `tfsdk:"path"`
+	Method     types.String `tfsdk:"method"`
+	Body       types.String `tfsdk:"body"`
+	TokenPath  types.String `tfsdk:"token_path"`
+	CookieName types.String `tfsdk:"cookie_name"`
+}
+
+type RetryOnModel struct {
+	Status types.Int64  `tfsdk:"status"`
+	Path   types.String `tfsdk:"path"`
+	Value  types.String `tfsdk:"value"`
+}
+
+type VaultModel struct {
+	Address    types.String `tfsdk:"address"`
+	Token      types.String `tfsdk:"token"`
+	RoleId     types.String `tfsdk:"role_id"`
+	SecretId   types.String `tfsdk:"secret_id"`
+	CaCertFile types.String `tfsdk:"ca_cert_file"`
+	Path       types.String `tfsdk:"path"`
+	Key        types.String `tfsdk:"key"`
 }
 
 func (p *TrustbuilderProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -77,15 +189,109 @@ func (p *TrustbuilderProvider) Schema(ctx context.Context, req provider.SchemaRe
 				Optional: true,
 			},
 			"headers": schema.MapAttribute{
-				Description: "A map of header names and values to set on all outbound requests. This is useful if you want to use a script via the 'external' provider or provide a pre-approved token or change Content-Type from `application/json`. If `username` and `password` are set and Authorization is one of the headers defined here, the BASIC auth credentials take precedence.",
+				Description: "A map of header names and values to set on all outbound requests. This is useful if you want to use a script via the 'external' provider or provide a pre-approved token or change Content-Type from `application/json`. The `auth_header_name` header (`Authorization` by default) can't be combined with `jwt_hashed_token` or `oauth_refresh_token`; other headers can.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"accept_language": schema.StringAttribute{
+				Description: "Language of the API responses, e.g. `fr-FR` or `fr, en;q=0.8`, sent in the `Accept-Language` header of all outbound requests, the OAuth2 token requests included, for APIs localizing their error messages and labels. " +
+					"The API error messages reported by the provider are then in this language. Can't be combined with an `Accept-Language` header in `headers`. Not set by default.",
+				Optional: true,
+			},
+			"idempotency_key_header": schema.StringAttribute{
+				Description: "Name of the header receiving an idempotency key on the create requests, e.g. `Idempotency-Key`, for APIs deduplicating the creations with it. " +
+					"The key is the SHA-256 of the method, path and body of the create, the same for all its retries with `max_retries`, so that a retried create doesn't create the object twice. " +
+					"Terraform doesn't give the resource address to the provider, so two creates of the same body at the same path share their key. Can't be combined with the same header in `headers`. Not set by default.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"status_messages": schema.MapAttribute{
+				Description: "A map of HTTP status codes to the messages reported instead of the generic error when the API answers with them, e.g. `{ \"401\" = \"Check the credentials\" }`. The API response body is then logged at DEBUG level.",
 				ElementType: types.StringType,
 				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(stringvalidator.RegexMatches(regexp.MustCompile(`^[1-5][0-9]{2}$`), "Must be an HTTP status code")),
+				},
 			},
+			"create_expected_status":  expectedStatusSchema("creation"),
+			"read_expected_status":    expectedStatusSchema("read"),
+			"update_expected_status":  expectedStatusSchema("update"),
+			"destroy_expected_status": expectedStatusSchema("destroy"),
 			"jwt_hashed_token": schema.SingleNestedAttribute{
-				Description: "Configuration for JWT token generation.",
+				Description: "Configuration for JWT token generation. Conflicts with `oauth_refresh_token` and with an `auth_header_name` entry of `headers`.",
 				Optional:    true,
 				Attributes:  jwtHashedTokenResourceSchema(),
 			},
+			"oauth_refresh_token": schema.SingleNestedAttribute{
+				Description: "Configuration for OAuth2 access tokens minted with the refresh token grant. Conflicts with `jwt_hashed_token` and with an `auth_header_name` entry of `headers`.",
+				Optional:    true,
+				Attributes:  oauthRefreshTokenResourceSchema(),
+			},
+			"login": schema.SingleNestedAttribute{
+				Description: "Login request opening the session of session-based APIs, sent once when the provider is configured and again when the API answers 401. " +
+					"The session is a token of the login response, sent in the `auth_header_name` header, or a cookie, sent back on each request. Conflicts with `jwt_hashed_token` and `oauth_refresh_token`.",
+				Optional:   true,
+				Attributes: loginResourceSchema(),
+			},
+			"vault": schema.SingleNestedAttribute{
+				Description: "Reads the secret of `jwt_hashed_token`, or its `private_key` with an asymmetric algorithm, or the `client_secret` of `oauth_refresh_token` from a HashiCorp Vault KV engine, version 1 or 2, when not set in their attribute. " +
+					"The secret is read once per provider instance and is kept out of the configuration and the state.",
+				Optional:   true,
+				Attributes: vaultResourceSchema(),
+			},
+			"reauth_on_401": schema.BoolAttribute{
+				Description: "When true, a request answered 401 is sent once more with new credentials, e.g. when a token expires or is revoked mid-apply: a newly signed `jwt_hashed_token` JWT or a refreshed `oauth_refresh_token` access token. `login` always logs in again on 401. Defaults to false.",
+				Optional:    true,
+			},
+			"auth_header_name": schema.StringAttribute{
+				Description: "Name of the header carrying the `jwt_hashed_token` or `oauth_refresh_token` token, e.g. `X-Auth-Token`. Defaults to `" + apiclient.DefaultAuthHeaderName + "`.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"auth_header_prefix": schema.StringAttribute{
+				Description: "Scheme preceding the `jwt_hashed_token` or `oauth_refresh_token` token in the `auth_header_name` header, e.g. `Token` or `JWT`. Defaults to `" + apiclient.DefaultAuthHeaderPrefix + "`.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"use_netrc": schema.BoolAttribute{
+				Description: "When true, the credentials of the `uri` host are read from `$HOME/.netrc`, like curl and git do: the `login` and `password` of the `machine` entry, or of the `default` entry, are sent with basic authentication, and a `password` without `login` is sent as a token in the `auth_header_name` header. Ignored when `jwt_hashed_token`, `oauth_refresh_token`, `login` or an `auth_header_name` entry of `headers` is set.",
+				Optional:    true,
+			},
+			"netrc_file": schema.StringAttribute{
+				Description: "Path of the .netrc file read as with `use_netrc`, which it implies. Unlike `$HOME/.netrc`, this file must exist.",
+				Optional:    true,
+			},
+			"pkcs12_file": schema.StringAttribute{
+				Description: "Path of a PKCS#12 (`.p12`) bundle holding the client certificate, its private key and optionally the CA chain, used for TLS client authentication.",
+				Optional:    true,
+			},
+			"pkcs12_password": schema.StringAttribute{
+				Description: "Password of the `pkcs12_file` bundle.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"http2_prior_knowledge": schema.BoolAttribute{
+				Description: "When true, the requests to an `http://` URI use cleartext HTTP/2 (h2c) right away, without upgrade from HTTP/1.1, for servers only speaking h2c. An `https://` URI then requires HTTP/2 too. Defaults to false.",
+				Optional:    true,
+			},
+			"cert_reload": schema.BoolAttribute{
+				Description: "When true, the `pkcs12_file` client certificate is loaded again when the file changes on disk, e.g. after a rotation, without restarting the provider. The idle kept-alive connections opened with the previous certificate are closed. A file that can't be loaded, e.g. while being replaced, keeps the previous certificate. Defaults to false.",
+				Optional:    true,
+			},
+			"pinned_cert_sha256": schema.StringAttribute{
+				Description: "SHA-256 fingerprint of the API server certificate, in hexadecimal with or without colons. The connections to a server presenting another certificate are rejected.",
+				Optional:    true,
+			},
+			"pinned_cert_only": schema.BoolAttribute{
+				Description: "When true, the server certificate matching `pinned_cert_sha256` is trusted without validating its chain and hostname, e.g. for a self-signed certificate. Defaults to false: the chain is validated too.",
+				Optional:    true,
+			},
 			"timeout": schema.Int64Attribute{
 				Description: "When set, will cause requests taking longer than this time (in seconds) to be aborted.",
 				Optional:    true,
@@ -94,8 +300,168 @@ func (p *TrustbuilderProvider) Schema(ctx context.Context, req provider.SchemaRe
 				Description: "If set, the provider will issue a read_method request to this path after instantiation requiring a 200 OK response before proceeding. This is useful if your API provides a no-op endpoint that can signal if this provider is configured correctly. Response data will be ignored.",
 				Optional:    true,
 			},
+			"test_paths": schema.ListAttribute{
+				Description: "A list of paths checked like `test_path`, e.g. an authentication, a data and a health endpoint. Each failing path is reported in its own error.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"create_returns_object": schema.BoolAttribute{
+				Description: "Set this when the API returns the created object on creation operations (POST). When unset, an empty creation response (e.g. 204 No Content) is followed by a read of the object to get its computed attributes.",
+				Optional:    true,
+			},
+			"strip_headers_on_redirect": schema.ListAttribute{
+				Description: "A list of header names removed from the request when the API answers with a redirect, whatever the redirection target. Go already drops sensitive headers like `Authorization` on cross-host redirects; use this for custom headers that must never be forwarded.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"restrict_redirects_to_same_host": schema.BoolAttribute{
+				Description: "When true, a redirect whose resolved location is on another host than the original request fails the request instead of being followed, e.g. when a gateway redirects to an internal hostname. Relative redirects are followed. Defaults to false.",
+				Optional:    true,
+			},
+			"follow_redirects": schema.BoolAttribute{
+				Description: "When false, the 3xx responses are returned instead of being followed. They fail the request unless their code is in the `*_expected_status` list of the operation, e.g. `create_expected_status = [201, 303]` for a POST-redirect-GET API, whose created object is then read at the `Location` of the response. Defaults to true.",
+				Optional:    true,
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				Description: "When set, caps the number of HTTP requests in flight at the same time, independently of Terraform's parallelism and of the rate limit. Useful for APIs limiting the number of concurrent connections.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"json_decode_retries": schema.Int64Attribute{
+				Description: "Number of times a read is sent again when its response body can't be parsed as JSON, e.g. when truncated by a gateway under load. Defaults to 0.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"json_escape_html": schema.BoolAttribute{
+				Description: "When true, `<`, `>` and `&` are escaped in the JSON documents sent to the API, e.g. `&` as `\\u0026`, as in the previous versions of the provider. Defaults to false.",
+				Optional:    true,
+			},
+			"max_response_size": schema.Int64Attribute{
+				Description: "When set, a response body larger than this size in bytes, after decompression, fails the request instead of being read into memory, including chunked responses without `Content-Length`.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"compress_request_min_bytes": schema.Int64Attribute{
+				Description: "When set, the request bodies of at least this size in bytes are sent gzip encoded, with `Content-Encoding: gzip`. The smaller bodies are sent uncompressed, as their compression costs CPU for no gain and some servers mishandle it. Defaults to no compression.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"response_root": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the objects, or the arrays of objects, in the API response bodies, e.g. `data` for APIs answering `{\"data\": {...}}` and `{\"data\": [...]}`. The tenants are read from this part on create, read and import, before their `select_element` and `select_subtree`, and the `object` data source reads its `results_key` from it. Defaults to the response itself.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"response_errors_path": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the errors in the response bodies, e.g. `errors` for GraphQL-style APIs answering 200 with `{\"errors\": [...], \"data\": {...}}`. A successful response whose value at this path is not null, an empty array, an empty object or an empty string fails the request, with the errors in the diagnostic.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"response_format": schema.StringAttribute{
+				Description: "Format of the response bodies: `json` for a single JSON document, or `jsonl` for newline-delimited JSON values (JSON Lines), e.g. from event APIs, converted into a JSON array. Defaults to `json`.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(apiclient.ResponseFormatJson, apiclient.ResponseFormatJsonLines),
+				},
+			},
+			"append_trailing_slash": schema.BoolAttribute{
+				Description: "When true, ensures a single trailing slash is present on every request path, before any query string. Useful for frameworks answering 404 on paths without trailing slash. Defaults to false.",
+				Optional:    true,
+			},
+			"version_header_name": schema.StringAttribute{
+				Description: "Name of the header carrying the provider version on all outbound requests. Defaults to `" + defaultVersionHeaderName + "`.",
+				Optional:    true,
+			},
+			"disable_version_headers": schema.BoolAttribute{
+				Description: "When true, neither the provider version header nor the default `User-Agent` (including the provider and Terraform versions) is sent.",
+				Optional:    true,
+			},
+			"identifier_query_param": schema.StringAttribute{
+				Description: "Name of the query parameter carrying the tenant name when reading or importing a tenant, e.g. `name` or `slug`. Defaults to `identifier`.",
+				Optional:    true,
+			},
+			"id_attribute": schema.StringAttribute{
+				Description: "Dot-separated JSON path of the id in the objects returned by the API, e.g. `data.key`. " +
+					"When not set, the first of `id`, `uuid`, `_id` and `name` present in the object is used. " +
+					"Can also be set with the " + envvar.TrustbuilderIdAttribute + " environment variable.",
+				Optional: true,
+			},
+			"default_path": schema.StringAttribute{
+				Description: "Default API path of the tenants, allowing to import a tenant with only its name instead of `path,tenant`.",
+				Optional:    true,
+			},
+			"max_retries": schema.Int64Attribute{
+				Description: "Number of times a request is sent again when the API answers 429, 502, 503 or 504, its error matches `retry_on`, or the connection fails, with an exponential backoff. Only the GET, HEAD, OPTIONS, PUT and DELETE requests are retried: a failed POST or PATCH, e.g. a create, may have reached the API, and sending it again could create the object twice. The creates are retried too when `idempotency_key_header` is set. Defaults to 0.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"retry_on": schema.ListNestedAttribute{
+				Description: "A list of conditions on the error response bodies classifying the API errors as retryable, in addition to the 429, 502, 503 and 504 responses, for APIs throttling with another status code, e.g. a 400 with `{\"code\": \"RATE_LIMITED\"}`. Applies with `max_retries`.",
+				Optional:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: retryOnResourceSchema(),
+				},
+			},
+			"retry_jitter": schema.StringAttribute{
+				Description: "Randomization of the backoff between retries, avoiding synchronized retries of many resources: `none`, `full`, `equal` or `decorrelated`. Defaults to `full`.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(jitterStrategyNames()...),
+				},
+			},
+			"retry_max_wait": schema.Int64Attribute{
+				Description: "Upper bound, in seconds, of the backoff between two retries. Defaults to 30.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"retry_max_elapsed_time": schema.Int64Attribute{
+				Description: "Time budget, in seconds, of a request and its retries. No retry is sent once its backoff would exceed the budget, the last error being returned, even when `max_retries` is not reached. Defaults to no limit.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"fail_fast": schema.BoolAttribute{
+				Description: "When set, the first request failing with a 5xx, the API being considered down, fails all the next requests of the provider at once, without sending them, instead of letting each resource fail on its own. " +
+					"The failing request is retried first with `max_retries`, only its last error opening the circuit, and the pending retries of the other requests are then stopped. Defaults to `false`.",
+				Optional: true,
+			},
+			"trace_http": schema.BoolAttribute{
+				Description: "Enabling this will log the complete wire format of every HTTP request and response at TRACE level (`TF_LOG=TRACE`), with the credentials headers redacted. The response bodies are logged as read by the provider, decompressed and limited to `max_response_size`. This is verbose and may expose sensitive payloads.",
+				Optional:    true,
+			},
 			"debug": schema.BoolAttribute{
-				Description: "Enabling this will cause lots of debug information to be printed to STDOUT by the API client.",
+				Description: "Enabling this will cause lots of debug information to be logged by the API client on STDERR, collected in the Terraform logs, or in `debug_log_file`.",
+				Optional:    true,
+			},
+			"debug_log_file": schema.StringAttribute{
+				Description: "Path of a file the `debug` information is appended to, to capture it separately from the Terraform output.",
+				Optional:    true,
+			},
+			"max_log_body_bytes": schema.Int64Attribute{
+				Description: "Length in bytes of the request and response bodies written to the `debug` output, the rest being cut with a `...[truncated N bytes]` suffix, so that large responses don't flood the logs. 0 writes the whole bodies. Defaults to 4096.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"redact_log_bodies": schema.BoolAttribute{
+				Description: "When true, the request and response bodies are replaced with their size, e.g. `<redacted 1024 bytes>`, in the `debug` output and the `trace_http` traces, for bodies holding secrets such as the tenant `data`. Defaults to false.",
 				Optional:    true,
 			},
 		},
@@ -110,15 +476,36 @@ func jwtHashedTokenResourceSchema() map[string]schema.Attribute {
 			Required:    true,
 		},
 		"secret": schema.StringAttribute{
-			Description: "HMAC secret to sign the JWT with",
-			Required:    true,
+			Description: "HMAC secret to sign the JWT with, mandatory with the `HS*` algorithms",
+			Optional:    true,
+			Sensitive:   true,
+		},
+		"private_key": schema.StringAttribute{
+			Description: "PEM private key (PKCS#1, PKCS#8 or SEC 1) to sign the JWT with, mandatory with the `RS*`, `PS*`, `ES*` and `EdDSA` algorithms",
+			Optional:    true,
 			Sensitive:   true,
+			Validators: []validator.String{
+				stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("secret")),
+			},
+		},
+		"certificate": schema.StringAttribute{
+			Description: "PEM certificate chain of `private_key`, leaf first, sent base64 DER encoded in the `x5c` header of the JWT, e.g. for Open Banking / FAPI verifiers. The leaf certificate must match the private key.",
+			Optional:    true,
+			Validators: []validator.String{
+				stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("private_key")),
+			},
 		},
 		"algorithm": schema.StringAttribute{
-			Description: "Signing algorithm to use.",
+			Description: "Signing algorithm to use. Defaults to `HS256`.",
 			Optional:    true,
 			Validators: []validator.String{
-				stringvalidator.OneOf([]string{"HS256", "HS384", "HS512"}...),
+				stringvalidator.OneOf([]string{
+					"HS256", "HS384", "HS512",
+					"RS256", "RS384", "RS512",
+					"PS256", "PS384", "PS512",
+					"ES256", "ES384", "ES512",
+					"EdDSA",
+				}...),
 			},
 		},
 		"validity_duration_minute": schema.Int64Attribute{
@@ -128,6 +515,174 @@ func jwtHashedTokenResourceSchema() map[string]schema.Attribute {
 	}
 }
 
+func oauthRefreshTokenResourceSchema() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"token_url": schema.StringAttribute{
+			Description: "The OAuth2 token endpoint URL",
+			Required:    true,
+		},
+		"client_id": schema.StringAttribute{
+			Description: "The OAuth2 client ID",
+			Optional:    true,
+		},
+		"client_secret": schema.StringAttribute{
+			Description: "The OAuth2 client secret",
+			Optional:    true,
+			Sensitive:   true,
+		},
+		"auth_method": schema.StringAttribute{
+			Description: "Client authentication of the token requests: `client_secret_basic` for the client id and secret in the `Authorization` header, `client_secret_post` for them in the form, " +
+				"or `tls_client_auth` for the client authenticated by the TLS certificate of the token requests, `cert_file` or else the API client certificate, without `client_secret` (RFC 8705). " +
+				"The tokens a FAPI identity provider then issues are bound to this certificate, which the API requests must present too. Defaults to `client_secret_basic`, falling back to `client_secret_post` when the token endpoint rejects it.",
+			Optional: true,
+			Validators: []validator.String{
+				stringvalidator.OneOf(oauthAuthMethodNames()...),
+			},
+		},
+		"refresh_token": schema.StringAttribute{
+			Description: "The refresh token used to bootstrap the token retrieval. When `token_file` holds a persisted token, its refresh token is used instead.",
+			Required:    true,
+			Sensitive:   true,
+		},
+		"scopes": schema.ListAttribute{
+			Description: "The OAuth2 scopes to request",
+			ElementType: types.StringType,
+			Optional:    true,
+		},
+		"audience": schema.StringAttribute{
+			Description: "The `audience` form parameter of the token requests, identifying the target API, e.g. `https://api.example.com/` for Auth0-style identity providers. Takes precedence over an `audience` entry of `endpoint_params`.",
+			Optional:    true,
+		},
+		"endpoint_params": schema.MapAttribute{
+			Description: "A map of additional form parameters of the token requests, e.g. `resource`. The parameters of the grant itself (`grant_type`, `refresh_token`, `client_id`, `client_secret` and `scope`) can't be set.",
+			ElementType: types.StringType,
+			Optional:    true,
+			Validators: []validator.Map{
+				mapvalidator.KeysAre(stringvalidator.NoneOf("grant_type", "refresh_token", "client_id", "client_secret", "scope")),
+			},
+		},
+		"token_file": schema.StringAttribute{
+			Description: "Path of a file where the last token, including the refresh token rotated by the identity provider, is written after each refresh and read back on the next run.",
+			Optional:    true,
+		},
+		"root_ca_file": schema.StringAttribute{
+			Description: "Path of the PEM root CA of the token endpoint, when it differs from the API's. When this or `cert_file` is set, the token requests don't use the TLS settings of the API, e.g. `pkcs12_file` or `pinned_cert_sha256`.",
+			Optional:    true,
+		},
+		"cert_file": schema.StringAttribute{
+			Description: "Path of the PEM client certificate presented to the token endpoint.",
+			Optional:    true,
+			Validators: []validator.String{
+				stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("key_file")),
+			},
+		},
+		"key_file": schema.StringAttribute{
+			Description: "Path of the PEM private key of `cert_file`.",
+			Optional:    true,
+			Validators: []validator.String{
+				stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("cert_file")),
+			},
+		},
+	}
+}
+
+func loginResourceSchema() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"path": schema.StringAttribute{
+			Description: "Path of the login endpoint, on top of the provider `uri`, e.g. `/login`.",
+			Required:    true,
+		},
+		"method": schema.StringAttribute{
+			Description: "Method of the login request. Defaults to `POST`.",
+			Optional:    true,
+			Validators: []validator.String{
+				stringvalidator.OneOf("GET", "POST", "PUT"),
+			},
+		},
+		"body": schema.StringAttribute{
+			Description: "JSON body of the login request holding the credentials, e.g. `jsonencode({ username = var.user, password = var.password })`.",
+			Optional:    true,
+			Sensitive:   true,
+		},
+		"token_path": schema.StringAttribute{
+			Description: "Dot-separated JSON path of the session token in the login response, e.g. `data.token`.",
+			Optional:    true,
+			Validators: []validator.String{
+				stringvalidator.ExactlyOneOf(path.MatchRelative().AtParent().AtName("cookie_name")),
+			},
+		},
+		"cookie_name": schema.StringAttribute{
+			Description: "Name of the session cookie set by the login response, e.g. `JSESSIONID`.",
+			Optional:    true,
+		},
+	}
+}
+
+func retryOnResourceSchema() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"status": schema.Int64Attribute{
+			Description: "Status code of the retryable errors, e.g. `400`. Defaults to any status code.",
+			Optional:    true,
+			Validators: []validator.Int64{
+				int64validator.Between(100, 599),
+			},
+		},
+		"path": schema.StringAttribute{
+			Description: "Dot-separated JSON path of the checked value in the error response body, e.g. `code`.",
+			Required:    true,
+		},
+		"value": schema.StringAttribute{
+			Description: "Value of a retryable error, compared with the string representation of the JSON value (e.g. `RATE_LIMITED`).",
+			Required:    true,
+		},
+	}
+}
+
+func vaultResourceSchema() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"address": schema.StringAttribute{
+			Description: "URL of the Vault server, e.g. `https://vault.example.com:8200`. Defaults to the `" + envvar.VaultAddr + "` environment variable.",
+			Optional:    true,
+		},
+		"token": schema.StringAttribute{
+			Description: "Vault token reading the secret. Defaults to the `" + envvar.VaultToken + "` environment variable when `role_id` is not set.",
+			Optional:    true,
+			Sensitive:   true,
+			Validators: []validator.String{
+				stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("role_id")),
+			},
+		},
+		"role_id": schema.StringAttribute{
+			Description: "Role ID of the AppRole auth method, mounted at `approle`, to log in with instead of a token.",
+			Optional:    true,
+			Sensitive:   true,
+			Validators: []validator.String{
+				stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("secret_id")),
+			},
+		},
+		"secret_id": schema.StringAttribute{
+			Description: "Secret ID of the AppRole `role_id`.",
+			Optional:    true,
+			Sensitive:   true,
+			Validators: []validator.String{
+				stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("role_id")),
+			},
+		},
+		"ca_cert_file": schema.StringAttribute{
+			Description: "Path of the PEM root CA of the Vault server, when not trusted by the system.",
+			Optional:    true,
+		},
+		"path": schema.StringAttribute{
+			Description: "API path of the secret, without the `/v1/` prefix, e.g. `secret/data/trustbuilder` in a KV version 2 engine mounted at `secret`, or `kv/trustbuilder` in a version 1 engine.",
+			Required:    true,
+		},
+		"key": schema.StringAttribute{
+			Description: "Key of the secret value in the secret data.",
+			Required:    true,
+		},
+	}
+}
+
 func (p *TrustbuilderProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 
 	var config TrustbuilderProviderModel
@@ -158,6 +713,11 @@ func (p *TrustbuilderProvider) Configure(ctx context.Context, req provider.Confi
 		return
 	}
 
+	idAttribute := os.Getenv(envvar.TrustbuilderIdAttribute)
+	if !config.IdAttribute.IsNull() {
+		idAttribute = config.IdAttribute.ValueString()
+	}
+
 	// headers := make(map[string]string)
 	// if iHeaders := config.Headers.ToMapValue(); iHeaders != nil {
 	// 	for k, v := range iHeaders.(map[string]interface{}) {
@@ -165,16 +725,123 @@ func (p *TrustbuilderProvider) Configure(ctx context.Context, req provider.Confi
 	// 	}
 	// }
 	headers := make(map[string]string)
-	for k, v := range config.Headers.Elements() {
-		headers[k] = v.String()
+	if !config.DisableVersionHeaders.ValueBool() {
+		versionHeaderName := defaultVersionHeaderName
+		if !config.VersionHeaderName.IsNull() {
+			versionHeaderName = config.VersionHeaderName.ValueString()
+		}
+		for k, v := range versionHeaders(versionHeaderName, p.version, req.TerraformVersion) {
+			headers[k] = v
+		}
+	}
+	var configHeaders map[string]string
+	resp.Diagnostics.Append(config.Headers.ElementsAs(ctx, &configHeaders, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	for k, v := range configHeaders {
+		headers[k] = v
+	}
+
+	if !config.AcceptLanguage.IsNull() && hasHeader(configHeaders, "Accept-Language") {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("accept_language"),
+			"Conflicting headers",
+			"accept_language sets the Accept-Language header, which is also set in headers. Configure only one of them.",
+		)
+	}
+	if !config.IdempotencyKeyHeader.IsNull() && hasHeader(configHeaders, config.IdempotencyKeyHeader.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("idempotency_key_header"),
+			"Conflicting headers",
+			fmt.Sprintf("idempotency_key_header sets the %s header, which is also set in headers. Configure only one of them.", config.IdempotencyKeyHeader.ValueString()),
+		)
+	}
+	resp.Diagnostics.Append(validateAuthentication(&config, configHeaders)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	statusMessages, diags := statusMessagesByCode(ctx, config.StatusMessages)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	expectedStatus, diags := expectedStatusByOperation(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var stripHeadersOnRedirect []string
+	resp.Diagnostics.Append(config.StripHeadersOnRedirect.ElementsAs(ctx, &stripHeadersOnRedirect, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	retryConditions, diags := retryConditions(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	maxLogBodyBytes := int64(apiclient.DefaultMaxLogBodyBytes)
+	if !config.MaxLogBodyBytes.IsNull() {
+		maxLogBodyBytes = config.MaxLogBodyBytes.ValueInt64()
 	}
 
 	opt := &apiclient.ApiClientOpt{
-		Uri:       config.URI.ValueString(),
-		Headers:   headers,
-		Timeout:   config.Timeout.ValueInt64(),
-		Debug:     config.Debug.ValueBool(),
-		RateLimit: 1,
+		Uri:                         config.URI.ValueString(),
+		Headers:                     headers,
+		AcceptLanguage:              config.AcceptLanguage.ValueString(),
+		IdempotencyKeyHeader:        config.IdempotencyKeyHeader.ValueString(),
+		StatusMessages:              statusMessages,
+		ExpectedStatus:              expectedStatus,
+		AuthHeaderName:              config.AuthHeaderName.ValueString(),
+		AuthHeaderPrefix:            config.AuthHeaderPrefix.ValueString(),
+		ReauthOn401:                 config.ReauthOn401.ValueBool(),
+		Pkcs12File:                  config.Pkcs12File.ValueString(),
+		Pkcs12Password:              config.Pkcs12Password.ValueString(),
+		PinnedCertSha256:            config.PinnedCertSha256.ValueString(),
+		CertReload:                  config.CertReload.ValueBool(),
+		Http2PriorKnowledge:         config.Http2PriorKnowledge.ValueBool(),
+		PinnedCertOnly:              config.PinnedCertOnly.ValueBool(),
+		Timeout:                     config.Timeout.ValueInt64(),
+		CreateReturnsObject:         config.CreateReturnsObject.ValueBool(),
+		StripHeadersOnRedirect:      stripHeadersOnRedirect,
+		RestrictRedirectsToSameHost: config.RestrictRedirectsToSameHost.ValueBool(),
+		NoFollowRedirects:           !config.FollowRedirects.IsNull() && !config.FollowRedirects.ValueBool(),
+		MaxConcurrentRequests:       config.MaxConcurrentRequests.ValueInt64(),
+		JsonDecodeRetries:           config.JsonDecodeRetries.ValueInt64(),
+		EscapeHTML:                  config.JsonEscapeHTML.ValueBool(),
+		MaxResponseSize:             config.MaxResponseSize.ValueInt64(),
+		CompressRequestMinBytes:     config.CompressRequestMinBytes.ValueInt64(),
+		ResponseErrorsPath:          config.ResponseErrorsPath.ValueString(),
+		ResponseFormat:              config.ResponseFormat.ValueString(),
+		ResponseRoot:                config.ResponseRoot.ValueString(),
+		AppendTrailingSlash:         config.AppendTrailingSlash.ValueBool(),
+		TraceHttp:                   config.TraceHttp.ValueBool(),
+		IdentifierQueryParam:        config.IdentifierQueryParam.ValueString(),
+		IdAttribute:                 idAttribute,
+		DefaultPath:                 config.DefaultPath.ValueString(),
+		MaxRetries:                  config.MaxRetries.ValueInt64(),
+		RetryConditions:             retryConditions,
+		RetryJitter:                 apiclient.JitterStrategy(config.RetryJitter.ValueString()),
+		RetryMaxWait:                config.RetryMaxWait.ValueInt64(),
+		RetryMaxElapsedTime:         config.RetryMaxElapsedTime.ValueInt64(),
+		FailFast:                    config.FailFast.ValueBool(),
+		Debug:                       config.Debug.ValueBool(),
+		DebugLogFile:                config.DebugLogFile.ValueString(),
+		MaxLogBodyBytes:             maxLogBodyBytes,
+		RedactLogBodies:             config.RedactLogBodies.ValueBool(),
+		RateLimit:                   1,
+	}
+
+	vaultSecret, diags := p.vaultSecret(ctx, req.Config, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	var jwtHashedTokenModel JwtHashedTokenModel
@@ -185,18 +852,41 @@ func (p *TrustbuilderProvider) Configure(ctx context.Context, req provider.Confi
 			return
 		}
 
+		asymmetric := apiclient.IsAsymmetricJwtAlgorithm(jwtHashedTokenModel.Algorithm.ValueString())
+
 		jwtSecret := os.Getenv(envvar.TrustbuilderJwtSecret)
+		if !asymmetric && vaultSecret != "" {
+			jwtSecret = vaultSecret
+		}
 		if !jwtHashedTokenModel.Secret.IsNull() {
 			jwtSecret = jwtHashedTokenModel.Secret.ValueString()
-			tflog.Debug(ctx, "jwtSecret content: "+jwtSecret)
 		}
 
-		if jwtSecret == "" {
+		privateKeyPem := jwtHashedTokenModel.PrivateKey.ValueString()
+		if asymmetric && jwtHashedTokenModel.PrivateKey.IsNull() {
+			privateKeyPem = vaultSecret
+		}
+		if asymmetric && privateKeyPem == "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("jwt_hashed_token").AtName("private_key"),
+				"The JWT private key is mandatory with an asymmetric algorithm",
+				"The algorithm "+jwtHashedTokenModel.Algorithm.ValueString()+" signs the JWT with a private key instead of a secret. Set the private_key value in the jwt_hashed_token attribute or read it from vault.",
+			)
+		}
+		if !asymmetric && !jwtHashedTokenModel.PrivateKey.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("jwt_hashed_token").AtName("private_key"),
+				"The JWT private key requires an asymmetric algorithm",
+				"The HS* algorithms sign the JWT with the secret. Set an RS*, PS*, ES* or EdDSA algorithm to sign it with the private key.",
+			)
+		}
+
+		if !asymmetric && jwtSecret == "" {
 			resp.Diagnostics.AddAttributeError(
 				path.Root("jwt_hashed_token.secret"),
 				"The JWT secret is mandatory when jwt_hashed_token is defined",
 				"The provider has unknown configuration value for the JWT secret. "+
-					"Set the secret value in the jwt_hashed_token attribute or use the "+envvar.TrustbuilderJwtSecret+" environment variable. "+
+					"Set the secret value in the jwt_hashed_token attribute, read it from vault or use the "+envvar.TrustbuilderJwtSecret+" environment variable. "+
 					"If either is already set, ensure the value is not empty.",
 			)
 		}
@@ -215,40 +905,500 @@ func (p *TrustbuilderProvider) Configure(ctx context.Context, req provider.Confi
 			Algortithm: jwtHashedTokenModel.Algorithm.ValueString(),
 			Claims:     claimsMap,
 		}
+		if asymmetric && privateKeyPem != "" {
+			privateKey, certificateChain, err := apiclient.LoadJwtSigningKey(privateKeyPem, jwtHashedTokenModel.Certificate.ValueString())
+			if err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("jwt_hashed_token").AtName("private_key"), "Invalid JWT signing key", err.Error())
+			}
+			jwt.PrivateKey = privateKey
+			jwt.CertificateChain = certificateChain
+		}
 
 		opt.Jwt = jwt
 	}
 
+	var oauthRefreshTokenModel OauthRefreshTokenModel
+	if !config.OauthRefreshToken.IsNull() && !config.OauthRefreshToken.IsUnknown() {
+		diags := req.Config.GetAttribute(ctx, path.Root("oauth_refresh_token"), &oauthRefreshTokenModel)
+		resp.Diagnostics.Append(diags...)
+		var scopes []string
+		resp.Diagnostics.Append(oauthRefreshTokenModel.Scopes.ElementsAs(ctx, &scopes, false)...)
+		var endpointParams map[string]string
+		resp.Diagnostics.Append(oauthRefreshTokenModel.EndpointParams.ElementsAs(ctx, &endpointParams, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		opt.OauthTokenURL = oauthRefreshTokenModel.TokenURL.ValueString()
+		opt.OauthClientID = oauthRefreshTokenModel.ClientID.ValueString()
+		opt.OauthClientSecret = oauthRefreshTokenModel.ClientSecret.ValueString()
+		opt.OauthAuthMethod = apiclient.OauthAuthMethod(oauthRefreshTokenModel.AuthMethod.ValueString())
+		if oauthRefreshTokenModel.ClientSecret.IsNull() && opt.OauthAuthMethod != apiclient.OauthTlsClientAuth {
+			opt.OauthClientSecret = vaultSecret
+		}
+		opt.OauthRefreshToken = oauthRefreshTokenModel.RefreshToken.ValueString()
+		opt.OauthScopes = scopes
+		opt.OauthTokenFile = oauthRefreshTokenModel.TokenFile.ValueString()
+		opt.OauthRootCaFile = oauthRefreshTokenModel.RootCaFile.ValueString()
+		opt.OauthCertFile = oauthRefreshTokenModel.CertFile.ValueString()
+		opt.OauthKeyFile = oauthRefreshTokenModel.KeyFile.ValueString()
+		opt.OauthAudience = oauthRefreshTokenModel.Audience.ValueString()
+		opt.OauthEndpointParams = url.Values{}
+		for key, value := range endpointParams {
+			opt.OauthEndpointParams.Set(key, value)
+		}
+	}
+
+	if !config.Login.IsNull() && !config.Login.IsUnknown() {
+		var loginModel LoginModel
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("login"), &loginModel)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		opt.Login = &apiclient.SessionLogin{
+			Path:       loginModel.Path.ValueString(),
+			Method:     loginModel.Method.ValueString(),
+			Body:       loginModel.Body.ValueString(),
+			TokenPath:  loginModel.TokenPath.ValueString(),
+			CookieName: loginModel.CookieName.ValueString(),
+		}
+	}
+
+	resp.Diagnostics.Append(netrcCredentials(ctx, &config, configHeaders, uri, opt)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	client, err := apiclient.NewAPIClient(opt)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"API client creation fail",
 			fmt.Sprintf("The creation of the API client failed. Verify the provider configuration. %v", err),
 		)
+		return
 	}
+	tflog.Debug(ctx, "Effective provider configuration, secrets masked", client.EffectiveConfig())
 
-	testPath := config.TestPath.ValueString()
-	if testPath != "" {
-		_, err = client.SendRequest(client.ReadMethod, testPath, "")
-		if err != nil {
-			resp.Diagnostics.AddError(
+	if client.Login != nil {
+		if err := client.LogIn(ctx); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("login"), "Login request error", fmt.Sprintf("The provider can't log in to the API: %v", err))
+			return
+		}
+	}
+
+	var testPaths []string
+	resp.Diagnostics.Append(config.TestPaths.ElementsAs(ctx, &testPaths, false)...)
+	if config.TestPath.ValueString() != "" {
+		testPaths = append([]string{config.TestPath.ValueString()}, testPaths...)
+	}
+	resp.Diagnostics.Append(checkTestPaths(ctx, client, testPaths)...)
+
+	resp.DataSourceData = client
+	resp.ResourceData = client
+
+}
+
+// ValidateConfig reports the settings without effect or partially set during
+// terraform validate. Unknown values are considered set.
+func (p *TrustbuilderProvider) ValidateConfig(ctx context.Context, req provider.ValidateConfigRequest, resp *provider.ValidateConfigResponse) {
+	var config TrustbuilderProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validateTlsSettings(&config)...)
+
+	if !config.OauthRefreshToken.IsNull() && !config.OauthRefreshToken.IsUnknown() {
+		var oauthRefreshTokenModel OauthRefreshTokenModel
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("oauth_refresh_token"), &oauthRefreshTokenModel)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		resp.Diagnostics.Append(validateOauthClient(&oauthRefreshTokenModel, !config.Vault.IsNull())...)
+	}
+	if !config.Vault.IsNull() && config.JwtHashedToken.IsNull() && config.OauthRefreshToken.IsNull() {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("vault"),
+			"Setting without effect",
+			"vault is ignored without jwt_hashed_token or oauth_refresh_token: there is no secret to read.",
+		)
+	}
+}
+
+// validateTlsSettings warns about the TLS settings depending on another one
+// that is not set, which are silently ignored.
+func validateTlsSettings(config *TrustbuilderProviderModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if config.PinnedCertOnly.ValueBool() && config.PinnedCertSha256.IsNull() {
+		diags.AddAttributeWarning(
+			path.Root("pinned_cert_only"),
+			"Setting without effect",
+			"pinned_cert_only is ignored without pinned_cert_sha256: the server certificate chain and hostname are validated.",
+		)
+	}
+	if !config.Pkcs12Password.IsNull() && config.Pkcs12File.IsNull() {
+		diags.AddAttributeWarning(
+			path.Root("pkcs12_password"),
+			"Setting without effect",
+			"pkcs12_password is ignored without pkcs12_file.",
+		)
+	}
+	if config.CertReload.ValueBool() && config.Pkcs12File.IsNull() {
+		diags.AddAttributeWarning(
+			path.Root("cert_reload"),
+			"Setting without effect",
+			"cert_reload is ignored without pkcs12_file: there is no client certificate file to reload.",
+		)
+	}
+
+	return diags
+}
+
+// validateOauthClient rejects a client secret without client id, and warns
+// about a client id without secret, only valid for public clients, unless the
+// secret is read from Vault. With the tls_client_auth method, the client id is
+// required and the secret rejected.
+func validateOauthClient(oauth *OauthRefreshTokenModel, vaultSet bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if oauth.AuthMethod.ValueString() == string(apiclient.OauthTlsClientAuth) {
+		if !oauth.ClientSecret.IsNull() {
+			diags.AddAttributeError(
+				path.Root("oauth_refresh_token").AtName("client_secret"),
+				"Conflicting OAuth client authentication",
+				"client_secret can't be set with the tls_client_auth auth_method, the client being authenticated by its TLS certificate.",
+			)
+		}
+		if oauth.ClientID.IsNull() {
+			diags.AddAttributeError(
+				path.Root("oauth_refresh_token").AtName("client_id"),
+				"Missing OAuth client id",
+				"The tls_client_auth auth_method sends the client_id with the client certificate. Set the client_id of the certificate.",
+			)
+		}
+		return diags
+	}
+
+	if !oauth.ClientSecret.IsNull() && oauth.ClientID.IsNull() {
+		diags.AddAttributeError(
+			path.Root("oauth_refresh_token").AtName("client_id"),
+			"Missing OAuth client id",
+			"client_secret is set without client_id. Set the client_id of the secret.",
+		)
+	}
+	if !oauth.ClientID.IsNull() && oauth.ClientSecret.IsNull() && !vaultSet {
+		diags.AddAttributeWarning(
+			path.Root("oauth_refresh_token").AtName("client_secret"),
+			"Missing OAuth client secret",
+			"client_id is set without client_secret, which only public clients can omit. Set the client_secret of confidential clients.",
+		)
+	}
+
+	return diags
+}
+
+// vaultSecret returns the secret of the vault attribute, or an empty string
+// when it is not set. The address and token default to the VAULT_ADDR and
+// VAULT_TOKEN environment variables.
+func (p *TrustbuilderProvider) vaultSecret(ctx context.Context, config tfsdk.Config, model *TrustbuilderProviderModel) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if model.Vault.IsNull() || model.Vault.IsUnknown() {
+		return "", diags
+	}
+	var vaultModel VaultModel
+	diags.Append(config.GetAttribute(ctx, path.Root("vault"), &vaultModel)...)
+	if diags.HasError() {
+		return "", diags
+	}
+
+	opt := &apiclient.VaultOpt{
+		Address:    os.Getenv(envvar.VaultAddr),
+		Token:      vaultModel.Token.ValueString(),
+		RoleId:     vaultModel.RoleId.ValueString(),
+		SecretId:   vaultModel.SecretId.ValueString(),
+		CaCertFile: vaultModel.CaCertFile.ValueString(),
+		Path:       vaultModel.Path.ValueString(),
+		Key:        vaultModel.Key.ValueString(),
+	}
+	if !vaultModel.Address.IsNull() {
+		opt.Address = vaultModel.Address.ValueString()
+	}
+	if opt.Token == "" && opt.RoleId == "" {
+		opt.Token = os.Getenv(envvar.VaultToken)
+	}
+	if opt.Address == "" {
+		diags.AddAttributeError(
+			path.Root("vault").AtName("address"),
+			"The Vault address is mandatory when vault is defined",
+			"Set the address value in the vault attribute or use the "+envvar.VaultAddr+" environment variable.",
+		)
+		return "", diags
+	}
+
+	secret, err := p.readVaultSecret(ctx, opt)
+	if err != nil {
+		diags.AddAttributeError(path.Root("vault"), "Vault secret read error", fmt.Sprintf("The secret can't be read from Vault: %v", err))
+	}
+	return secret, diags
+}
+
+// readVaultSecret reads a secret from Vault on its first use only, later reads
+// returning the cached value.
+func (p *TrustbuilderProvider) readVaultSecret(ctx context.Context, opt *apiclient.VaultOpt) (string, error) {
+	p.vaultSecretsMu.Lock()
+	defer p.vaultSecretsMu.Unlock()
+
+	cacheKey := strings.Join([]string{opt.Address, opt.Path, opt.Key}, "\x00")
+	if secret, found := p.vaultSecrets[cacheKey]; found {
+		return secret, nil
+	}
+	secret, err := apiclient.ReadVaultSecret(ctx, opt)
+	if err != nil {
+		return "", err
+	}
+	if p.vaultSecrets == nil {
+		p.vaultSecrets = make(map[string]string)
+	}
+	p.vaultSecrets[cacheKey] = secret
+	tflog.Debug(ctx, "secret read from Vault: "+opt.Path)
+	return secret, nil
+}
+
+// checkTestPaths sends a read request to each test path, reporting an error
+// for each path not answering with an OK response.
+func checkTestPaths(ctx context.Context, client *apiclient.APIClient, testPaths []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, testPath := range testPaths {
+		if _, err := client.SendRequestWithContext(ctx, client.ReadMethod, testPath, ""); err != nil {
+			diags.AddError(
 				"test_path send request fail",
 				fmt.Sprintf("a test request to %v after setting up the provider did not return an OK response - is your configuration correct? %v", testPath, err),
 			)
 		}
 	}
+	return diags
+}
 
-	resp.DataSourceData = client
-	resp.ResourceData = client
+// validateAuthentication rejects the authentication options producing the same
+// header, Authorization or the auth_header_name, whose precedence would be
+// surprising. Other headers can be combined with any authentication option.
+func validateAuthentication(config *TrustbuilderProviderModel, configHeaders map[string]string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	headerName := authHeaderName(config)
+	jwtSet := !config.JwtHashedToken.IsNull()
+	oauthSet := !config.OauthRefreshToken.IsNull()
+	loginSet := !config.Login.IsNull()
+	authHeaderSet := hasHeader(configHeaders, headerName)
 
+	if jwtSet && oauthSet {
+		diags.AddAttributeError(
+			path.Root("oauth_refresh_token"),
+			"Conflicting authentication options",
+			"jwt_hashed_token and oauth_refresh_token both set the "+headerName+" header. Configure only one of them.",
+		)
+	}
+	if loginSet && (jwtSet || oauthSet) {
+		diags.AddAttributeError(
+			path.Root("login"),
+			"Conflicting authentication options",
+			"login opens its own session and can't be combined with jwt_hashed_token or oauth_refresh_token. Configure only one of them.",
+		)
+	}
+	if authHeaderSet && (jwtSet || oauthSet) {
+		diags.AddAttributeError(
+			path.Root("headers"),
+			"Conflicting authentication options",
+			"A "+headerName+" header can't be set in headers along with jwt_hashed_token or oauth_refresh_token, "+
+				"which set it. Remove the header or the authentication option.",
+		)
+	}
+
+	return diags
+}
+
+// netrcCredentials sets the credentials of the uri host entry of the .netrc
+// file on the client options, as basic auth or as a token when the entry has
+// no login. The file is netrc_file, or $HOME/.netrc with use_netrc, in which
+// case a missing file is ignored. Nothing is read when another authentication
+// option is configured.
+func netrcCredentials(ctx context.Context, config *TrustbuilderProviderModel, configHeaders map[string]string, uri string, opt *apiclient.ApiClientOpt) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	netrcFile := config.NetrcFile.ValueString()
+	if netrcFile == "" && !config.UseNetrc.ValueBool() {
+		return diags
+	}
+	if !config.JwtHashedToken.IsNull() || !config.OauthRefreshToken.IsNull() || !config.Login.IsNull() || hasHeader(configHeaders, authHeaderName(config)) {
+		tflog.Debug(ctx, "netrc credentials ignored: another authentication option is configured")
+		return diags
+	}
+	if netrcFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			diags.AddAttributeError(path.Root("use_netrc"), "The home directory can't be found", err.Error())
+			return diags
+		}
+		netrcFile = filepath.Join(home, ".netrc")
+		if _, err := os.Stat(netrcFile); errors.Is(err, fs.ErrNotExist) {
+			tflog.Debug(ctx, "netrc credentials ignored: "+netrcFile+" doesn't exist")
+			return diags
+		}
+	}
+
+	parsedUri, err := url.Parse(uri)
+	if err != nil {
+		diags.AddAttributeError(path.Root("uri"), "The uri can't be parsed", err.Error())
+		return diags
+	}
+	entry, found, err := apiclient.ReadNetrc(netrcFile, parsedUri.Hostname())
+	if err != nil {
+		diags.AddAttributeError(path.Root("netrc_file"), "The netrc file can't be read", err.Error())
+		return diags
+	}
+	if !found {
+		tflog.Debug(ctx, "netrc credentials ignored: no entry for "+parsedUri.Hostname()+" in "+netrcFile)
+		return diags
+	}
+
+	if entry.Login == "" {
+		opt.Token = entry.Password
+	} else {
+		opt.Username = entry.Login
+		opt.Password = entry.Password
+	}
+	return diags
+}
+
+// statusMessagesByCode converts the status_messages keys to status codes.
+func statusMessagesByCode(ctx context.Context, statusMessages types.Map) (map[int]string, diag.Diagnostics) {
+	var messages map[string]string
+	diags := statusMessages.ElementsAs(ctx, &messages, false)
+	byCode := make(map[int]string, len(messages))
+	for code, message := range messages {
+		statusCode, err := strconv.Atoi(code)
+		if err != nil {
+			diags.AddAttributeError(path.Root("status_messages"), "Invalid status code", fmt.Sprintf("%q is not an HTTP status code", code))
+			continue
+		}
+		byCode[statusCode] = message
+	}
+	return byCode, diags
+}
+
+// expectedStatusSchema returns the schema of the status codes expected in the
+// responses of the requests of an operation.
+func expectedStatusSchema(operation string) schema.ListAttribute {
+	return schema.ListAttribute{
+		Description: "A list of the HTTP status codes of a successful " + operation + " response, e.g. `[201]`, any other code failing the request. Defaults to any 2xx code.",
+		ElementType: types.Int64Type,
+		Optional:    true,
+		Validators: []validator.List{
+			listvalidator.SizeAtLeast(1),
+			listvalidator.ValueInt64sAre(int64validator.Between(100, 599)),
+		},
+	}
+}
+
+// expectedStatusByOperation returns the expected status codes of the
+// operations configured with an *_expected_status attribute.
+func expectedStatusByOperation(ctx context.Context, config *TrustbuilderProviderModel) (map[apiclient.Operation][]int, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	byOperation := make(map[apiclient.Operation][]int)
+	for operation, statusList := range map[apiclient.Operation]types.List{
+		apiclient.OperationCreate:  config.CreateExpectedStatus,
+		apiclient.OperationRead:    config.ReadExpectedStatus,
+		apiclient.OperationUpdate:  config.UpdateExpectedStatus,
+		apiclient.OperationDestroy: config.DestroyExpectedStatus,
+	} {
+		var codes []int64
+		diags.Append(statusList.ElementsAs(ctx, &codes, false)...)
+		for _, code := range codes {
+			byOperation[operation] = append(byOperation[operation], int(code))
+		}
+	}
+	return byOperation, diags
+}
+
+// retryConditions returns the conditions of the retry_on attribute.
+func retryConditions(ctx context.Context, config *TrustbuilderProviderModel) ([]apiclient.RetryCondition, diag.Diagnostics) {
+	var retryOn []RetryOnModel
+	diags := config.RetryOn.ElementsAs(ctx, &retryOn, false)
+	conditions := make([]apiclient.RetryCondition, 0, len(retryOn))
+	for _, condition := range retryOn {
+		conditions = append(conditions, apiclient.RetryCondition{
+			StatusCode: int(condition.Status.ValueInt64()),
+			Predicate:  apiclient.JsonPredicate{Path: condition.Path.ValueString(), Value: condition.Value.ValueString()},
+		})
+	}
+	return conditions, diags
+}
+
+// authHeaderName returns the header set by the authentication options.
+func authHeaderName(config *TrustbuilderProviderModel) string {
+	if config.AuthHeaderName.ValueString() != "" {
+		return config.AuthHeaderName.ValueString()
+	}
+	return apiclient.DefaultAuthHeaderName
+}
+
+// hasHeader reports whether the headers hold the name, case insensitively.
+func hasHeader(headers map[string]string, name string) bool {
+	for n := range headers {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// oauthAuthMethodNames returns the supported oauth_refresh_token auth_method
+// values.
+func oauthAuthMethodNames() []string {
+	names := make([]string, 0, len(apiclient.OauthAuthMethods))
+	for _, method := range apiclient.OauthAuthMethods {
+		names = append(names, string(method))
+	}
+	return names
+}
+
+// jitterStrategyNames returns the supported retry_jitter values.
+func jitterStrategyNames() []string {
+	names := make([]string, 0, len(apiclient.JitterStrategies))
+	for _, strategy := range apiclient.JitterStrategies {
+		names = append(names, string(strategy))
+	}
+	return names
+}
+
+// versionHeaders returns the headers identifying the provider and Terraform
+// versions to the API. They can be overridden by the provider headers.
+func versionHeaders(headerName string, providerVersion string, terraformVersion string) map[string]string {
+	userAgent := "terraform-provider-trustbuilder/" + providerVersion
+	if terraformVersion != "" {
+		userAgent = "Terraform/" + terraformVersion + " " + userAgent
+	}
+
+	headers := map[string]string{
+		"User-Agent": userAgent,
+	}
+	if headerName != "" {
+		headers[headerName] = providerVersion
+	}
+	return headers
 }
 
 func (p *TrustbuilderProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewTenantResource,
+		NewJsonFieldResource,
 	}
 }
 
 func (p *TrustbuilderProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewObjectDataSource,
+		NewListFileDataSource,
+	}
 }
@@ -1,12 +1,25 @@
 package provider
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/trustbuilder/terraform-provider-trustbuilder/internal/apiclient"
 )
 
 const (
@@ -86,6 +99,305 @@ func TestProvider_basic(t *testing.T) {
 	}
 }
 
+func TestProvider_configModel(t *testing.T) {
+	ctx := context.Background()
+	schemaResp := &provider.SchemaResponse{}
+	New("test")().Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("Unexpected schema type: %v", schemaResp.Schema.Type())
+	}
+	nullAttributes := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		nullAttributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+	config := tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(objectType, nullAttributes),
+	}
+	var model TrustbuilderProviderModel
+	if diags := config.Get(ctx, &model); diags.HasError() {
+		t.Fatalf("The provider model doesn't match its schema: %v", diags)
+	}
+}
+
+func TestProvider_configureDebugOutput(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+	p := New("test")()
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	/* Returns the value of the object type with the given attributes, the others null */
+	objectValue := func(objectType tftypes.Object, attributes map[string]tftypes.Value) tftypes.Value {
+		values := map[string]tftypes.Value{}
+		for name, attributeType := range objectType.AttributeTypes {
+			values[name] = tftypes.NewValue(attributeType, nil)
+		}
+		for name, value := range attributes {
+			values[name] = value
+		}
+		return tftypes.NewValue(objectType, values)
+	}
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	jwtType := objectType.AttributeTypes["jwt_hashed_token"].(tftypes.Object)
+	config := tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw: objectValue(objectType, map[string]tftypes.Value{
+			"uri":   tftypes.NewValue(tftypes.String, "http://localhost:19090"),
+			"debug": tftypes.NewValue(tftypes.Bool, true),
+			"jwt_hashed_token": objectValue(jwtType, map[string]tftypes.Value{
+				"claims_json": tftypes.NewValue(tftypes.String, `{"sub": "terraform"}`),
+				"algorithm":   tftypes.NewValue(tftypes.String, "HS256"),
+				"secret":      tftypes.NewValue(tftypes.String, "jwt-s3cret"),
+			}),
+		}),
+	}
+
+	resp := &provider.ConfigureResponse{}
+	p.Configure(ctx, provider.ConfigureRequest{Config: config}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Configure returned errors: %v", resp.Diagnostics)
+	}
+	if !strings.Contains(output.String(), "Effective provider configuration") {
+		t.Errorf("The effective configuration is not logged:\n%s", output.String())
+	}
+	if strings.Contains(output.String(), "jwt-s3cret") {
+		t.Errorf("The JWT secret appears in the debug output:\n%s", output.String())
+	}
+}
+
+func TestProvider_versionHeaders(t *testing.T) {
+	headers := versionHeaders(defaultVersionHeaderName, "1.2.3", "1.11.0")
+	if headers["User-Agent"] != "Terraform/1.11.0 terraform-provider-trustbuilder/1.2.3" {
+		t.Errorf("Unexpected User-Agent: %s", headers["User-Agent"])
+	}
+	if headers[defaultVersionHeaderName] != "1.2.3" {
+		t.Errorf("Unexpected %s: %s", defaultVersionHeaderName, headers[defaultVersionHeaderName])
+	}
+
+	headers = versionHeaders("X-Client-Version", "test", "")
+	if headers["User-Agent"] != "terraform-provider-trustbuilder/test" {
+		t.Errorf("Unexpected User-Agent without Terraform version: %s", headers["User-Agent"])
+	}
+	if headers["X-Client-Version"] != "test" {
+		t.Errorf("Unexpected X-Client-Version: %s", headers["X-Client-Version"])
+	}
+	if _, ok := headers[defaultVersionHeaderName]; ok {
+		t.Errorf("%s should not be set when the header name is customized", defaultVersionHeaderName)
+	}
+}
+
+func TestProvider_validateAuthentication(t *testing.T) {
+	set := types.ObjectValueMust(map[string]attr.Type{}, map[string]attr.Value{})
+	unset := types.ObjectNull(map[string]attr.Type{})
+	tests := []struct {
+		jwt            types.Object
+		oauth          types.Object
+		authHeaderName types.String
+		headers        map[string]string
+		fails          bool
+	}{
+		{unset, unset, types.StringNull(), map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, false},
+		{set, unset, types.StringNull(), map[string]string{"X-Tenant": "tenant_1"}, false},
+		{unset, set, types.StringNull(), nil, false},
+		{set, set, types.StringNull(), nil, true},
+		{set, unset, types.StringNull(), map[string]string{"authorization": "Bearer token"}, true},
+		{unset, set, types.StringNull(), map[string]string{"Authorization": "Bearer token"}, true},
+		{set, unset, types.StringValue("X-Auth-Token"), map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, false},
+		{unset, set, types.StringValue("X-Auth-Token"), map[string]string{"x-auth-token": "token"}, true},
+	}
+
+	for i, test := range tests {
+		config := &TrustbuilderProviderModel{JwtHashedToken: test.jwt, OauthRefreshToken: test.oauth, AuthHeaderName: test.authHeaderName}
+		diags := validateAuthentication(config, test.headers)
+		if diags.HasError() != test.fails {
+			t.Errorf("Case %d: validateAuthentication returned errors: %t; want %t (%v)", i, diags.HasError(), test.fails, diags)
+		}
+	}
+
+	if diags := validateAuthentication(&TrustbuilderProviderModel{Login: set}, nil); diags.HasError() {
+		t.Errorf("validateAuthentication rejected a login alone: %v", diags)
+	}
+	if diags := validateAuthentication(&TrustbuilderProviderModel{Login: set, JwtHashedToken: set}, nil); !diags.HasError() {
+		t.Error("validateAuthentication should reject a login along with jwt_hashed_token")
+	}
+}
+
+func TestProvider_validateTlsSettings(t *testing.T) {
+	tests := []struct {
+		config   TrustbuilderProviderModel
+		warnings int
+	}{
+		{TrustbuilderProviderModel{PinnedCertSha256: types.StringValue("ab"), PinnedCertOnly: types.BoolValue(true)}, 0},
+		{TrustbuilderProviderModel{PinnedCertOnly: types.BoolValue(true)}, 1},
+		{TrustbuilderProviderModel{PinnedCertSha256: types.StringUnknown(), PinnedCertOnly: types.BoolValue(true)}, 0},
+		{TrustbuilderProviderModel{Pkcs12File: types.StringValue("client.p12"), Pkcs12Password: types.StringValue("secret"), CertReload: types.BoolValue(true)}, 0},
+		{TrustbuilderProviderModel{Pkcs12Password: types.StringValue("secret"), CertReload: types.BoolValue(true)}, 2},
+		{TrustbuilderProviderModel{CertReload: types.BoolValue(false)}, 0},
+	}
+
+	for i, test := range tests {
+		diags := validateTlsSettings(&test.config)
+		if diags.HasError() || diags.WarningsCount() != test.warnings {
+			t.Errorf("Case %d: validateTlsSettings returned the diagnostics %v; want %d warnings", i, diags, test.warnings)
+		}
+	}
+}
+
+func TestProvider_validateOauthClient(t *testing.T) {
+	tests := []struct {
+		clientId     types.String
+		clientSecret types.String
+		vaultSet     bool
+		fails        bool
+		warnings     int
+	}{
+		{types.StringValue("app"), types.StringValue("secret"), false, false, 0},
+		{types.StringNull(), types.StringNull(), false, false, 0},
+		{types.StringValue("app"), types.StringNull(), false, false, 1},
+		{types.StringValue("app"), types.StringNull(), true, false, 0},
+		{types.StringNull(), types.StringValue("secret"), false, true, 0},
+		{types.StringUnknown(), types.StringValue("secret"), false, false, 0},
+	}
+
+	for i, test := range tests {
+		diags := validateOauthClient(&OauthRefreshTokenModel{ClientID: test.clientId, ClientSecret: test.clientSecret}, test.vaultSet)
+		if diags.HasError() != test.fails || diags.WarningsCount() != test.warnings {
+			t.Errorf("Case %d: validateOauthClient returned the diagnostics %v; want errors: %t, %d warnings", i, diags, test.fails, test.warnings)
+		}
+	}
+
+	/* tls_client_auth authenticates the client id by its certificate, without secret */
+	tlsClientAuth := types.StringValue("tls_client_auth")
+	if diags := validateOauthClient(&OauthRefreshTokenModel{ClientID: types.StringValue("app"), ClientSecret: types.StringNull(), AuthMethod: tlsClientAuth}, false); diags.HasError() || diags.WarningsCount() > 0 {
+		t.Errorf("tls_client_auth without secret should be valid, got: %v", diags)
+	}
+	if diags := validateOauthClient(&OauthRefreshTokenModel{ClientID: types.StringValue("app"), ClientSecret: types.StringValue("secret"), AuthMethod: tlsClientAuth}, false); !diags.HasError() {
+		t.Error("tls_client_auth should reject a client secret")
+	}
+	if diags := validateOauthClient(&OauthRefreshTokenModel{ClientID: types.StringNull(), ClientSecret: types.StringNull(), AuthMethod: tlsClientAuth}, false); !diags.HasError() {
+		t.Error("tls_client_auth should require a client id")
+	}
+}
+
+func TestProvider_readVaultSecret(t *testing.T) {
+	reads := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reads++
+		fmt.Fprint(w, `{"data":{"data":{"jwt_secret":"s3cr3t"},"metadata":{"version":1}}}`)
+	}))
+	defer svr.Close()
+
+	p := &TrustbuilderProvider{}
+	opt := &apiclient.VaultOpt{Address: svr.URL, Token: "root-token", Path: "secret/data/trustbuilder", Key: "jwt_secret"}
+	for i := 0; i < 2; i++ {
+		secret, err := p.readVaultSecret(context.Background(), opt)
+		if err != nil || secret != "s3cr3t" {
+			t.Fatalf("readVaultSecret returned '%s' and the error: %v", secret, err)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("readVaultSecret sent %d requests to Vault; want 1", reads)
+	}
+}
+
+func TestProvider_expectedStatusByOperation(t *testing.T) {
+	ctx := context.Background()
+	config := &TrustbuilderProviderModel{
+		CreateExpectedStatus:  types.ListValueMust(types.Int64Type, []attr.Value{types.Int64Value(201)}),
+		ReadExpectedStatus:    types.ListNull(types.Int64Type),
+		UpdateExpectedStatus:  types.ListNull(types.Int64Type),
+		DestroyExpectedStatus: types.ListValueMust(types.Int64Type, []attr.Value{types.Int64Value(204), types.Int64Value(404)}),
+	}
+
+	expectedStatus, diags := expectedStatusByOperation(ctx, config)
+	if diags.HasError() {
+		t.Fatalf("expectedStatusByOperation returned errors: %v", diags)
+	}
+	expected := map[apiclient.Operation][]int{
+		apiclient.OperationCreate:  {201},
+		apiclient.OperationDestroy: {204, 404},
+	}
+	if fmt.Sprint(expectedStatus) != fmt.Sprint(expected) {
+		t.Errorf("expectedStatusByOperation() = %v; want %v", expectedStatus, expected)
+	}
+}
+
+func TestProvider_netrcCredentials(t *testing.T) {
+	ctx := context.Background()
+	netrcFile := filepath.Join(t.TempDir(), ".netrc")
+	content := "machine api.example.com login terraform password s3cret\nmachine token.example.com password t0ken\n"
+	if err := os.WriteFile(netrcFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("Writing the netrc file failed: %s", err)
+	}
+	set := types.ObjectValueMust(map[string]attr.Type{}, map[string]attr.Value{})
+
+	tests := []struct {
+		uri      string
+		jwt      types.Object
+		expected apiclient.ApiClientOpt
+	}{
+		{"https://api.example.com/v1", types.ObjectNull(map[string]attr.Type{}), apiclient.ApiClientOpt{Username: "terraform", Password: "s3cret"}},
+		{"https://token.example.com:8443", types.ObjectNull(map[string]attr.Type{}), apiclient.ApiClientOpt{Token: "t0ken"}},
+		{"https://unknown.example.com", types.ObjectNull(map[string]attr.Type{}), apiclient.ApiClientOpt{}},
+		{"https://api.example.com/v1", set, apiclient.ApiClientOpt{}},
+	}
+
+	for i, test := range tests {
+		config := &TrustbuilderProviderModel{NetrcFile: types.StringValue(netrcFile), JwtHashedToken: test.jwt}
+		opt := &apiclient.ApiClientOpt{}
+		if diags := netrcCredentials(ctx, config, nil, test.uri, opt); diags.HasError() {
+			t.Fatalf("Case %d: netrcCredentials returned errors: %v", i, diags)
+		}
+		if opt.Username != test.expected.Username || opt.Password != test.expected.Password || opt.Token != test.expected.Token {
+			t.Errorf("Case %d: credentials = %q/%q/%q; want %q/%q/%q", i, opt.Username, opt.Password, opt.Token,
+				test.expected.Username, test.expected.Password, test.expected.Token)
+		}
+	}
+
+	config := &TrustbuilderProviderModel{NetrcFile: types.StringValue(filepath.Join(t.TempDir(), "missing"))}
+	if diags := netrcCredentials(ctx, config, nil, "https://api.example.com", &apiclient.ApiClientOpt{}); !diags.HasError() {
+		t.Error("netrcCredentials should fail on a missing netrc_file")
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	config = &TrustbuilderProviderModel{UseNetrc: types.BoolValue(true)}
+	if diags := netrcCredentials(ctx, config, nil, "https://api.example.com", &apiclient.ApiClientOpt{}); diags.HasError() {
+		t.Errorf("netrcCredentials should ignore a missing $HOME/.netrc: %v", diags)
+	}
+}
+
+func TestProvider_checkTestPaths(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" || r.URL.Path == "/auth" {
+			fmt.Fprint(w, "{}")
+			return
+		}
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer svr.Close()
+
+	client, err := apiclient.NewAPIClient(&apiclient.ApiClientOpt{Uri: svr.URL, Timeout: 2, RateLimit: 10})
+	if err != nil {
+		t.Fatalf("API client creation failed: %s", err)
+	}
+
+	if diags := checkTestPaths(context.Background(), client, []string{"/auth", "/health"}); diags.HasError() {
+		t.Errorf("checkTestPaths returned errors on healthy paths: %v", diags)
+	}
+	diags := checkTestPaths(context.Background(), client, []string{"/auth", "/data", "/health", "/metrics"})
+	if diags.ErrorsCount() != 2 {
+		t.Fatalf("checkTestPaths returned %d errors; want one per failing path: %v", diags.ErrorsCount(), diags)
+	}
+	for i, failing := range []string{"/data", "/metrics"} {
+		if !strings.Contains(diags.Errors()[i].Detail(), failing) {
+			t.Errorf("Error %d should name the path %s: %s", i, failing, diags.Errors()[i].Detail())
+		}
+	}
+}
+
 func createProviderServer(provider provider.Provider) (tfprotov6.ProviderServer, error) {
 	providerServerFunc := providerserver.NewProtocol6WithError(provider)
 	return providerServerFunc()